@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"bytes"
+	"go/format"
+)
+
+// AnalyzeAllInput represents the input for running every basic analysis at once
+type AnalyzeAllInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// AnalyzeAllOutput represents the combined results of AnalyzeAll. The parts
+// are what AnalyzeCode, FormatCode, GetSymbols and CalculateMetrics return
+// for the code with their default options.
+type AnalyzeAllOutput struct {
+	Success     bool                    `json:"success"` // Whether the code parsed and every analysis ran; see Vet for issues found
+	Vet         *AnalyzeCodeOutput      `json:"vet,omitempty"`
+	Format      *FormatCodeOutput       `json:"format,omitempty"`
+	Symbols     *GetSymbolsOutput       `json:"symbols,omitempty"`
+	Metrics     *CalculateMetricsOutput `json:"metrics,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+	Diagnostics []Diagnostic            `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// AnalyzeAll runs go vet, gofmt, symbol extraction and metrics on code in
+// one call. The code is parsed once, and the same AST feeds the symbols, the
+// metrics and the formatter, which prints it with go/format instead of
+// parsing the code again. go vet needs the code on disk and runs as in
+// AnalyzeCode, including its cache, while the rest is computed.
+func AnalyzeAll(code string) (*AnalyzeAllOutput, error) {
+	if err := checkSource(code); err != nil {
+		return nil, err
+	}
+	normalized, info := normalizeSource(code)
+	sources, fset, err := parseSources(normalized, nil, false)
+	if sources == nil {
+		return &AnalyzeAllOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	type vetResult struct {
+		output *AnalyzeCodeOutput
+		err    error
+	}
+	vetDone := make(chan vetResult, 1)
+	go func() {
+		output, err := AnalyzeCode(AnalyzeCodeInput{Code: code})
+		vetDone <- vetResult{output, err}
+	}()
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, sources[0].AST); err != nil {
+		<-vetDone
+		return nil, err
+	}
+	formatted := info.restore(buf.String())
+	result := &AnalyzeAllOutput{
+		Success: true,
+		Format: &FormatCodeOutput{
+			Success:       true,
+			FormattedCode: formatted,
+			Changed:       formatted != code,
+			Formatter:     FormatterGofmt,
+			BOM:           info.bom,
+			CRLF:          info.crlf,
+		},
+	}
+
+	symbols := []Symbol{}
+	result.Symbols, _ = emitSymbols(sources, fset, false, "", nil, lineScope{}, func(sym Symbol) error {
+		symbols = append(symbols, sym)
+		return nil
+	})
+	result.Symbols.Symbols = symbols
+	result.Metrics = measureSources(sources, fset, CalculateMetricsInput{Code: normalized}, "", lineScope{}, DefaultComplexityThreshold, DefaultHistogramBounds())
+
+	vet := <-vetDone
+	if vet.err != nil {
+		return nil, vet.err
+	}
+	result.Vet = vet.output
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+const analyzeAllSample = `package sample
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Greeter builds greetings
+type Greeter struct {
+	prefix string
+}
+
+// Greet greets name, shouting when asked
+func (g *Greeter) Greet(name string, shout bool) string {
+	msg := g.prefix + " " + name
+	if shout {
+		msg = strings.ToUpper(msg)
+	}
+	for i := 0; i < 2; i++ {
+		if i > 0 && shout {
+			msg += "!"
+		}
+	}
+	return msg
+}
+
+func main() {
+	g := &Greeter{prefix: "hello"}
+	fmt.Printf("%d\n", g.Greet("world", true)) // TODO: fix the verb
+}
+`
+
+// withoutCache disables the result cache for the duration of a test or
+// benchmark, so every call does the work
+func withoutCache(tb testing.TB) {
+	old := results
+	results = newResultCache(0)
+	tb.Cleanup(func() { results = old })
+}
+
+// analyzeSeparately makes the four calls that AnalyzeAll replaces
+func analyzeSeparately(tb testing.TB, code string) *AnalyzeAllOutput {
+	vet, err := AnalyzeCode(AnalyzeCodeInput{Code: code})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	format, err := FormatCode(FormatCodeInput{Code: code})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	symbols, err := GetSymbols(GetSymbolsInput{Code: code})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	metrics, err := CalculateMetrics(CalculateMetricsInput{Code: code})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return &AnalyzeAllOutput{Success: true, Vet: vet, Format: format, Symbols: symbols, Metrics: metrics}
+}
+
+func TestAnalyzeAllMatchesSeparateCalls(t *testing.T) {
+	withoutCache(t)
+	tests := []struct {
+		name string
+		code string
+	}{
+		{name: "sample", code: analyzeAllSample},
+		{name: "formatted", code: "package p\n\nfunc f() {}\n"},
+		{name: "CRLF", code: "package p\r\n\r\nfunc  f() {}\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AnalyzeAll(tt.code)
+			if err != nil {
+				t.Fatalf("AnalyzeAll: %v", err)
+			}
+			want := analyzeSeparately(t, tt.code)
+			if !got.Success {
+				t.Fatalf("AnalyzeAll failed: %s", got.Error)
+			}
+			for _, part := range []struct {
+				name      string
+				got, want any
+			}{
+				{"vet", got.Vet, want.Vet},
+				{"format", got.Format, want.Format},
+				{"symbols", got.Symbols, want.Symbols},
+				{"metrics", got.Metrics, want.Metrics},
+			} {
+				if !reflect.DeepEqual(part.got, part.want) {
+					t.Errorf("%s = %+v, want %+v", part.name, part.got, part.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeAllSyntaxError(t *testing.T) {
+	result, err := AnalyzeAll("package p\nfunc {")
+	if err != nil {
+		t.Fatalf("AnalyzeAll: %v", err)
+	}
+	if result.Success || len(result.Diagnostics) == 0 {
+		t.Errorf("AnalyzeAll = %+v, want a failure with diagnostics", result)
+	}
+}
+
+func BenchmarkAnalyzeAll(b *testing.B) {
+	withoutCache(b)
+	for b.Loop() {
+		if _, err := AnalyzeAll(analyzeAllSample); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAnalyzeSeparately is the baseline for BenchmarkAnalyzeAll: the
+// same analyses as four calls
+func BenchmarkAnalyzeSeparately(b *testing.B) {
+	withoutCache(b)
+	for b.Loop() {
+		analyzeSeparately(b, analyzeAllSample)
+	}
+}
@@ -1,67 +1,396 @@
+// Package analyzer implements the Go code analyses behind the MCP tools and
+// the HTTP API.
+//
+// Its functions are safe for concurrent use. They never change the process
+// environment or working directory: subprocesses get a copy of the
+// environment with their own overrides, from subprocessEnv. The state they
+// share, the result cache, the scratch directory pool and the standard
+// library importer, is synchronized. MaxInputSize, SourceRoot and
+// GoimportsPath are configuration, to be set before the first analysis.
 package analyzer
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
+	"go/scanner"
 	"go/token"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 // AnalyzeCodeInput represents the input for code analysis
 type AnalyzeCodeInput struct {
-	Code     string `json:"code" jsonschema:"Go source code to analyze"`
-	FileName string `json:"fileName,omitempty" jsonschema:"Optional filename for context (default: temp.go)"`
+	Code          string         `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath      string         `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Path          string         `json:"path,omitempty" jsonschema:"Alternative to code: a Go file or package directory on disk within the source root, vetted in place with its own go.mod"`
+	FileName      string         `json:"fileName,omitempty" jsonschema:"Optional filename for context, without a directory (default: temp.go)"`
+	VetFlags      []string       `json:"vetFlags,omitempty" jsonschema:"Optional go vet analyzer flags, e.g. '-printf=false' or '-printf.funcs=Logf'"`
+	VetChecks     []string       `json:"vetChecks,omitempty" jsonschema:"Optional go vet analyzers to run instead of all of them, e.g. 'structtag'"`
+	BuildTags     []string       `json:"buildTags,omitempty" jsonschema:"Optional build tags to satisfy //go:build constraints, e.g. 'integration'"`
+	GOOS          string         `json:"goos,omitempty" jsonschema:"Optional target operating system, e.g. 'linux' or 'windows'"`
+	GOARCH        string         `json:"goarch,omitempty" jsonschema:"Optional target architecture, e.g. 'amd64' or 'arm64'"`
+	ModuleContext *ModuleContext `json:"moduleContext,omitempty" jsonschema:"Optional module to analyze the code in, so third-party imports resolve"`
+	NoCache       bool           `json:"noCache,omitempty" jsonschema:"Run the analysis again instead of returning the cached result of an identical earlier request"`
+	Verbose       bool           `json:"verbose,omitempty" jsonschema:"Also return the exit code and raw stdout and stderr of go vet, for debugging"`
+}
+
+// ModuleContext describes the module the analyzed code belongs to. The temp
+// directory vet runs in becomes that module.
+type ModuleContext struct {
+	GoMod string `json:"goMod,omitempty" jsonschema:"Contents of go.mod (default: a module named tmp)"`
+	GoSum string `json:"goSum,omitempty" jsonschema:"Contents of go.sum"`
+	Tidy  bool   `json:"tidy,omitempty" jsonschema:"Run go mod tidy first, using only modules already in the local module cache"`
 }
 
 // AnalyzeCodeOutput represents the result of code analysis
 type AnalyzeCodeOutput struct {
-	Success     bool         `json:"success"`
-	Diagnostics []Diagnostic `json:"diagnostics"`
-	ErrorCount  int          `json:"error_count"`
-	WarningCount int          `json:"warning_count"`
+	Success      bool          `json:"success"`
+	Diagnostics  []Diagnostic  `json:"diagnostics"`
+	ErrorCount   int           `json:"error_count"`
+	WarningCount int           `json:"warning_count"`
+	ExitCode     *int          `json:"exit_code,omitempty"`  // Exit status of go vet, only with Verbose
+	RawOutput    *RawVetOutput `json:"raw_output,omitempty"` // What go vet wrote, only with Verbose
+}
+
+// RawVetOutput is what a go vet run wrote, with the scratch directory
+// removed from paths as in diagnostics
+type RawVetOutput struct {
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
 }
 
 // Diagnostic represents a single diagnostic message
 type Diagnostic struct {
-	File     string `json:"file"`
-	Line     int    `json:"line"`
-	Column   int    `json:"column"`
-	Message  string `json:"message"`
-	Severity string `json:"severity"` // "error" or "warning"
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Column       int    `json:"column"`
+	Message      string `json:"message"`
+	Severity     string `json:"severity"`                // "error" or "warning"
+	Check        string `json:"check,omitempty"`         // Check that reported the diagnostic, when known: a vet analyzer such as "printf", or "typecheck" for compile errors
+	SuggestedFix string `json:"suggested_fix,omitempty"` // Replacement code for the flagged construct, when one can be given
+	Fingerprint  string `json:"fingerprint,omitempty"`   // Stable across edits that only move the diagnostic
+}
+
+// vetAnalyzers lists the analyzers built into go vet. Only flags naming one of
+// these are passed through to the subprocess. The shadow analyzer is not among
+// them: it was removed from go vet and needs an external -vettool.
+var vetAnalyzers = map[string]bool{
+	"appends": true, "asmdecl": true, "assign": true, "atomic": true,
+	"bools": true, "buildtag": true, "cgocall": true, "composites": true,
+	"copylocks": true, "defers": true, "directive": true, "errorsas": true,
+	"framepointer": true, "hostport": true, "httpresponse": true, "ifaceassert": true,
+	"loopclosure": true, "lostcancel": true, "nilfunc": true, "printf": true,
+	"shift": true, "sigchanyzer": true, "slog": true, "stdmethods": true,
+	"stdversion": true, "stringintconv": true, "structtag": true, "testinggoroutine": true,
+	"tests": true, "timeformat": true, "unmarshal": true, "unreachable": true,
+	"unsafeptr": true, "unusedresult": true, "waitgroup": true,
+}
+
+// VetAnalyzers returns the names of the analyzers built into go vet, sorted
+func VetAnalyzers() []string {
+	names := make([]string, 0, len(vetAnalyzers))
+	for name := range vetAnalyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateFileName checks that fileName is a bare file name, since it names
+// the file written into a scratch directory and must not lead out of it
+func validateFileName(fileName string) error {
+	if filepath.Base(fileName) != fileName || fileName == "." || fileName == ".." {
+		return fmt.Errorf("fileName %q must not contain a directory", fileName)
+	}
+	return nil
+}
+
+// validateVetFlags checks that every flag is of the form -analyzer,
+// -analyzer=true|false or -analyzer.option=value for a known analyzer, so
+// callers cannot inject arbitrary flags into the go vet subprocess
+func validateVetFlags(flags []string) error {
+	for _, flag := range flags {
+		name := strings.TrimPrefix(strings.TrimPrefix(flag, "-"), "-")
+		if name == flag || name == "" {
+			return fmt.Errorf("invalid vet flag %q: flags must start with '-'", flag)
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		analyzer, option, hasOption := strings.Cut(name, ".")
+		if !vetAnalyzers[analyzer] {
+			return fmt.Errorf("invalid vet flag %q: %q is not a go vet analyzer", flag, analyzer)
+		}
+		if hasOption && option == "" {
+			return fmt.Errorf("invalid vet flag %q: missing analyzer option name", flag)
+		}
+		if !hasOption && hasValue && value != "true" && value != "false" {
+			return fmt.Errorf("invalid vet flag %q: analyzer flags accept only true or false", flag)
+		}
+	}
+	return nil
+}
+
+// validateVetChecks checks that every name is a go vet analyzer
+func validateVetChecks(checks []string) error {
+	for _, check := range checks {
+		if check == "shadow" {
+			return fmt.Errorf("invalid vet check %q: shadow is not built into go vet; use find_shadowed_variables", check)
+		}
+		if !vetAnalyzers[check] {
+			return fmt.Errorf("invalid vet check %q: not a go vet analyzer", check)
+		}
+	}
+	return nil
+}
+
+// vetChecksFlags returns the flags restricting go vet to checks. Enabling an
+// analyzer explicitly disables every analyzer not enabled.
+func vetChecksFlags(checks []string) []string {
+	flags := make([]string, 0, len(checks))
+	for _, check := range checks {
+		flags = append(flags, "-"+check)
+	}
+	return flags
+}
+
+// validateBuildContext checks build tags and the GOOS/GOARCH values before they
+// reach the go vet command line and environment
+func validateBuildContext(tags []string, goos, goarch string) error {
+	for _, tag := range tags {
+		if tag == "" || strings.TrimFunc(tag, isBuildTagRune) != "" {
+			return fmt.Errorf("invalid build tag %q: tags may contain only letters, digits, '_' and '.'", tag)
+		}
+	}
+	for name, value := range map[string]string{"GOOS": goos, "GOARCH": goarch} {
+		if strings.TrimFunc(value, isBuildTagRune) != "" || strings.Contains(value, ".") {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+	}
+	return nil
+}
+
+// buildTagsFlag returns the go command flag selecting tags, if there are any
+func buildTagsFlag(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(tags, ",")}
+}
+
+// untrustedCodeEnv holds the environment overrides of go commands run on
+// submitted code, snippets and uploaded modules alike. Cgo is disabled so
+// that #include directives cannot pull host files into the diagnostics, and
+// the go command keeps to the local toolchain and module cache, so that a
+// submitted go.mod cannot make the server download toolchains or modules.
+var untrustedCodeEnv = []string{"CGO_ENABLED=0", "GOTOOLCHAIN=local", "GOPROXY=off", "GOFLAGS=-mod=mod"}
+
+// setTargetEnv overrides GOOS and GOARCH in the environment of cmd when
+// given, after the variables of env
+func setTargetEnv(cmd *exec.Cmd, goos, goarch string, env ...string) {
+	vars := slices.Clone(env)
+	if goos != "" {
+		vars = append(vars, "GOOS="+goos)
+	}
+	if goarch != "" {
+		vars = append(vars, "GOARCH="+goarch)
+	}
+	if len(vars) > 0 {
+		cmd.Env = subprocessEnv(vars...)
+	}
+}
+
+// subprocessEnv returns a copy of the process environment with vars, of the
+// form key=value, appended so that they take precedence. Analyses set their
+// subprocess variables this way rather than with os.Setenv, which would race
+// with concurrent analyses.
+func subprocessEnv(vars ...string) []string {
+	return append(os.Environ(), vars...)
+}
+
+// targetBuildContext returns the default build context with tags added and
+// GOOS/GOARCH overridden when given. Cgo is assumed off when cross-compiling,
+// as the go command does.
+func targetBuildContext(tags []string, goos, goarch string) build.Context {
+	ctx := build.Default
+	ctx.BuildTags = append(ctx.BuildTags[:len(ctx.BuildTags):len(ctx.BuildTags)], tags...)
+	if goos != "" && goos != ctx.GOOS {
+		ctx.GOOS = goos
+		ctx.CgoEnabled = false
+	}
+	if goarch != "" && goarch != ctx.GOARCH {
+		ctx.GOARCH = goarch
+		ctx.CgoEnabled = false
+	}
+	return ctx
+}
+
+// isBuildTagRune reports whether r may appear in a build tag
+func isBuildTagRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// AnalyzeCode runs go vet on the provided code. Build tags are passed with
+// -tags and GOOS/GOARCH override the environment of the vet subprocess, so
+// files guarded by build constraints can be analyzed for their target. At
+// most one analysis per CPU runs at a time; further calls wait their turn.
+// When input.Path is set instead of input.Code, the file or package it names
+// is vetted where it is, see analyzePath. Results for code are cached by
+// input, so an identical request does not run go vet again unless
+// input.NoCache is set; files on disk may change, so paths are never cached.
+// Code read from input.FilePath is cached by its contents, under the file's
+// base name unless input.FileName is set.
+func AnalyzeCode(input AnalyzeCodeInput) (*AnalyzeCodeOutput, error) {
+	var err error
+	input.Code, input.FileName, err = loadInputFile(input.Code, input.FileName, input.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	input.FilePath = ""
+	if err := checkSource(input.Code); err != nil {
+		return nil, err
+	}
+	if input.Path != "" {
+		return analyzeCode(input)
+	}
+	refresh := input.NoCache
+	input.NoCache = false
+	return cachedResult("analyze_code", input, refresh, analyzeCode)
 }
 
-// AnalyzeCode runs go vet on the provided code
-func AnalyzeCode(code, fileName string) (*AnalyzeCodeOutput, error) {
+// analyzeCode implements AnalyzeCode without the cache
+func analyzeCode(input AnalyzeCodeInput) (*AnalyzeCodeOutput, error) {
+	code, _ := normalizeSource(input.Code)
+	fileName := input.FileName
 	if fileName == "" {
 		fileName = "temp.go"
 	}
+	if err := validateFileName(fileName); err != nil {
+		return nil, err
+	}
+
+	if err := validateVetFlags(input.VetFlags); err != nil {
+		return nil, err
+	}
+	if err := validateVetChecks(input.VetChecks); err != nil {
+		return nil, err
+	}
+	if err := validateBuildContext(input.BuildTags, input.GOOS, input.GOARCH); err != nil {
+		return nil, err
+	}
+	if input.Path != "" {
+		if input.Code != "" {
+			return nil, errors.New("provide either code or path, not both")
+		}
+		if input.ModuleContext != nil {
+			return nil, errors.New("moduleContext cannot be used with path; the module containing path is used")
+		}
+		return analyzePath(input)
+	}
 
-	// Create temp file
-	tempDir, err := os.MkdirTemp("", "go-analyzer-*")
+	// Create temp file in a pooled scratch directory, waiting for one if
+	// the maximum number of analyses is already running
+	tempDir, release, err := workDirs.acquire()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		return nil, err
 	}
-	defer os.RemoveAll(tempDir)
+	defer release()
 
 	tempFile := filepath.Join(tempDir, fileName)
 	if err := os.WriteFile(tempFile, []byte(code), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write temp file: %w", err)
 	}
 
-	// Run go vet
-	cmd := exec.Command("go", "vet", tempFile)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	if input.ModuleContext != nil {
+		if err := prepareModule(tempDir, input.ModuleContext); err != nil {
+			return nil, err
+		}
+	}
 
-	_ = cmd.Run() // Ignore exit code, we'll parse stderr
+	// Run go vet in the temp dir so it reports paths relative to it
+	args := append(buildTagsFlag(input.BuildTags), input.VetFlags...)
+	args = append(args, vetChecksFlags(input.VetChecks)...)
+	diagnostics, run := runVet(tempDir, append(args, tempFile), input.GOOS, input.GOARCH, untrustedCodeEnv...)
+	hideScratchDir(diagnostics, tempDir)
+	fingerprintDiagnostics(diagnostics, "vet", func(file string) string {
+		if file == fileName {
+			return code
+		}
+		return ""
+	})
 
-	// Parse diagnostics
-	diagnostics := parseVetOutput(stderr.String())
+	result := newAnalyzeCodeOutput(diagnostics)
+	if input.Verbose {
+		run.stdout = redactScratchDir(run.stdout, tempDir)
+		run.stderr = redactScratchDir(run.stderr, tempDir)
+		run.addTo(result)
+	}
+	return result, nil
+}
 
+// analyzePath runs go vet on a file or a package directory in place, inside
+// the module that contains it, without copying it to a scratch directory.
+// Diagnostic file names are relative to the directory. Like filePath, the
+// path must lie within SourceRoot. The analysis still takes one of the slots
+// bounding concurrent go vet runs.
+func analyzePath(input AnalyzeCodeInput) (*AnalyzeCodeOutput, error) {
+	path, err := resolveSourcePath("path", input.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, target := path, "."
+	if !info.IsDir() {
+		if filepath.Ext(path) != ".go" {
+			return nil, fmt.Errorf("%s is neither a Go file nor a directory", input.Path)
+		}
+		dir, target = filepath.Dir(path), filepath.Base(path)
+	}
+
+	_, release, err := workDirs.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	args := append(buildTagsFlag(input.BuildTags), input.VetFlags...)
+	args = append(args, vetChecksFlags(input.VetChecks)...)
+	diagnostics, run := runVet(dir, append(args, target), input.GOOS, input.GOARCH)
+	sources := map[string]string{}
+	fingerprintDiagnostics(diagnostics, "vet", func(file string) string {
+		if src, ok := sources[file]; ok {
+			return src
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		sources[file] = string(data)
+		return sources[file]
+	})
+
+	result := newAnalyzeCodeOutput(diagnostics)
+	if input.Verbose {
+		run.addTo(result)
+	}
+	return result, nil
+}
+
+// newAnalyzeCodeOutput counts diagnostics by severity into an AnalyzeCodeOutput
+func newAnalyzeCodeOutput(diagnostics []Diagnostic) *AnalyzeCodeOutput {
 	errorCount := 0
 	warningCount := 0
 	for _, diag := range diagnostics {
@@ -77,40 +406,467 @@ func AnalyzeCode(code, fileName string) (*AnalyzeCodeOutput, error) {
 		Diagnostics:  diagnostics,
 		ErrorCount:   errorCount,
 		WarningCount: warningCount,
-	}, nil
+	}
 }
 
-// parseVetOutput parses go vet stderr output into diagnostics
-func parseVetOutput(output string) []Diagnostic {
-	if output == "" {
-		return []Diagnostic{}
+// prepareModule turns dir into the module described by mc, writing go.mod and
+// go.sum and optionally running go mod tidy. Tidy uses the local module cache
+// as its proxy and skips the checksum database, so it never needs the network.
+// go.mod is submitted code, so the go commands run in untrustedCodeEnv.
+func prepareModule(dir string, mc *ModuleContext) error {
+	goMod := mc.GoMod
+	if goMod == "" {
+		cmd := exec.Command("go", "mod", "init", "tmp")
+		cmd.Dir = dir
+		cmd.Env = subprocessEnv(untrustedCodeEnv...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("go mod init failed: %v - %s", err, out)
+		}
+	} else if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		return fmt.Errorf("failed to write go.mod: %w", err)
 	}
 
-	// go vet output format: "file:line:column: message"
-	lines := bytes.Split([]byte(output), []byte("\n"))
-	diagnostics := []Diagnostic{}
+	if mc.GoSum != "" {
+		if err := os.WriteFile(filepath.Join(dir, "go.sum"), []byte(mc.GoSum), 0644); err != nil {
+			return fmt.Errorf("failed to write go.sum: %w", err)
+		}
+	}
+
+	if mc.Tidy {
+		modCache, err := exec.Command("go", "env", "GOMODCACHE").Output()
+		if err != nil {
+			return fmt.Errorf("failed to locate the module cache: %w", err)
+		}
+		proxy := "file://" + filepath.ToSlash(filepath.Join(strings.TrimSpace(string(modCache)), "cache", "download"))
 
-	for _, line := range lines {
-		if len(line) == 0 {
+		cmd := exec.Command("go", "mod", "tidy", "-e")
+		cmd.Dir = dir
+		cmd.Env = subprocessEnv(append(slices.Clone(untrustedCodeEnv), "GOPROXY="+proxy, "GOSUMDB=off")...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("go mod tidy failed: %v - %s", err, out)
+		}
+	}
+
+	return nil
+}
+
+// vetPositionPattern matches the "file:line:column: message" form of go vet
+// and type-checker output, with an optional "vet: " prefix
+var vetPositionPattern = regexp.MustCompile(`^(?:vet: )?(.+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// vetFinding is one diagnostic in the output of go vet -json
+type vetFinding struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+}
+
+// runVet runs go vet -json with args in dir, for the target platform when
+// goos or goarch is given. Findings are tagged with the analyzer that
+// reported them and errors that stopped vet, such as compile errors, with
+// "typecheck". The diagnostics are sorted by position, with file names
+// relative to dir. env holds further environment overrides, such as
+// untrustedCodeEnv. The exit code and output of the run are returned along
+// with the diagnostics, which are parsed from the output whatever the exit
+// code.
+func runVet(dir string, args []string, goos, goarch string, env ...string) ([]Diagnostic, vetRun) {
+	cmd := exec.Command("go", append([]string{"vet", "-json"}, args...)...)
+	cmd.Dir = dir
+	setTargetEnv(cmd, goos, goarch, env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	run := vetRun{}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			run.exitCode = exitErr.ExitCode()
+		} else {
+			// go did not run, so there is no exit code of its own
+			run.exitCode = -1
+			stderr.WriteString(err.Error() + "\n")
+		}
+	}
+	run.stdout, run.stderr = stdout.String(), stderr.String()
+
+	diagnostics := append(parseVetOutput(stderr.String(), dir), parseVetJSON(stdout.Bytes(), dir)...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return diagnostics, run
+}
+
+// vetRun holds the exit code and raw output of a go vet run
+type vetRun struct {
+	exitCode       int
+	stdout, stderr string
+}
+
+// addTo sets the exit code and raw output of result from the run
+func (run vetRun) addTo(result *AnalyzeCodeOutput) {
+	result.ExitCode = &run.exitCode
+	result.RawOutput = &RawVetOutput{Stdout: run.stdout, Stderr: run.stderr}
+}
+
+// parseVetOutput parses go vet stderr output into diagnostics. File names are
+// made relative to baseDir; "# package" header lines are skipped. Lines with
+// a position are compile errors, tagged "typecheck".
+func parseVetOutput(output, baseDir string) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" || strings.HasPrefix(line, "# ") {
 			continue
 		}
+		diag := parseVetLine(line, baseDir)
+		if diag.File != "" {
+			diag.Check = "typecheck"
+		}
+		diagnostics = append(diagnostics, diag)
+	}
+	return diagnostics
+}
 
-		// Simple parsing - can be enhanced
-		diagnostics = append(diagnostics, Diagnostic{
-			Message:  string(line),
+// parseVetJSON parses the JSON that go vet -json writes to stdout, one object
+// per package mapping analyzer names to their findings, into diagnostics
+// tagged with the analyzer name. File names are made relative to baseDir.
+func parseVetJSON(output []byte, baseDir string) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var packages map[string]map[string]json.RawMessage
+		if err := decoder.Decode(&packages); err != nil {
+			break
+		}
+		for _, analyzers := range packages {
+			for analyzer, raw := range analyzers {
+				var findings []vetFinding
+				if err := json.Unmarshal(raw, &findings); err != nil {
+					// An analyzer that failed reports {"error": "..."} instead
+					var failure struct {
+						Error string `json:"error"`
+					}
+					if json.Unmarshal(raw, &failure) == nil && failure.Error != "" {
+						diagnostics = append(diagnostics, Diagnostic{
+							Message:  analyzer + ": " + failure.Error,
+							Severity: "error",
+							Check:    analyzer,
+						})
+					}
+					continue
+				}
+				for _, finding := range findings {
+					diag := parseVetLine(finding.Posn+": "+finding.Message, baseDir)
+					diag.Check = analyzer
+					diagnostics = append(diagnostics, diag)
+				}
+			}
+		}
+	}
+	return diagnostics
+}
+
+// parseVetLine parses one "file:line:column: message" line of go vet output.
+// A line without a position becomes a diagnostic with just the message.
+func parseVetLine(line, baseDir string) Diagnostic {
+	match := vetPositionPattern.FindStringSubmatch(line)
+	if match == nil {
+		return Diagnostic{
+			Message:  line,
 			Severity: "error",
-		})
+		}
 	}
 
-	return diagnostics
+	file := match[1]
+	if rel, err := filepath.Rel(baseDir, file); err == nil && filepath.IsAbs(file) && filepath.IsLocal(rel) {
+		file = rel
+	}
+	lineNum, _ := strconv.Atoi(match[2])
+	column, _ := strconv.Atoi(match[3])
+	return Diagnostic{
+		File:     filepath.ToSlash(strings.TrimPrefix(file, "./")),
+		Line:     lineNum,
+		Column:   column,
+		Message:  match[4],
+		Severity: "error",
+	}
+}
+
+// hideScratchDir removes the scratch directory dir from the file names and
+// messages of diagnostics, so that they name the caller's file (fileName or
+// temp.go) rather than a path that changes with every run. go reports paths
+// relative to the directory it runs in, but may spell out absolute ones, for
+// example with symbolic links in dir resolved.
+func hideScratchDir(diagnostics []Diagnostic, dir string) {
+	dirs := scratchDirSpellings(dir)
+	for i := range diagnostics {
+		diag := &diagnostics[i]
+		for _, d := range dirs {
+			if rel, ok := strings.CutPrefix(filepath.FromSlash(diag.File), d+string(filepath.Separator)); ok {
+				diag.File = filepath.ToSlash(rel)
+			}
+		}
+		diag.Message = redactScratchDir(diag.Message, dir)
+	}
+}
+
+// redactScratchDir removes the scratch directory dir from text, such as raw
+// go vet output, leaving paths below it relative and dir itself as "."
+func redactScratchDir(text, dir string) string {
+	for _, d := range scratchDirSpellings(dir) {
+		text = strings.ReplaceAll(text, d+string(filepath.Separator), "")
+		text = strings.ReplaceAll(text, d, ".")
+	}
+	return text
+}
+
+// scratchDirSpellings returns dir and, when it differs, dir with symbolic
+// links resolved, longer paths first in case one of them contains the other
+func scratchDirSpellings(dir string) []string {
+	dirs := []string{dir}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil && resolved != dir {
+		dirs = append(dirs, resolved)
+	}
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	return dirs
+}
+
+// fingerprintDiagnostics sets the Fingerprint of each diagnostic to a hash of
+// its Check, the whitespace-normalized text of the line it points at, and its
+// message. Diagnostics that name no check are hashed with tool instead. Line
+// and column numbers are left out, so the fingerprint survives edits elsewhere
+// in the file. source returns the contents of a diagnostic's file, or "" when
+// it is unknown.
+func fingerprintDiagnostics(diagnostics []Diagnostic, tool string, source func(file string) string) {
+	for i := range diagnostics {
+		diag := diagnostics[i]
+		context := ""
+		if diag.File != "" && diag.Line > 0 {
+			lines := strings.Split(source(diag.File), "\n")
+			if diag.Line <= len(lines) {
+				context = strings.Join(strings.Fields(lines[diag.Line-1]), " ")
+			}
+		}
+
+		check := diag.Check
+		if check == "" {
+			check = tool
+		}
+		sum := sha256.Sum256([]byte(check + "\x00" + context + "\x00" + diag.Message))
+		diagnostics[i].Fingerprint = hex.EncodeToString(sum[:16])
+	}
 }
 
 // ParseAST parses Go source code into an AST
 func ParseAST(code string) (*ast.File, *token.FileSet, error) {
+	if err := checkSource(code); err != nil {
+		return nil, nil, err
+	}
+	code, _ = normalizeSource(code)
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, "temp.go", code, parser.ParseComments)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse code: %w", err)
 	}
 	return file, fset, nil
-}
\ No newline at end of file
+}
+
+// ParseASTBestEffort parses code like ParseAST, but when the code has syntax
+// errors it still returns the partial AST the parser recovered, along with the
+// errors. Code the parser could not make sense of appears as *ast.BadDecl,
+// *ast.BadStmt or *ast.BadExpr nodes. The file is nil only when the error is
+// not a syntax error.
+func ParseASTBestEffort(code string) (*ast.File, *token.FileSet, error) {
+	if err := checkSource(code); err != nil {
+		return nil, nil, err
+	}
+	code, _ = normalizeSource(code)
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "temp.go", code, parser.ParseComments)
+	if err != nil {
+		var list scanner.ErrorList
+		if !errors.As(err, &list) || file == nil || file.Name.Name == "" {
+			// Without a package clause there is nothing to work with
+			return nil, nil, fmt.Errorf("failed to parse code: %w", err)
+		}
+		return file, fset, fmt.Errorf("failed to parse code: %w", err)
+	}
+	return file, fset, nil
+}
+
+// sourceFile is one parsed file of a single- or multi-file request
+type sourceFile struct {
+	Name string
+	Code string
+	AST  *ast.File
+}
+
+// parseSources parses code, or every entry of files when it is non-empty, into
+// a shared FileSet. Files are returned in name order and must all declare the
+// same package, or in _test.go files its external test package. Syntax errors
+// from every file are reported together. With bestEffort, files with syntax
+// errors are returned with their partial ASTs, as by ParseASTBestEffort, along
+// with the syntax errors; the sources are nil when the error is of any other
+// kind, or when no file has a package clause.
+func parseSources(code string, files map[string]string, bestEffort bool) ([]sourceFile, *token.FileSet, error) {
+	if len(files) == 0 {
+		parse := ParseAST
+		if bestEffort {
+			parse = ParseASTBestEffort
+		}
+		file, fset, err := parse(code)
+		if file == nil {
+			return nil, nil, err
+		}
+		return []sourceFile{{Name: "temp.go", Code: code, AST: file}}, fset, err
+	}
+	if code != "" {
+		return nil, nil, errors.New("provide either code or files, not both")
+	}
+	if err := checkSources(files); err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fset := token.NewFileSet()
+	sources := make([]sourceFile, 0, len(names))
+	var errs scanner.ErrorList
+	for _, name := range names {
+		src, _ := normalizeSource(files[name])
+		file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			var list scanner.ErrorList
+			if !errors.As(err, &list) {
+				return nil, nil, fmt.Errorf("failed to parse %s: %w", name, err)
+			}
+			errs = append(errs, list...)
+			if !bestEffort || file == nil || file.Name.Name == "" {
+				continue
+			}
+		}
+		sources = append(sources, sourceFile{Name: name, Code: src, AST: file})
+	}
+	var syntaxErr error
+	if len(errs) > 0 {
+		errs.Sort()
+		syntaxErr = fmt.Errorf("failed to parse code: %w", errs)
+		if !bestEffort || len(sources) == 0 {
+			return nil, nil, syntaxErr
+		}
+	}
+
+	// External test packages may accompany the package in _test.go files
+	pkg := sourcePackage(sources[0])
+	for _, src := range sources[1:] {
+		if sourcePackage(src) != pkg {
+			return nil, nil, fmt.Errorf("files belong to different packages: %s declares %q but %s declares %q",
+				sources[0].Name, sources[0].AST.Name.Name, src.Name, src.AST.Name.Name)
+		}
+	}
+
+	return sources, fset, syntaxErr
+}
+
+// sourcePackage returns the package a file belongs to, counting an external
+// test package, declared as the package name with a _test suffix in a
+// _test.go file, as that package
+func sourcePackage(src sourceFile) string {
+	if strings.HasSuffix(src.Name, "_test.go") {
+		return strings.TrimSuffix(src.AST.Name.Name, "_test")
+	}
+	return src.AST.Name.Name
+}
+
+// parseErrorsToDiagnostics converts the scanner.ErrorList returned by the Go
+// parser into one positioned diagnostic per syntax error. Other errors become a
+// single diagnostic without a position.
+func parseErrorsToDiagnostics(err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		var single *scanner.Error
+		if !errors.As(err, &single) {
+			return []Diagnostic{{Message: err.Error(), Severity: "error"}}
+		}
+		list = scanner.ErrorList{single}
+	}
+
+	diagnostics := make([]Diagnostic, 0, len(list))
+	for _, e := range list {
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     e.Pos.Filename,
+			Line:     e.Pos.Line,
+			Column:   e.Pos.Column,
+			Message:  e.Msg,
+			Severity: "error",
+		})
+	}
+	return diagnostics
+}
+
+// importName returns the local name under which path is imported, or "" if
+// the file does not import it (or imports it only for side effects)
+func importName(file *ast.File, path string) string {
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || importPath != path {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == "_" || imp.Name.Name == "." {
+				return ""
+			}
+			return imp.Name.Name
+		}
+		return defaultImportName(importPath)
+	}
+	return ""
+}
+
+// defaultImportName returns the last element of an import path
+func defaultImportName(path string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '/' {
+			return path[i+1:]
+		}
+	}
+	return path
+}
+
+// isPackageIdent reports whether expr is the identifier pkgName
+func isPackageIdent(expr ast.Expr, pkgName string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == pkgName
+}
+
+// receiverTypeName returns the base type name of a method receiver, stripping
+// pointers, parentheses and type parameters
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.ParenExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	}
+	return ""
+}
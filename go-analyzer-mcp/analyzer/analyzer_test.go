@@ -0,0 +1,278 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAnalyzeCodePath(t *testing.T) {
+	root := withSourceRoot(t, map[string]string{
+		"go.mod":   "module example.com/m\n\ngo 1.21\n",
+		"p/p.go":   "package p\n\nimport \"fmt\"\n\nfunc F() { fmt.Printf(\"%d\", \"x\") }\n",
+		"q/q.go":   "package q\n\nfunc G() {}\n",
+		"notgo.md": "# notes\n",
+	})
+	outside := t.TempDir()
+
+	tests := []struct {
+		name     string
+		path     string
+		warnings int
+		wantErr  string
+	}{
+		{name: "package directory", path: "p", warnings: 1},
+		{name: "file", path: "p/p.go", warnings: 1},
+		{name: "clean package", path: filepath.Join(root, "q")},
+		{name: "not Go", path: "notgo.md", wantErr: "neither a Go file nor a directory"},
+		{name: "parent", path: "..", wantErr: "path .. is outside the source root"},
+		{name: "absolute outside", path: outside, wantErr: "is outside the source root"},
+		{name: "missing", path: "r", wantErr: "path r does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := AnalyzeCode(AnalyzeCodeInput{Path: tt.path})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("AnalyzeCode error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AnalyzeCode: %v", err)
+			}
+			if result.WarningCount+result.ErrorCount != tt.warnings {
+				t.Errorf("found %d issues, want %d: %+v", result.WarningCount+result.ErrorCount, tt.warnings, result.Diagnostics)
+			}
+		})
+	}
+}
+
+// TestAnalyzeCodeConcurrentTargets is meant to be run with -race. Each call
+// sets its own GOOS, which must not leak into the others.
+// TestConcurrentCalls mixes go vet runs for different targets with
+// formatting and metrics on distinct inputs, so that go test -race catches
+// state shared between calls, and checks that each call gets its own result
+func TestConcurrentCalls(t *testing.T) {
+	const calls = 50
+
+	var wg sync.WaitGroup
+	for i := range calls {
+		wg.Go(func() {
+			switch i % 4 {
+			case 0, 1:
+				// The constant only exists on Windows
+				goos, issues := "windows", 0
+				if i%4 == 1 {
+					goos, issues = "linux", 1
+				}
+				code := fmt.Sprintf("package p\n\nimport \"syscall\"\n\nvar V%d = syscall.CREATE_NEW_PROCESS_GROUP\n", i)
+				result, err := AnalyzeCode(AnalyzeCodeInput{Code: code, GOOS: goos, NoCache: true})
+				if err != nil {
+					t.Errorf("call %d: AnalyzeCode(GOOS=%s): %v", i, goos, err)
+					return
+				}
+				if got := result.WarningCount + result.ErrorCount; got != issues {
+					t.Errorf("call %d: AnalyzeCode(GOOS=%s) found %d issues, want %d: %+v", i, goos, got, issues, result.Diagnostics)
+				}
+			case 2:
+				code := fmt.Sprintf("package p\n\nfunc  F%d()  {}\n", i)
+				result, err := FormatCode(FormatCodeInput{Code: code})
+				if err != nil {
+					t.Errorf("call %d: FormatCode: %v", i, err)
+					return
+				}
+				if want := fmt.Sprintf("package p\n\nfunc F%d() {}\n", i); result.FormattedCode != want {
+					t.Errorf("call %d: FormattedCode = %q, want %q", i, result.FormattedCode, want)
+				}
+			case 3:
+				// F has one if statement per call, so its complexity is unique
+				code := fmt.Sprintf("package p\n\nfunc F(x int) {\n%s}\n", strings.Repeat("\tif x > 0 {\n\t}\n", i))
+				result, err := CalculateMetrics(CalculateMetricsInput{Code: code, NoCache: true})
+				if err != nil {
+					t.Errorf("call %d: CalculateMetrics: %v", i, err)
+					return
+				}
+				if len(result.FunctionMetrics) != 1 || result.FunctionMetrics[0].CyclomaticComplexity != i+1 {
+					t.Errorf("call %d: FunctionMetrics = %+v, want F with complexity %d", i, result.FunctionMetrics, i+1)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestAnalyzeCodeFileName(t *testing.T) {
+	const code = "package p\n\nfunc F() {}\n"
+	tests := []struct {
+		fileName string
+		wantErr  bool
+	}{
+		{fileName: "", wantErr: false},
+		{fileName: "main.go", wantErr: false},
+		{fileName: "../x.go", wantErr: true},
+		{fileName: "sub/x.go", wantErr: true},
+		{fileName: "/tmp/x.go", wantErr: true},
+		{fileName: "..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			result, err := AnalyzeCode(AnalyzeCodeInput{Code: code, FileName: tt.fileName, NoCache: true})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "must not contain a directory") {
+					t.Errorf("AnalyzeCode(fileName %q) = %v, want a directory error", tt.fileName, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AnalyzeCode(fileName %q): %v", tt.fileName, err)
+			}
+			if !result.Success {
+				t.Errorf("AnalyzeCode(fileName %q) found issues: %+v", tt.fileName, result.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestValidateVetFlags(t *testing.T) {
+	tests := []struct {
+		flags   []string
+		wantErr string
+	}{
+		{flags: nil},
+		{flags: []string{"-printf"}},
+		{flags: []string{"--printf=false", "-unusedresult=true"}},
+		{flags: []string{"-printf.funcs=Logf,Warnf"}},
+		{flags: []string{"printf"}, wantErr: "must start with '-'"},
+		{flags: []string{"-"}, wantErr: "must start with '-'"},
+		{flags: []string{"-toolexec=/bin/sh"}, wantErr: `"toolexec" is not a go vet analyzer`},
+		{flags: []string{"-vettool=x"}, wantErr: "not a go vet analyzer"},
+		{flags: []string{"-shadow"}, wantErr: "not a go vet analyzer"},
+		{flags: []string{"-printf.=x"}, wantErr: "missing analyzer option name"},
+		{flags: []string{"-printf=maybe"}, wantErr: "only true or false"},
+		{flags: []string{"-printf", "-o=out"}, wantErr: `"o" is not a go vet analyzer`},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.flags, " "), func(t *testing.T) {
+			err := validateVetFlags(tt.flags)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateVetFlags: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateVetFlags error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAnalyzeCodeVetFlags(t *testing.T) {
+	const code = "package p\n\nimport \"fmt\"\n\nfunc F() { fmt.Printf(\"%d\", \"x\") }\n"
+	tests := []struct {
+		name   string
+		flags  []string
+		issues int
+	}{
+		{name: "default", issues: 1},
+		{name: "printf disabled", flags: []string{"-printf=false"}, issues: 0},
+		{name: "other analyzer only", flags: []string{"-unusedresult"}, issues: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := AnalyzeCode(AnalyzeCodeInput{Code: code, VetFlags: tt.flags, NoCache: true})
+			if err != nil {
+				t.Fatalf("AnalyzeCode: %v", err)
+			}
+			if got := result.WarningCount + result.ErrorCount; got != tt.issues {
+				t.Errorf("found %d issues, want %d: %+v", got, tt.issues, result.Diagnostics)
+			}
+		})
+	}
+
+	if _, err := AnalyzeCode(AnalyzeCodeInput{Code: code, VetFlags: []string{"-toolexec=/bin/sh"}}); err == nil {
+		t.Error("AnalyzeCode accepted a flag that is not an analyzer's")
+	}
+}
+
+func TestRedactScratchDir(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	sep := string(filepath.Separator)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "file below", text: link + sep + "temp.go:3:2: bad", want: "temp.go:3:2: bad"},
+		{name: "resolved spelling", text: dir + sep + "temp.go:3:2: bad", want: "temp.go:3:2: bad"},
+		{name: "directory itself", text: "# " + link + "\nvet: " + link, want: "# .\nvet: ."},
+		{name: "unrelated path", text: "/usr/lib/go/src/fmt/print.go", want: "/usr/lib/go/src/fmt/print.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactScratchDir(tt.text, link); got != tt.want {
+				t.Errorf("redactScratchDir = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeCodeHidesScratchDir(t *testing.T) {
+	const code = "package p\n\nimport \"fmt\"\n\nfunc F() { fmt.Printf(\"%d\", \"x\") }\n\nvar _ = undefined\n"
+	result, err := AnalyzeCode(AnalyzeCodeInput{Code: code, Verbose: true, NoCache: true})
+	if err != nil {
+		t.Fatalf("AnalyzeCode: %v", err)
+	}
+	if len(result.Diagnostics) == 0 || result.RawOutput == nil {
+		t.Fatalf("AnalyzeCode = %+v, want diagnostics and raw output", result)
+	}
+	texts := []string{result.RawOutput.Stdout, result.RawOutput.Stderr}
+	for _, diag := range result.Diagnostics {
+		texts = append(texts, diag.File, diag.Message)
+	}
+	for _, text := range texts {
+		if strings.Contains(text, os.TempDir()) || strings.Contains(text, "go-analyzer-") {
+			t.Errorf("output mentions the scratch directory: %q", text)
+		}
+	}
+}
+
+func TestFingerprintDiagnostics(t *testing.T) {
+	const (
+		code  = "package p\n\nfunc f() {\n\tx  :=  1\n}\n"
+		moved = "package p\n\n// f moved down\nfunc f() {\n\tx := 1\n}\n"
+	)
+	sources := map[string]string{"a.go": code, "b.go": moved}
+	diagnostics := []Diagnostic{
+		{File: "a.go", Line: 4, Message: "x declared and not used", Check: "typecheck"},
+		{File: "a.go", Line: 4, Message: "x declared and not used", Check: "unusedresult"},
+		{File: "b.go", Line: 5, Message: "x declared and not used", Check: "typecheck"},
+		{File: "a.go", Line: 4, Message: "x declared and not used"},
+		{File: "a.go", Line: 4, Message: "x declared and not used", Check: "vet"},
+	}
+	fingerprintDiagnostics(diagnostics, "vet", func(file string) string { return sources[file] })
+
+	for i, d := range diagnostics {
+		if len(d.Fingerprint) != 32 {
+			t.Errorf("diagnostic %d has fingerprint %q, want 32 hex digits", i, d.Fingerprint)
+		}
+	}
+	if diagnostics[0].Fingerprint == diagnostics[1].Fingerprint {
+		t.Error("findings of different checks share a fingerprint")
+	}
+	if diagnostics[0].Fingerprint != diagnostics[2].Fingerprint {
+		t.Error("fingerprint changed when the line moved or its spacing changed")
+	}
+	if diagnostics[3].Fingerprint != diagnostics[4].Fingerprint {
+		t.Error("a diagnostic without a check is not fingerprinted with the tool name")
+	}
+}
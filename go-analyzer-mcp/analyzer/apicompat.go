@@ -0,0 +1,325 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// CompareSymbolsInput represents the input for an API compatibility check
+type CompareSymbolsInput struct {
+	OldCode string `json:"oldCode" jsonschema:"Base version of the Go source file"`
+	NewCode string `json:"newCode" jsonschema:"Head version of the Go source file"`
+}
+
+// CompareSymbolsOutput represents the changes to the exported API of a file
+type CompareSymbolsOutput struct {
+	Success       bool             `json:"success"`
+	Groups        []APIChangeGroup `json:"groups"` // Changes grouped by kind, only for kinds with changes
+	AddedCount    int              `json:"added_count"`
+	RemovedCount  int              `json:"removed_count"`
+	ChangedCount  int              `json:"changed_count"`
+	BreakingCount int              `json:"breaking_count"`
+	Breaking      bool             `json:"breaking"` // Whether any change breaks code using the old API
+	Error         string           `json:"error,omitempty"`
+	Diagnostics   []Diagnostic     `json:"diagnostics,omitempty"` // Syntax errors when parsing fails, with File set to "old" or "new"
+}
+
+// APIChangeGroup holds the changes to the exported symbols of one kind
+type APIChangeGroup struct {
+	Kind    string      `json:"kind"` // "function", "method", "type", "struct", "interface", "field", "const" or "var"
+	Added   []APIChange `json:"added"`
+	Removed []APIChange `json:"removed"`
+	Changed []APIChange `json:"changed"`
+}
+
+// APIChange represents an exported symbol added, removed or changed
+type APIChange struct {
+	Name     string `json:"name"`          // Qualified by the type for methods and fields, e.g. "Server.Close"
+	Old      string `json:"old,omitempty"` // Declaration in the old version
+	New      string `json:"new,omitempty"` // Declaration in the new version
+	Line     int    `json:"line"`          // In the new version, or the old one for removed symbols
+	Breaking bool   `json:"breaking"`
+	Reason   string `json:"reason,omitempty"` // Why the change breaks code using the old API
+}
+
+// apiKinds orders the groups of a comparison
+var apiKinds = []string{"function", "method", "type", "struct", "interface", "field", "const", "var"}
+
+// apiSymbol is an exported symbol of a file, with the part of its declaration
+// that code using it depends on
+type apiSymbol struct {
+	kind    string
+	display string // Declaration as shown to the user
+	shape   string // Declaration without parameter and type parameter names, compared across versions
+	pointer bool   // For methods, whether the receiver is a pointer
+	line    int
+}
+
+// CompareSymbols compares the exported API of two versions of a file, for
+// gating changes that break the code using it. Exported functions, methods
+// of exported types, types, fields of exported structs, constants and
+// variables are matched by name, with methods and fields qualified by their
+// type. Removing a symbol, changing its kind, or changing the signature of a
+// function or method, the type of a field, variable or constant, or the
+// definition of a non-struct type is breaking, as is moving a method from a
+// value to a pointer receiver; adding a symbol is not.
+// Parameter names are ignored, since renaming them breaks no caller. Unlike
+// DiffSymbols, unexported symbols are left out. Interface methods are not
+// compared.
+func CompareSymbols(oldCode, newCode string) (*CompareSymbolsOutput, error) {
+	for _, code := range []string{oldCode, newCode} {
+		if err := checkSource(code); err != nil {
+			return nil, err
+		}
+	}
+	oldAPI, errOut := apiSurface(oldCode, "old")
+	if errOut != nil {
+		return errOut, nil
+	}
+	newAPI, errOut := apiSurface(newCode, "new")
+	if errOut != nil {
+		return errOut, nil
+	}
+
+	groups := map[string]*APIChangeGroup{}
+	group := func(kind string) *APIChangeGroup {
+		if groups[kind] == nil {
+			groups[kind] = &APIChangeGroup{Kind: kind, Added: []APIChange{}, Removed: []APIChange{}, Changed: []APIChange{}}
+		}
+		return groups[kind]
+	}
+	result := &CompareSymbolsOutput{Success: true, Groups: []APIChangeGroup{}}
+	breaking := func(change APIChange, reason string) APIChange {
+		change.Breaking, change.Reason = true, reason
+		result.BreakingCount++
+		return change
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(newAPI)) {
+		sym := newAPI[name]
+		old, ok := oldAPI[name]
+		if !ok {
+			g := group(sym.kind)
+			g.Added = append(g.Added, APIChange{Name: name, New: sym.display, Line: sym.line})
+			result.AddedCount++
+			continue
+		}
+		if old.kind == sym.kind && old.shape == sym.shape && old.pointer == sym.pointer {
+			continue
+		}
+
+		change := APIChange{Name: name, Old: old.display, New: sym.display, Line: sym.line}
+		switch {
+		case old.kind != sym.kind:
+			change = breaking(change, fmt.Sprintf("%s became a %s", old.kind, sym.kind))
+		case sym.kind == "function" || (sym.kind == "method" && old.shape != sym.shape):
+			change = breaking(change, "signature changed")
+		case sym.kind == "method":
+			// A value receiver puts the method in the method sets of both the
+			// type and its pointer, so only the move to a pointer receiver
+			// takes it away from values
+			if sym.pointer {
+				typeName, _, _ := strings.Cut(name, ".")
+				change = breaking(change, "receiver became a pointer, so "+typeName+" values no longer have the method")
+			}
+		case sym.kind == "struct" || sym.kind == "interface":
+			change = breaking(change, "type parameters changed")
+		default:
+			change = breaking(change, "type changed")
+		}
+		g := group(sym.kind)
+		g.Changed = append(g.Changed, change)
+		result.ChangedCount++
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(oldAPI)) {
+		old := oldAPI[name]
+		if _, ok := newAPI[name]; ok {
+			continue
+		}
+		g := group(old.kind)
+		g.Removed = append(g.Removed, breaking(APIChange{Name: name, Old: old.display, Line: old.line}, "exported "+old.kind+" removed"))
+		result.RemovedCount++
+	}
+
+	for _, kind := range apiKinds {
+		if g := groups[kind]; g != nil {
+			result.Groups = append(result.Groups, *g)
+		}
+	}
+	result.Breaking = result.BreakingCount > 0
+	return result, nil
+}
+
+// apiSurface parses one version of a file and returns its exported symbols
+// by name, or the failed output to report, with diagnostics attributed to side
+func apiSurface(code, side string) (map[string]apiSymbol, *CompareSymbolsOutput) {
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		diagnostics := parseErrorsToDiagnostics(err)
+		for i := range diagnostics {
+			diagnostics[i].File = side
+		}
+		return nil, &CompareSymbolsOutput{
+			Success:     false,
+			Error:       side + " code: " + err.Error(),
+			Diagnostics: diagnostics,
+		}
+	}
+
+	api := map[string]apiSymbol{}
+	line := func(node ast.Node) int {
+		return fset.Position(node.Pos()).Line
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			sym := extractFunctionSymbol(d, fset)
+			if sym.Kind == "function" {
+				api[d.Name.Name] = apiSymbol{
+					kind:    "function",
+					display: "func " + sym.Signature,
+					shape:   funcShape(d.Type),
+					line:    line(d.Name),
+				}
+				continue
+			}
+			if !ast.IsExported(sym.TypeName) {
+				continue
+			}
+			_, pointer := d.Recv.List[0].Type.(*ast.StarExpr)
+			api[sym.TypeName+"."+d.Name.Name] = apiSymbol{
+				kind:    "method",
+				display: "func (" + sym.Receiver + ") " + sym.Signature,
+				shape:   funcShape(d.Type),
+				pointer: pointer,
+				line:    line(d.Name),
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						addTypeAPI(api, s, line)
+					}
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					typ := ""
+					if s.Type != nil {
+						typ = types.ExprString(s.Type)
+					}
+					for _, name := range s.Names {
+						if name.IsExported() {
+							api[name.Name] = apiSymbol{
+								kind:    kind,
+								display: strings.TrimSpace(kind + " " + name.Name + " " + typ),
+								shape:   typ,
+								line:    line(name),
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return api, nil
+}
+
+// addTypeAPI adds an exported type to api, and for a struct its exported fields
+func addTypeAPI(api map[string]apiSymbol, spec *ast.TypeSpec, line func(ast.Node) int) {
+	typeParams := ""
+	if spec.TypeParams != nil {
+		typeParams = "[" + fieldListString(spec.TypeParams) + "]"
+	}
+	sym := apiSymbol{
+		kind:  "type",
+		shape: "[" + fieldTypesString(spec.TypeParams) + "]",
+		line:  line(spec.Name),
+	}
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		sym.kind = "struct"
+		sym.display = "type " + spec.Name.Name + typeParams + " struct"
+		for _, field := range t.Fields.List {
+			typ := types.ExprString(field.Type)
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{{Name: embeddedFieldName(field.Type), NamePos: field.Type.Pos()}}
+			}
+			for _, name := range names {
+				if ast.IsExported(name.Name) {
+					display := spec.Name.Name + " embeds " + typ
+					if len(field.Names) > 0 {
+						display = spec.Name.Name + "." + name.Name + " " + typ
+					}
+					api[spec.Name.Name+"."+name.Name] = apiSymbol{
+						kind:    "field",
+						display: display,
+						shape:   typ,
+						line:    line(name),
+					}
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		sym.kind = "interface"
+		sym.display = "type " + spec.Name.Name + typeParams + " interface"
+	default:
+		assign := " "
+		if spec.Assign.IsValid() {
+			assign = " = "
+		}
+		sym.display = "type " + spec.Name.Name + typeParams + assign + types.ExprString(spec.Type)
+		sym.shape += assign + types.ExprString(spec.Type)
+	}
+	api[spec.Name.Name] = sym
+}
+
+// funcShape renders a function type with its type parameter constraints,
+// parameter types and result types, but without names
+func funcShape(ft *ast.FuncType) string {
+	return "[" + fieldTypesString(ft.TypeParams) + "](" + fieldTypesString(ft.Params) + ")(" + fieldTypesString(ft.Results) + ")"
+}
+
+// fieldTypesString renders the type of each name in list, e.g. "int, int,
+// error" for (a, b int, err error)
+func fieldTypesString(list *ast.FieldList) string {
+	if list == nil {
+		return ""
+	}
+	var fieldTypes []string
+	for _, field := range list.List {
+		for range max(len(field.Names), 1) {
+			fieldTypes = append(fieldTypes, types.ExprString(field.Type))
+		}
+	}
+	return strings.Join(fieldTypes, ", ")
+}
+
+// embeddedFieldName returns the name of an embedded field, which is its type
+// name without package qualifier, pointer or type arguments
+func embeddedFieldName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.StarExpr:
+		return embeddedFieldName(t.X)
+	case *ast.IndexExpr:
+		return embeddedFieldName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldName(t.X)
+	}
+	return receiverTypeName(expr)
+}
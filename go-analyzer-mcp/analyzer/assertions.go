@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"go/ast"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// assertionPackages are assertion libraries; any call into them counts as an
+// assertion
+var assertionPackages = []string{
+	"github.com/stretchr/testify/assert",
+	"github.com/stretchr/testify/require",
+	"gotest.tools/v3/assert",
+	"gotest.tools/assert",
+	"github.com/frankban/quicktest",
+	"github.com/onsi/gomega",
+}
+
+// testingFailurePrefixes are the *testing.T methods that fail or end a test
+var testingFailurePrefixes = []string{"Error", "Fatal", "Fail", "Skip"}
+
+// FindAssertionlessTestsInput represents the input for assertionless test detection
+type FindAssertionlessTestsInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go test source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// AssertionlessOutput represents the result of assertionless test detection
+type AssertionlessOutput struct {
+	Success     bool                `json:"success"`
+	Tests       []AssertionlessTest `json:"tests"`
+	Count       int                 `json:"count"`
+	TestCount   int                 `json:"test_count"` // Number of test functions examined
+	Error       string              `json:"error,omitempty"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// AssertionlessTest represents a test function that can never fail
+type AssertionlessTest struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// FindAssertionlessTests flags test functions, func TestX(t *testing.T), whose
+// bodies never call a t.Error*, t.Fatal*, t.Fail* or t.Skip* method or a
+// function of a known assertion library. Subtests are searched along with
+// the test; tests that pass t to a helper are assumed to assert through it.
+func FindAssertionlessTests(testCode string) (*AssertionlessOutput, error) {
+	testCode, _ = normalizeSource(testCode)
+	file, fset, err := ParseAST(testCode)
+	if err != nil {
+		return &AssertionlessOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	result := &AssertionlessOutput{
+		Success: true,
+		Tests:   []AssertionlessTest{},
+	}
+
+	testingName := importName(file, "testing")
+	if testingName == "" {
+		return result, nil
+	}
+	assertionNames := map[string]bool{}
+	for _, path := range assertionPackages {
+		if name := importName(file, path); name != "" {
+			assertionNames[name] = true
+		}
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil || !isTestFuncName(fn.Name.Name) {
+			continue
+		}
+		param, ok := testingTParam(fn.Type, testingName)
+		if !ok {
+			continue
+		}
+		result.TestCount++
+
+		if !asserts(fn.Body, param, assertionNames) {
+			pos := fset.Position(fn.Name.Pos())
+			result.Tests = append(result.Tests, AssertionlessTest{
+				Name:    fn.Name.Name,
+				Message: "test never reports a failure, so it passes vacuously; add a t.Error/t.Fatal call or an assertion",
+				Line:    pos.Line,
+				Column:  pos.Column,
+			})
+		}
+	}
+
+	result.Count = len(result.Tests)
+	return result, nil
+}
+
+// isTestFuncName reports whether name is a test function name as go test sees
+// it: "Test" followed by nothing or by a character that is not lower case
+func isTestFuncName(name string) bool {
+	return hasTestPrefix(name, "Test")
+}
+
+// hasTestPrefix reports whether name is prefix followed by nothing or by a
+// character that is not lower case, as go test requires of test, benchmark
+// and fuzz function names
+func hasTestPrefix(name, prefix string) bool {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return rest == "" || !unicode.IsLower(r)
+}
+
+// testingTParam returns the parameter name of a func(t *testing.T) signature,
+// which is "" or "_" when the parameter is unnamed
+func testingTParam(fnType *ast.FuncType, testingName string) (string, bool) {
+	if fnType.Params == nil || len(fnType.Params.List) != 1 || fnType.Results != nil {
+		return "", false
+	}
+	field := fnType.Params.List[0]
+	star, ok := field.Type.(*ast.StarExpr)
+	if !ok {
+		return "", false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "T" || !isPackageIdent(sel.X, testingName) || len(field.Names) > 1 {
+		return "", false
+	}
+	if len(field.Names) == 0 {
+		return "", true
+	}
+	return field.Names[0].Name, true
+}
+
+// asserts reports whether body can fail the test: through a failure method on
+// t, a call into an assertion library, or by handing t to another function.
+// Subtest closures are searched too; inside them t may be redeclared, which
+// is matched by name.
+func asserts(body *ast.BlockStmt, t string, assertionNames map[string]bool) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if found || !ok {
+			return !found
+		}
+
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				if ident.Name == t && t != "" && t != "_" {
+					for _, prefix := range testingFailurePrefixes {
+						if strings.HasPrefix(sel.Sel.Name, prefix) {
+							found = true
+						}
+					}
+				}
+				if ident.Obj == nil && assertionNames[ident.Name] {
+					found = true
+				}
+			}
+		}
+
+		for _, arg := range call.Args {
+			if ident, ok := arg.(*ast.Ident); ok && ident.Name == t && t != "" && t != "_" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+)
+
+// DumpASTInput represents the input for dumping a syntax tree
+type DumpASTInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to parse"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	MaxDepth int    `json:"maxDepth,omitempty" jsonschema:"Omit lines nested more than this many levels deep; every field and list element is one level (default: no limit)"`
+}
+
+// DumpASTOutput represents a syntax tree printed with ast.Fprint
+type DumpASTOutput struct {
+	Success      bool         `json:"success"`
+	Dump         string       `json:"dump,omitempty"`
+	Lines        int          `json:"lines"`         // Lines of the full dump, before MaxDepth is applied
+	OmittedLines int          `json:"omitted_lines"` // Lines left out for being nested deeper than MaxDepth
+	MaxDepth     int          `json:"max_depth"`     // The limit applied, 0 for none
+	Error        string       `json:"error,omitempty"`
+	Diagnostics  []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// DumpAST parses code and prints its syntax tree as ast.Fprint does, with
+// positions as line:column. Nil fields and the deprecated identifier
+// resolution data (Obj, Scope and Unresolved) are left out. When maxDepth is
+// positive, lines nested deeper than maxDepth levels are replaced by a
+// single "..." line per run.
+func DumpAST(code string, maxDepth int) (*DumpASTOutput, error) {
+	code, _ = normalizeSource(code)
+	if maxDepth < 0 {
+		return &DumpASTOutput{
+			Success: false,
+			Error:   "maxDepth must not be negative",
+		}, nil
+	}
+
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &DumpASTOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	filter := func(name string, value reflect.Value) bool {
+		switch value.Type() {
+		case astObjType, astScopeType:
+			return false
+		}
+		return name != "Unresolved" && ast.NotNilFilter(name, value)
+	}
+	if err := ast.Fprint(&buf, fset, file, filter); err != nil {
+		return nil, fmt.Errorf("failed to print syntax tree: %w", err)
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	result := &DumpASTOutput{
+		Success:  true,
+		Lines:    len(lines),
+		MaxDepth: maxDepth,
+	}
+	var dump strings.Builder
+	omitting := false
+	for _, line := range lines {
+		line = strings.Replace(line, ": temp.go:", ": ", 1)
+		if maxDepth == 0 || dumpLineDepth(line) <= maxDepth {
+			dump.WriteString(line)
+			omitting = false
+			continue
+		}
+		if !omitting {
+			fmt.Fprintf(&dump, "%8s%s...\n", "", strings.Repeat(".  ", maxDepth+1))
+			omitting = true
+		}
+		result.OmittedLines++
+	}
+	result.Dump = dump.String()
+	return result, nil
+}
+
+// dumpLineDepth returns the nesting level of a line printed by ast.Fprint,
+// given by the ".  " markers following its line number
+func dumpLineDepth(line string) int {
+	_, rest, _ := strings.Cut(strings.TrimLeft(line, " "), "  ")
+	depth := 0
+	for strings.HasPrefix(rest, ".  ") {
+		rest = rest[len(".  "):]
+		depth++
+	}
+	return depth
+}
@@ -0,0 +1,155 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+)
+
+// maxASTNodes bounds the size of the tree returned by ASTToJSON
+const maxASTNodes = 10000
+
+// ASTJSONInput represents the input for AST serialization
+type ASTJSONInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to parse"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// ASTJSONOutput represents a syntax tree serialized for client-side processing
+type ASTJSONOutput struct {
+	Success     bool         `json:"success"`
+	Root        *ASTNode     `json:"root,omitempty"`
+	NodeCount   int          `json:"node_count"`
+	Truncated   bool         `json:"truncated"` // Set when the tree was cut off at MaxNodes
+	MaxNodes    int          `json:"max_nodes"`
+	Error       string       `json:"error,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// ASTNode is one node of a serialized syntax tree
+type ASTNode struct {
+	Type     string     `json:"type"`            // go/ast type name, e.g. "FuncDecl"
+	Field    string     `json:"field,omitempty"` // Field of the parent holding this node, e.g. "Body"
+	Name     string     `json:"name,omitempty"`  // For identifiers
+	Value    string     `json:"value,omitempty"` // Literal value or comment text
+	Token    string     `json:"token,omitempty"` // Operator, keyword or literal kind
+	Start    ASTPos     `json:"start"`
+	End      ASTPos     `json:"end"`
+	Children []*ASTNode `json:"children,omitempty"`
+}
+
+// ASTPos is a source position within a serialized syntax tree
+type ASTPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+var (
+	astNodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	tokenType    = reflect.TypeOf(token.ILLEGAL)
+	stringType   = reflect.TypeOf("")
+	astObjType   = reflect.TypeOf((*ast.Object)(nil))
+	astScopeType = reflect.TypeOf((*ast.Scope)(nil))
+	fileType     = reflect.TypeOf(ast.File{})
+)
+
+// ASTToJSON parses code and serializes its syntax tree, including comments,
+// into nested nodes with their type, positions and children. Trees larger
+// than maxASTNodes are truncated.
+func ASTToJSON(code string) (*ASTJSONOutput, error) {
+	if err := checkSource(code); err != nil {
+		return nil, err
+	}
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &ASTJSONOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	b := &astBuilder{fset: fset}
+	root := b.build(file, "")
+
+	return &ASTJSONOutput{
+		Success:   true,
+		Root:      root,
+		NodeCount: b.count,
+		Truncated: b.truncated,
+		MaxNodes:  maxASTNodes,
+	}, nil
+}
+
+// astBuilder converts go/ast nodes into ASTNodes, counting them as it goes
+type astBuilder struct {
+	fset      *token.FileSet
+	count     int
+	truncated bool
+}
+
+// build serializes n and its descendants by reflecting over its fields, so
+// every go/ast node type is covered without a per-type switch
+func (b *astBuilder) build(n ast.Node, field string) *ASTNode {
+	b.count++
+	v := reflect.ValueOf(n).Elem()
+	node := &ASTNode{
+		Type:  v.Type().Name(),
+		Field: field,
+		Start: b.position(n.Pos()),
+		End:   b.position(n.End()),
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		name := v.Type().Field(i).Name
+
+		switch {
+		case f.Type() == tokenType:
+			if tok := token.Token(f.Int()); tok != token.ILLEGAL {
+				node.Token = tok.String()
+			}
+		case f.Type() == stringType:
+			if name == "Name" {
+				node.Name = f.String()
+			} else {
+				node.Value = f.String()
+			}
+		case f.Type() == astObjType || f.Type() == astScopeType:
+			// Deprecated resolver state, not part of the syntax
+		case v.Type() == fileType && (name == "Imports" || name == "Unresolved"):
+			// Indexes into nodes that already appear under Decls
+		case f.Type().Implements(astNodeType):
+			b.addChild(node, f, name)
+		case f.Kind() == reflect.Slice && f.Type().Elem().Implements(astNodeType):
+			for j := 0; j < f.Len(); j++ {
+				b.addChild(node, f.Index(j), name)
+			}
+		}
+	}
+
+	return node
+}
+
+// addChild appends the node held in v, if any, unless the node budget is spent
+func (b *astBuilder) addChild(parent *ASTNode, v reflect.Value, field string) {
+	if v.IsNil() {
+		return
+	}
+	if b.count >= maxASTNodes {
+		b.truncated = true
+		return
+	}
+	parent.Children = append(parent.Children, b.build(v.Interface().(ast.Node), field))
+}
+
+// position converts a token.Pos, reporting zero for positions that are unset
+func (b *astBuilder) position(pos token.Pos) ASTPos {
+	if !pos.IsValid() {
+		return ASTPos{}
+	}
+	p := b.fset.Position(pos)
+	return ASTPos{Line: p.Line, Column: p.Column, Offset: p.Offset}
+}
@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// maxBatchFiles bounds the number of files accepted by AnalyzeBatch
+const maxBatchFiles = 200
+
+// AnalyzeBatchInput represents the input for analyzing several files at once
+type AnalyzeBatchInput struct {
+	Files []AnalyzeCodeInput `json:"files" jsonschema:"Files to analyze, each with a unique fileName"`
+}
+
+// AnalyzeBatchOutput represents the result of analyzing several files at once
+type AnalyzeBatchOutput struct {
+	Success      bool                          `json:"success"`          // Whether every file was analyzed without diagnostics
+	Results      map[string]*AnalyzeCodeOutput `json:"results"`          // Keyed by file name
+	Errors       map[string]string             `json:"errors,omitempty"` // Files that could not be analyzed, keyed by file name
+	ErrorCount   int                           `json:"error_count"`
+	WarningCount int                           `json:"warning_count"`
+	Error        string                        `json:"error,omitempty"`
+}
+
+// AnalyzeBatch runs AnalyzeCode on each file concurrently, using at most one
+// worker per CPU. A file that cannot be analyzed is reported under Errors
+// without failing the rest of the batch. Once ctx is done no further files
+// are started; analyses already running finish and the batch fails with the
+// context's error.
+func AnalyzeBatch(ctx context.Context, input AnalyzeBatchInput) (*AnalyzeBatchOutput, error) {
+	if err := validateBatch(input.Files); err != nil {
+		return &AnalyzeBatchOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	type fileResult struct {
+		name   string
+		result *AnalyzeCodeOutput
+		err    error
+	}
+
+	jobs := make(chan AnalyzeCodeInput)
+	results := make(chan fileResult, len(input.Files))
+	workers := min(runtime.NumCPU(), len(input.Files))
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				result, err := AnalyzeCode(file)
+				results <- fileResult{name: file.FileName, result: result, err: err}
+			}
+		}()
+	}
+dispatch:
+	for _, file := range input.Files {
+		select {
+		case jobs <- file:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("batch analysis canceled: %w", err)
+	}
+
+	output := &AnalyzeBatchOutput{
+		Success: true,
+		Results: map[string]*AnalyzeCodeOutput{},
+		Errors:  map[string]string{},
+	}
+	for r := range results {
+		if r.err != nil {
+			output.Errors[r.name] = r.err.Error()
+			output.Success = false
+			continue
+		}
+		output.Results[r.name] = r.result
+		output.ErrorCount += r.result.ErrorCount
+		output.WarningCount += r.result.WarningCount
+		output.Success = output.Success && r.result.Success
+	}
+	return output, nil
+}
+
+// validateBatch checks that a batch is non-empty, within maxBatchFiles, and
+// that every file has a unique plain file name
+func validateBatch(files []AnalyzeCodeInput) error {
+	if len(files) == 0 {
+		return fmt.Errorf("files must not be empty")
+	}
+	if len(files) > maxBatchFiles {
+		return fmt.Errorf("a batch may contain at most %d files", maxBatchFiles)
+	}
+
+	seen := map[string]bool{}
+	for _, file := range files {
+		if file.FileName == "" {
+			return fmt.Errorf("every file needs a fileName")
+		}
+		if file.Path != "" {
+			return fmt.Errorf("fileName %q: path is not supported in batches", file.FileName)
+		}
+		if file.FilePath != "" && NoPathInputs {
+			return fmt.Errorf("fileName %q: filePath is %w; send the code instead", file.FileName, ErrPathInputsDisabled)
+		}
+		if err := validateFileName(file.FileName); err != nil {
+			return err
+		}
+		if seen[file.FileName] {
+			return fmt.Errorf("duplicate fileName %q", file.FileName)
+		}
+		seen[file.FileName] = true
+	}
+	return nil
+}
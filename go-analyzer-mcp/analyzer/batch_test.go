@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []AnalyzeCodeInput
+		wantErr string
+	}{
+		{name: "valid", files: []AnalyzeCodeInput{{FileName: "a.go"}, {FileName: "b.go"}}},
+		{name: "empty", wantErr: "must not be empty"},
+		{name: "too many", files: make([]AnalyzeCodeInput, maxBatchFiles+1), wantErr: "at most"},
+		{name: "unnamed", files: []AnalyzeCodeInput{{Code: "package p"}}, wantErr: "needs a fileName"},
+		{name: "path", files: []AnalyzeCodeInput{{FileName: "a.go", Path: "."}}, wantErr: "path is not supported"},
+		{name: "directory", files: []AnalyzeCodeInput{{FileName: "sub/a.go"}}, wantErr: "must not contain a directory"},
+		{name: "parent", files: []AnalyzeCodeInput{{FileName: "../a.go"}}, wantErr: "must not contain a directory"},
+		{name: "dot dot", files: []AnalyzeCodeInput{{FileName: ".."}}, wantErr: "must not contain a directory"},
+		{name: "duplicate", files: []AnalyzeCodeInput{{FileName: "a.go"}, {FileName: "a.go"}}, wantErr: "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBatch(tt.files)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateBatch: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateBatch error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// batchFiles returns n distinct files, each with one go vet warning
+func batchFiles(n int) []AnalyzeCodeInput {
+	files := make([]AnalyzeCodeInput, n)
+	for i := range files {
+		files[i] = AnalyzeCodeInput{
+			FileName: fmt.Sprintf("f%d.go", i),
+			Code:     fmt.Sprintf("package p\n\nimport \"fmt\"\n\nfunc F%d() { fmt.Printf(\"%%d\", \"x\") }\n", i),
+			NoCache:  true,
+		}
+	}
+	return files
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	files := append(batchFiles(3), AnalyzeCodeInput{FileName: "big.go", Code: strings.Repeat("x", MaxInputSize+1)})
+	result, err := AnalyzeBatch(context.Background(), AnalyzeBatchInput{Files: files})
+	if err != nil {
+		t.Fatalf("AnalyzeBatch: %v", err)
+	}
+	if result.Success {
+		t.Error("batch with issues reported success")
+	}
+	if len(result.Results) != 3 || result.WarningCount+result.ErrorCount != 3 {
+		t.Errorf("got %d results with %d issues, want 3 with 3", len(result.Results), result.WarningCount+result.ErrorCount)
+	}
+	if _, ok := result.Errors["big.go"]; !ok || len(result.Errors) != 1 {
+		t.Errorf("Errors = %v, want only big.go", result.Errors)
+	}
+}
+
+func TestAnalyzeBatchOrder(t *testing.T) {
+	files := append(batchFiles(12),
+		AnalyzeCodeInput{FileName: "clean.go", Code: "package p\n\nfunc Clean() {}\n", NoCache: true},
+		AnalyzeCodeInput{FileName: "broken.go", Code: "package p\n\nfunc Broken() { undefined() }\n", NoCache: true},
+		AnalyzeCodeInput{FileName: "big.go", Code: strings.Repeat("x", MaxInputSize+1), NoCache: true},
+	)
+	want, err := AnalyzeBatch(context.Background(), AnalyzeBatchInput{Files: files})
+	if err != nil {
+		t.Fatalf("AnalyzeBatch: %v", err)
+	}
+	if len(want.Results) != len(files)-1 || len(want.Errors) != 1 {
+		t.Fatalf("got %d results and %d errors, want %d and 1", len(want.Results), len(want.Errors), len(files)-1)
+	}
+
+	// Workers finish in whatever order go vet returns; shuffling the input
+	// varies it further, and neither may change the result
+	rng := rand.New(rand.NewSource(1))
+	for run := range 4 {
+		shuffled := append([]AnalyzeCodeInput(nil), files...)
+		rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		got, err := AnalyzeBatch(context.Background(), AnalyzeBatchInput{Files: shuffled})
+		if err != nil {
+			t.Fatalf("run %d: AnalyzeBatch: %v", run, err)
+		}
+		if !reflect.DeepEqual(got.Results, want.Results) {
+			t.Errorf("run %d: Results differ from the first run", run)
+		}
+		if !reflect.DeepEqual(got.Errors, want.Errors) {
+			t.Errorf("run %d: Errors = %v, want %v", run, got.Errors, want.Errors)
+		}
+		if got.Success != want.Success || got.ErrorCount != want.ErrorCount || got.WarningCount != want.WarningCount {
+			t.Errorf("run %d: success %v with %d errors and %d warnings, want %v with %d and %d", run,
+				got.Success, got.ErrorCount, got.WarningCount, want.Success, want.ErrorCount, want.WarningCount)
+		}
+	}
+}
+
+func TestAnalyzeBatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	_, err := AnalyzeBatch(ctx, AnalyzeBatchInput{Files: batchFiles(50)})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("AnalyzeBatch error = %v, want context.Canceled", err)
+	}
+	// Running go vet on 50 files takes seconds; none should have started
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("canceled batch took %v", elapsed)
+	}
+}
+
+func TestAnalyzeBatchCanceledMidway(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	files := batchFiles(4 * maxBatchFiles / 5)
+	start := time.Now()
+	_, err := AnalyzeBatch(ctx, AnalyzeBatchInput{Files: files})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("AnalyzeBatch error = %v, want context.DeadlineExceeded", err)
+	}
+	// Only the analyses running at the deadline finish
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("batch took %v after its deadline", elapsed)
+	}
+}
+
+// BenchmarkAnalyzeBatch compares analyzing 50 files one at a time with
+// AnalyzeCode against the worker pool of AnalyzeBatch
+func BenchmarkAnalyzeBatch(b *testing.B) {
+	files := batchFiles(50)
+	b.Run("serial", func(b *testing.B) {
+		for b.Loop() {
+			for _, file := range files {
+				if _, err := AnalyzeCode(file); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("pool", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := AnalyzeBatch(context.Background(), AnalyzeBatchInput{Files: files}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
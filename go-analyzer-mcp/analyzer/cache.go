@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+)
+
+// DefaultCacheSize is the number of results kept when no size is configured
+const DefaultCacheSize = 256
+
+// results memoizes AnalyzeCode, GetSymbols and CalculateMetrics, so a client
+// sending unchanged code again, as editors do on every keystroke, does not
+// rerun go vet or the parser
+var results = newResultCache(DefaultCacheSize)
+
+// resultCache is a least-recently-used cache of analysis results keyed by a
+// SHA-256 of the analysis and its input. Results are shared between callers
+// and must not be modified.
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Front is the most recently used entry
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+// cacheEntry is one cached result, kept in resultCache.order
+type cacheEntry struct {
+	key    [sha256.Size]byte
+	result any
+}
+
+func newResultCache(size int) *resultCache {
+	return &resultCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[[sha256.Size]byte]*list.Element{},
+	}
+}
+
+// get returns the result stored under key and marks it as recently used
+func (c *resultCache) get(key [sha256.Size]byte) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).result, true
+}
+
+// put stores result under key, evicting the least recently used entries
+// beyond the cache size
+func (c *resultCache) put(key [sha256.Size]byte, result any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).result = result
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, result: result})
+	c.evict()
+}
+
+// evict drops the least recently used entries until the cache fits its size
+func (c *resultCache) evict() {
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// resize changes the number of entries kept, dropping the least recently
+// used ones if needed
+func (c *resultCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.size = size
+	c.evict()
+}
+
+// clear drops every entry
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	clear(c.entries)
+}
+
+// enabled reports whether the cache keeps any entries
+func (c *resultCache) enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size > 0
+}
+
+// SetCacheSize sets how many analysis results are cached; 0 disables the
+// cache. Servers call it at startup.
+func SetCacheSize(size int) {
+	results.resize(max(size, 0))
+}
+
+// ClearCache drops every cached analysis result
+func ClearCache() {
+	results.clear()
+}
+
+// cachedResult returns the result of analyze(input) from the cache, computing
+// and storing it on a miss. The key covers name and all of input, so every
+// flag that changes the result is part of it. With refresh set, the result
+// is computed again and replaces the cached one. Errors are not cached.
+func cachedResult[In, Out any](name string, input In, refresh bool, analyze func(In) (*Out, error)) (*Out, error) {
+	if !results.enabled() {
+		return analyze(input)
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return analyze(input)
+	}
+	key := sha256.Sum256(append([]byte(name+"\x00"), data...))
+
+	if !refresh {
+		if result, ok := results.get(key); ok {
+			return result.(*Out), nil
+		}
+	}
+	result, err := analyze(input)
+	if err == nil {
+		results.put(key, result)
+	}
+	return result, err
+}
@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func cacheKey(i int) [sha256.Size]byte {
+	return sha256.Sum256([]byte(fmt.Sprint(i)))
+}
+
+func TestResultCacheEviction(t *testing.T) {
+	c := newResultCache(2)
+	c.put(cacheKey(1), 1)
+	c.put(cacheKey(2), 2)
+	c.get(cacheKey(1)) // 2 is now the least recently used
+	c.put(cacheKey(3), 3)
+
+	tests := []struct {
+		key  int
+		want bool
+	}{
+		{key: 1, want: true},
+		{key: 2, want: false},
+		{key: 3, want: true},
+	}
+	for _, tt := range tests {
+		if _, ok := c.get(cacheKey(tt.key)); ok != tt.want {
+			t.Errorf("entry %d cached = %v, want %v", tt.key, ok, tt.want)
+		}
+	}
+
+	c.resize(0)
+	if c.enabled() || c.order.Len() != 0 {
+		t.Errorf("cache of size 0 keeps %d entries", c.order.Len())
+	}
+}
+
+// TestResultCacheConcurrent is meant to be run with -race
+func TestResultCacheConcurrent(t *testing.T) {
+	c := newResultCache(8)
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Go(func() {
+			for i := range 200 {
+				key := cacheKey(g*1000 + i%20)
+				c.put(key, g)
+				if result, ok := c.get(key); ok && result.(int) != g {
+					// Keys are per goroutine, so no other one writes them
+					t.Errorf("goroutine %d read a result of %d", g, result)
+				}
+				if i%50 == 0 {
+					c.resize(4 + g%8)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.order.Len() != len(c.entries) || c.order.Len() > c.size {
+		t.Errorf("cache holds %d entries in order and %d in the map, size %d", c.order.Len(), len(c.entries), c.size)
+	}
+}
+
+// TestCachedResultConcurrent is meant to be run with -race
+func TestCachedResultConcurrent(t *testing.T) {
+	old := results
+	results = newResultCache(DefaultCacheSize)
+	t.Cleanup(func() { results = old })
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Go(func() {
+			for i := range 50 {
+				input := fmt.Sprint(i % 5)
+				result, err := cachedResult("test", input, g%4 == 0, func(in string) (*string, error) {
+					out := "result " + in
+					return &out, nil
+				})
+				if err != nil || *result != "result "+input {
+					t.Errorf("cachedResult(%q) = %q, %v", input, *result, err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestCachedResult(t *testing.T) {
+	old := results
+	results = newResultCache(DefaultCacheSize)
+	t.Cleanup(func() { results = old })
+
+	type input struct {
+		Code string
+		Flag bool
+	}
+	calls := 0
+	analyze := func(in input) (*string, error) {
+		calls++
+		if in.Code == "fail" {
+			return nil, errors.New("failed")
+		}
+		out := fmt.Sprintf("%s %v %d", in.Code, in.Flag, calls)
+		return &out, nil
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		input   input
+		refresh bool
+		calls   int // Total calls to analyze after this step
+	}{
+		{name: "miss", tool: "a", input: input{Code: "x"}, calls: 1},
+		{name: "hit", tool: "a", input: input{Code: "x"}, calls: 1},
+		{name: "other flag", tool: "a", input: input{Code: "x", Flag: true}, calls: 2},
+		{name: "other tool", tool: "b", input: input{Code: "x"}, calls: 3},
+		{name: "refresh", tool: "a", input: input{Code: "x"}, refresh: true, calls: 4},
+		{name: "hit after refresh", tool: "a", input: input{Code: "x"}, calls: 4},
+		{name: "error", tool: "a", input: input{Code: "fail"}, calls: 5},
+		{name: "error not cached", tool: "a", input: input{Code: "fail"}, calls: 6},
+	}
+	for _, tt := range tests {
+		if _, err := cachedResult(tt.tool, tt.input, tt.refresh, analyze); (err != nil) != (tt.input.Code == "fail") {
+			t.Fatalf("%s: cachedResult error = %v", tt.name, err)
+		}
+		if calls != tt.calls {
+			t.Errorf("%s: analyze called %d times, want %d", tt.name, calls, tt.calls)
+		}
+	}
+
+	// The refreshed result replaced the first one
+	if result, _ := cachedResult("a", input{Code: "x"}, false, analyze); *result != "x false 4" {
+		t.Errorf("cached result = %q, want the refreshed one", *result)
+	}
+
+	SetCacheSize(0)
+	cachedResult("a", input{Code: "x"}, false, analyze)
+	if calls != 7 {
+		t.Errorf("disabled cache answered from memory")
+	}
+}
+
+func TestAnalysesUseCache(t *testing.T) {
+	old := results
+	results = newResultCache(DefaultCacheSize)
+	t.Cleanup(func() { results = old })
+
+	const code = "package p\n\nfunc F() {}\n"
+	first, err := GetSymbols(GetSymbolsInput{Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := GetSymbols(GetSymbolsInput{Code: code}); again != first {
+		t.Error("identical GetSymbols request was not answered from the cache")
+	}
+	if filtered, _ := GetSymbols(GetSymbolsInput{Code: code, Filter: "type"}); filtered == first {
+		t.Error("GetSymbols request with another filter was answered from the cache")
+	}
+	if fresh, _ := GetSymbols(GetSymbolsInput{Code: code, NoCache: true}); fresh == first {
+		t.Error("GetSymbols with NoCache was answered from the cache")
+	}
+
+	metrics, err := CalculateMetrics(CalculateMetricsInput{Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := CalculateMetrics(CalculateMetricsInput{Code: code}); again != metrics {
+		t.Error("identical CalculateMetrics request was not answered from the cache")
+	}
+
+	vet, err := AnalyzeCode(AnalyzeCodeInput{Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := AnalyzeCode(AnalyzeCodeInput{Code: code}); again != vet {
+		t.Error("identical AnalyzeCode request was not answered from the cache")
+	}
+}
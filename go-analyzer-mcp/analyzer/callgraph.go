@@ -0,0 +1,224 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// CallGraphInput represents the input for call graph construction
+type CallGraphInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// CallGraphOutput represents the calls made between the functions of a file
+type CallGraphOutput struct {
+	Success     bool                `json:"success"`
+	Functions   []string            `json:"functions"`          // Declared functions and methods ("Type.Method"), in source order
+	Graph       map[string][]string `json:"graph"`              // Caller to the functions of this file it calls
+	External    map[string][]string `json:"external,omitempty"` // Caller to the imported functions it calls, e.g. "net/http.Get"
+	Uncalled    []string            `json:"uncalled"`           // Functions never called or referenced within the file
+	Error       string              `json:"error,omitempty"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// BuildCallGraph builds the call graph of the functions declared in code.
+// Method calls are resolved when the receiver's type is known from the file:
+// the method's own receiver, a parameter, or a variable declared with a type
+// or initialized from a composite literal or new(T). Other method calls are
+// left out. Functions that are only referenced, for example passed as a
+// callback, count as used; main and init are never reported as uncalled.
+func BuildCallGraph(code string) (*CallGraphOutput, error) {
+	code, _ = normalizeSource(code)
+	file, _, err := ParseAST(code)
+	if err != nil {
+		return &CallGraphOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	result := &CallGraphOutput{
+		Success:   true,
+		Functions: []string{},
+		Graph:     map[string][]string{},
+		External:  map[string][]string{},
+		Uncalled:  []string{},
+	}
+
+	// Index declared functions, and methods by receiver type and name
+	funcs := map[string]bool{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := callGraphName(fn)
+		funcs[name] = true
+		result.Functions = append(result.Functions, name)
+	}
+
+	imports := map[string]string{}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if name := importName(file, path); name != "" && name != "." {
+			imports[name] = path
+		}
+	}
+
+	// Recursive calls do not count as uses
+	used := map[string]bool{}
+	use := func(caller, name string) {
+		if name != caller {
+			used[name] = true
+		}
+	}
+
+	for _, decl := range file.Decls {
+		caller := ""
+		var declName *ast.Ident
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			caller = callGraphName(fn)
+			declName = fn.Name
+		}
+
+		callees := map[string]bool{}
+		external := map[string]bool{}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.Ident:
+				// A reference to a top-level function, called or not
+				if node != declName && funcs[node.Name] && node.Obj != nil && node.Obj.Kind == ast.Fun {
+					use(caller, node.Name)
+				}
+			case *ast.SelectorExpr:
+				if typeName := exprTypeName(node.X); typeName != "" && funcs[typeName+"."+node.Sel.Name] {
+					use(caller, typeName+"."+node.Sel.Name)
+				}
+			case *ast.CallExpr:
+				if caller == "" {
+					return true
+				}
+				switch fun := unwrapCallee(node.Fun).(type) {
+				case *ast.Ident:
+					if funcs[fun.Name] && fun.Obj != nil && fun.Obj.Kind == ast.Fun {
+						callees[fun.Name] = true
+					}
+				case *ast.SelectorExpr:
+					if pkg, ok := fun.X.(*ast.Ident); ok && pkg.Obj == nil && imports[pkg.Name] != "" {
+						external[imports[pkg.Name]+"."+fun.Sel.Name] = true
+					} else if typeName := exprTypeName(fun.X); typeName != "" && funcs[typeName+"."+fun.Sel.Name] {
+						callees[typeName+"."+fun.Sel.Name] = true
+					}
+				}
+			}
+			return true
+		})
+
+		if len(callees) > 0 {
+			result.Graph[caller] = sortedKeys(callees)
+		}
+		if len(external) > 0 {
+			result.External[caller] = sortedKeys(external)
+		}
+	}
+
+	for _, name := range result.Functions {
+		if !used[name] && name != "main" && name != "init" {
+			result.Uncalled = append(result.Uncalled, name)
+		}
+	}
+	return result, nil
+}
+
+// callGraphName names a function declaration as it appears in the graph:
+// "Name" for functions and "Type.Name" for methods
+func callGraphName(fn *ast.FuncDecl) string {
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		return receiverTypeName(fn.Recv.List[0].Type) + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// unwrapCallee strips parentheses and explicit type arguments from a call's
+// function expression
+func unwrapCallee(expr ast.Expr) ast.Expr {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		default:
+			return expr
+		}
+	}
+}
+
+// exprTypeName returns the name of the type of a method call receiver when
+// it can be read from the declaration of the variable, or "" otherwise
+func exprTypeName(expr ast.Expr) string {
+	ident, ok := unwrapCallee(expr).(*ast.Ident)
+	if !ok || ident.Obj == nil || ident.Obj.Kind != ast.Var {
+		return ""
+	}
+
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		return receiverTypeName(decl.Type)
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return receiverTypeName(decl.Type)
+		}
+		for i, name := range decl.Names {
+			if name.Name == ident.Name && i < len(decl.Values) {
+				return constructedTypeName(decl.Values[i])
+			}
+		}
+	case *ast.AssignStmt:
+		if decl.Tok != token.DEFINE || len(decl.Lhs) != len(decl.Rhs) {
+			return ""
+		}
+		for i, lhs := range decl.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && id.Name == ident.Name {
+				return constructedTypeName(decl.Rhs[i])
+			}
+		}
+	}
+	return ""
+}
+
+// constructedTypeName returns the type name constructed by T{...}, &T{...}
+// or new(T), or "" for any other expression
+func constructedTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return constructedTypeName(e.X)
+		}
+	case *ast.CompositeLit:
+		if e.Type != nil {
+			return receiverTypeName(e.Type)
+		}
+	case *ast.CallExpr:
+		if ident, ok := e.Fun.(*ast.Ident); ok && ident.Name == "new" && len(e.Args) == 1 {
+			return receiverTypeName(e.Args[0])
+		}
+	}
+	return ""
+}
+
+// sortedKeys returns the keys of set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ConfigureFromEnv applies the settings both servers read from the
+// environment at startup:
+//
+//   - GOIMPORTS_PATH and GOFUMPT_PATH set GoimportsPath and GofumptPath
+//   - ANALYZER_CACHE_SIZE is the number of results to cache, 0 to disable
+//     caching
+//   - ANALYZER_MAX_INPUT_SIZE sets MaxInputSize in bytes, 0 to disable the
+//     limit
+//   - ANALYZER_SOURCE_ROOT sets SourceRoot, which must be an existing
+//     directory
+//
+// Unset variables leave the defaults in place. It returns an error naming
+// the first variable with an invalid value.
+func ConfigureFromEnv() error {
+	if path := os.Getenv("GOIMPORTS_PATH"); path != "" {
+		GoimportsPath = path
+	}
+
+	if path := os.Getenv("GOFUMPT_PATH"); path != "" {
+		GofumptPath = path
+	}
+
+	if size := os.Getenv("ANALYZER_CACHE_SIZE"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid ANALYZER_CACHE_SIZE %q: use a number of results, or 0 to disable caching", size)
+		}
+		SetCacheSize(n)
+	}
+
+	if size := os.Getenv("ANALYZER_MAX_INPUT_SIZE"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid ANALYZER_MAX_INPUT_SIZE %q: use a number of bytes, or 0 to disable the limit", size)
+		}
+		MaxInputSize = n
+	}
+
+	if root := os.Getenv("ANALYZER_SOURCE_ROOT"); root != "" {
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("invalid ANALYZER_SOURCE_ROOT %q: use an existing directory", root)
+		}
+		SourceRoot = root
+	}
+
+	return nil
+}
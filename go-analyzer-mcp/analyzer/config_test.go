@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigureFromEnv(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+		check   func(t *testing.T)
+	}{
+		{
+			name: "unset",
+			check: func(t *testing.T) {
+				if GoimportsPath != "goimports" || MaxInputSize != DefaultMaxInputSize || SourceRoot != "" {
+					t.Errorf("defaults changed: %q, %d, %q", GoimportsPath, MaxInputSize, SourceRoot)
+				}
+			},
+		},
+		{
+			name: "all set",
+			env: map[string]string{
+				"GOIMPORTS_PATH":          "/opt/bin/goimports",
+				"GOFUMPT_PATH":            "/opt/bin/gofumpt",
+				"ANALYZER_CACHE_SIZE":     "0",
+				"ANALYZER_MAX_INPUT_SIZE": "100",
+				"ANALYZER_SOURCE_ROOT":    root,
+			},
+			check: func(t *testing.T) {
+				if GoimportsPath != "/opt/bin/goimports" || GofumptPath != "/opt/bin/gofumpt" {
+					t.Errorf("formatter paths = %q, %q", GoimportsPath, GofumptPath)
+				}
+				if results.enabled() {
+					t.Error("cache still enabled")
+				}
+				if MaxInputSize != 100 {
+					t.Errorf("MaxInputSize = %d, want 100", MaxInputSize)
+				}
+				if SourceRoot != root {
+					t.Errorf("SourceRoot = %q, want %q", SourceRoot, root)
+				}
+			},
+		},
+		{name: "bad cache size", env: map[string]string{"ANALYZER_CACHE_SIZE": "-1"}, wantErr: "ANALYZER_CACHE_SIZE"},
+		{name: "bad input size", env: map[string]string{"ANALYZER_MAX_INPUT_SIZE": "1MB"}, wantErr: "ANALYZER_MAX_INPUT_SIZE"},
+		{name: "missing source root", env: map[string]string{"ANALYZER_SOURCE_ROOT": root + "/missing"}, wantErr: "ANALYZER_SOURCE_ROOT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"GOIMPORTS_PATH", "GOFUMPT_PATH", "ANALYZER_CACHE_SIZE", "ANALYZER_MAX_INPUT_SIZE", "ANALYZER_SOURCE_ROOT"} {
+				t.Setenv(name, tt.env[name])
+			}
+			goimports, gofumpt, maxInput, sourceRoot := GoimportsPath, GofumptPath, MaxInputSize, SourceRoot
+			t.Cleanup(func() {
+				GoimportsPath, GofumptPath, MaxInputSize, SourceRoot = goimports, gofumpt, maxInput, sourceRoot
+				SetCacheSize(DefaultCacheSize)
+			})
+
+			err := ConfigureFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ConfigureFromEnv() = %v, want an error naming %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConfigureFromEnv: %v", err)
+			}
+			tt.check(t)
+		})
+	}
+}
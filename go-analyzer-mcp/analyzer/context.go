@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// FindDetachedContextInput represents the input for detached context detection
+type FindDetachedContextInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// DetachedCtxOutput represents the result of detached context detection
+type DetachedCtxOutput struct {
+	Success     bool                  `json:"success"`
+	Findings    []DetachedContextCall `json:"findings"`
+	Count       int                   `json:"count"`
+	Error       string                `json:"error,omitempty"`
+	Diagnostics []Diagnostic          `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// DetachedContextCall represents a context.Background() or context.TODO() call
+// made inside a function that already has a context.Context available
+type DetachedContextCall struct {
+	Function     string `json:"function"`
+	Call         string `json:"call"`          // "context.Background()" or "context.TODO()"
+	ContextParam string `json:"context_param"` // The parameter that should have been used
+	SuggestedFix string `json:"suggested_fix"` // Replacement for the call
+	Line         int    `json:"line"`
+	Column       int    `json:"column"`
+}
+
+// FindDetachedContext flags context.Background() and context.TODO() calls inside
+// functions that receive a context.Context parameter, which usually means the
+// caller's context (and its cancellation) was not threaded through
+func FindDetachedContext(code string) (*DetachedCtxOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &DetachedCtxOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	findings := []DetachedContextCall{}
+
+	pkgName := importName(file, "context")
+	if pkgName != "" {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			ctxParam := contextParamName(fn.Type, pkgName)
+			findings = findDetachedCalls(fn.Body, fn.Name.Name, ctxParam, pkgName, fset, findings)
+		}
+	}
+
+	return &DetachedCtxOutput{
+		Success:  true,
+		Findings: findings,
+		Count:    len(findings),
+	}, nil
+}
+
+// findDetachedCalls walks body looking for detached context calls. Function
+// literals inherit the enclosing context parameter unless they declare their own.
+func findDetachedCalls(body ast.Node, funcName, ctxParam, pkgName string, fset *token.FileSet, findings []DetachedContextCall) []DetachedContextCall {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			inner := contextParamName(node.Type, pkgName)
+			if inner == "" {
+				inner = ctxParam
+			}
+			findings = findDetachedCalls(node.Body, funcName, inner, pkgName, fset, findings)
+			return false
+
+		case *ast.CallExpr:
+			if ctxParam == "" {
+				return true
+			}
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok || !isPackageIdent(sel.X, pkgName) {
+				return true
+			}
+			if sel.Sel.Name == "Background" || sel.Sel.Name == "TODO" {
+				pos := fset.Position(node.Pos())
+				findings = append(findings, DetachedContextCall{
+					Function:     funcName,
+					Call:         "context." + sel.Sel.Name + "()",
+					ContextParam: ctxParam,
+					SuggestedFix: ctxParam,
+					Line:         pos.Line,
+					Column:       pos.Column,
+				})
+			}
+		}
+		return true
+	})
+
+	return findings
+}
+
+// contextParamName returns the name of the first usable context.Context
+// parameter of a function type, or "" if there is none
+func contextParamName(fnType *ast.FuncType, pkgName string) string {
+	if fnType.Params == nil {
+		return ""
+	}
+	for _, param := range fnType.Params.List {
+		sel, ok := param.Type.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Context" || !isPackageIdent(sel.X, pkgName) {
+			continue
+		}
+		for _, name := range param.Names {
+			if name.Name != "_" {
+				return name.Name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// FindDeferredReturnMutationInput represents the input for deferred return mutation detection
+type FindDeferredReturnMutationInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// DeferReturnOutput represents the result of deferred return mutation detection
+type DeferReturnOutput struct {
+	Success     bool                     `json:"success"`
+	Mutations   []DeferredReturnMutation `json:"mutations"`
+	Count       int                      `json:"count"`
+	Error       string                   `json:"error,omitempty"`
+	Diagnostics []Diagnostic             `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// DeferredReturnMutation represents an assignment to a named result inside a
+// deferred closure
+type DeferredReturnMutation struct {
+	Function  string `json:"function"` // Enclosing function, or "func literal"
+	Result    string `json:"result"`   // Name of the named result assigned
+	Message   string `json:"message"`
+	Line      int    `json:"line"` // Position of the assignment
+	Column    int    `json:"column"`
+	DeferLine int    `json:"defer_line"` // Line of the defer statement
+}
+
+// FindDeferredReturnMutation flags deferred closures that assign to the named
+// results of their enclosing function. Such assignments run after the return
+// statement and replace the value it returned. Assignments to a local that
+// shadows a result are not reported.
+func FindDeferredReturnMutation(code string) (*DeferReturnOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &DeferReturnOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	mutations := []DeferredReturnMutation{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		var name string
+		var typ *ast.FuncType
+		var body *ast.BlockStmt
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			name, typ, body = fn.Name.Name, fn.Type, fn.Body
+		case *ast.FuncLit:
+			name, typ, body = "func literal", fn.Type, fn.Body
+		default:
+			return true
+		}
+		if body != nil {
+			mutations = append(mutations, deferredResultAssignments(name, typ, body, fset)...)
+		}
+		return true
+	})
+
+	return &DeferReturnOutput{
+		Success:   true,
+		Mutations: mutations,
+		Count:     len(mutations),
+	}, nil
+}
+
+// deferredResultAssignments reports the assignments to the named results of
+// one function made by closures it defers. Nested functions are skipped, as
+// their defers run when they return, not when this function does.
+func deferredResultAssignments(name string, typ *ast.FuncType, body *ast.BlockStmt, fset *token.FileSet) []DeferredReturnMutation {
+	// Identifiers resolve to the object of their declaration, which tells a
+	// named result apart from a local that shadows it
+	results := map[*ast.Object]string{}
+	if typ.Results != nil {
+		for _, field := range typ.Results.List {
+			for _, ident := range field.Names {
+				if ident.Obj != nil && ident.Name != "_" {
+					results[ident.Obj] = ident.Name
+				}
+			}
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	var mutations []DeferredReturnMutation
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			lit, ok := stmt.Call.Fun.(*ast.FuncLit)
+			if !ok {
+				return true
+			}
+			deferLine := fset.Position(stmt.Pos()).Line
+			ast.Inspect(lit.Body, func(n ast.Node) bool {
+				var targets []ast.Expr
+				switch s := n.(type) {
+				case *ast.AssignStmt:
+					if s.Tok != token.DEFINE {
+						targets = s.Lhs
+					}
+				case *ast.IncDecStmt:
+					targets = []ast.Expr{s.X}
+				}
+				for _, target := range targets {
+					ident, ok := target.(*ast.Ident)
+					if !ok || results[ident.Obj] == "" {
+						continue
+					}
+					pos := fset.Position(ident.Pos())
+					mutations = append(mutations, DeferredReturnMutation{
+						Function:  name,
+						Result:    ident.Name,
+						Message:   fmt.Sprintf("deferred closure assigns to named result %q, replacing the value returned", ident.Name),
+						Line:      pos.Line,
+						Column:    pos.Column,
+						DeferLine: deferLine,
+					})
+				}
+				return true
+			})
+			return false
+		}
+		return true
+	})
+	return mutations
+}
@@ -0,0 +1,256 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each hunk
+const diffContextLines = 3
+
+// diffOp is a single line in an edit script
+type diffOp struct {
+	kind byte // ' ' (keep), '-' (delete) or '+' (insert)
+	text string
+}
+
+// unifiedDiff returns a unified diff turning oldText into newText, using the
+// conventional a/ and b/ prefixes on fileName so the patch applies with -p1.
+// It returns "" when the two texts are identical.
+func unifiedDiff(fileName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	return formatDiff(fileName, diffLines(splitDiffLines(oldText), splitDiffLines(newText)))
+}
+
+// formatDiff renders the edit script ops as a unified diff of fileName
+func formatDiff(fileName string, ops []diffOp) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", fileName, fileName)
+
+	// Walk the edit script, emitting a hunk for each run of changes together
+	// with its surrounding context
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// Back up to include leading context
+		start := i
+		for start > 0 && i-start < diffContextLines && ops[start-1].kind == ' ' {
+			start--
+		}
+		hunkOld := oldLine - (i - start)
+		hunkNew := newLine - (i - start)
+
+		// Extend until a run of unchanged lines long enough to split hunks
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := 0
+			for end+run < len(ops) && ops[end+run].kind == ' ' {
+				run++
+			}
+			if end+run == len(ops) || run > 2*diffContextLines {
+				end += min(run, diffContextLines)
+				break
+			}
+			end += run
+		}
+
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+		}
+
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	return b.String()
+}
+
+// divergentLines returns the old lines, at most limit of them, where each
+// run of changes in the edit script ops starts. A pure insertion is reported
+// at the line it is inserted before.
+func divergentLines(ops []diffOp, limit int) []int {
+	var lines []int
+	oldLine := 1
+	for i, op := range ops {
+		if op.kind != ' ' && (i == 0 || ops[i-1].kind == ' ') {
+			if len(lines) == limit {
+				break
+			}
+			lines = append(lines, oldLine)
+		}
+		if op.kind != '+' {
+			oldLine++
+		}
+	}
+	return lines
+}
+
+// hunkRange formats a "start,count" hunk range as used in @@ headers
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range refers to the line before the insertion point
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitDiffLines splits text into lines that keep their trailing newline. A
+// final line without one is marked the way diff(1) does.
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	return lines
+}
+
+// maxDiffCost bounds the number of edits diffLines searches for a middle
+// snake. Past it the texts are so unlike that the changed region is reported
+// as deleted and inserted whole, which keeps the time of the diff bounded.
+const maxDiffCost = 4096
+
+// diffLines computes a shortest edit script between a and b using the linear
+// space refinement of Myers' O(ND) algorithm: it finds the middle snake of an
+// optimal path and recurses on the halves either side of it, so memory stays
+// proportional to the length of the texts rather than to the square of the
+// number of edits
+func diffLines(a, b []string) []diffOp {
+	return appendDiff(make([]diffOp, 0, max(len(a), len(b))), a, b)
+}
+
+// appendDiff appends the edit script turning a into b to ops
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	ops = appendOps(ops, ' ', a[:prefix])
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	// With the common ends trimmed and both sides non-empty at least two
+	// edits remain, so each half of the split is a strictly smaller problem
+	if len(a) > 0 && len(b) > 0 {
+		if x, y, u, v, ok := middleSnake(a, b); ok {
+			ops = appendDiff(ops, a[:x], b[:y])
+			ops = appendOps(ops, ' ', a[x:u])
+			ops = appendDiff(ops, a[u:], b[v:])
+			return appendOps(ops, ' ', common)
+		}
+	}
+	ops = appendOps(ops, '-', a)
+	ops = appendOps(ops, '+', b)
+	return appendOps(ops, ' ', common)
+}
+
+// appendOps appends an op of kind for each of lines to ops
+func appendOps(ops []diffOp, kind byte, lines []string) []diffOp {
+	for _, line := range lines {
+		ops = append(ops, diffOp{kind: kind, text: line})
+	}
+	return ops
+}
+
+// middleSnake finds the snake, from (x, y) to (u, v), in the middle of a
+// shortest edit script turning a into b, by searching forward from the start
+// and backward from the end until the two searches overlap. It reports false
+// when the script needs more than maxDiffCost edits each way.
+func middleSnake(a, b []string) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := min((n+m+1)/2, maxDiffCost)
+
+	// forward[k] is the furthest x reached on diagonal x-y = k from the
+	// start, and backward[k] how far along diagonal k the search from the
+	// end reached, measured from the end of both texts
+	offset := limit + 1
+	forward := make([]int, 2*limit+3)
+	backward := make([]int, 2*limit+3)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			// The backward search has run d-1 rounds when D is odd
+			if back := delta - k; odd && back >= -(d-1) && back <= d-1 && x+backward[offset+back] >= n {
+				return startX, startY, x, y, true
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if front := delta - k; !odd && front >= -d && front <= d && x+forward[offset+front] >= n {
+				return n - x, m - y, n - startX, m - startY, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
@@ -0,0 +1,149 @@
+package analyzer
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// applyOps checks that ops turns a into b, returning the number of edits
+func applyOps(t *testing.T, a, b []string, ops []diffOp) int {
+	t.Helper()
+	var gotA, gotB []string
+	edits := 0
+	for _, op := range ops {
+		switch op.kind {
+		case ' ':
+			gotA = append(gotA, op.text)
+			gotB = append(gotB, op.text)
+		case '-':
+			gotA = append(gotA, op.text)
+			edits++
+		case '+':
+			gotB = append(gotB, op.text)
+			edits++
+		default:
+			t.Fatalf("unexpected op kind %q", op.kind)
+		}
+	}
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Fatalf("edit script does not turn %q into %q", a, b)
+	}
+	return edits
+}
+
+// editDistance returns the number of insertions and deletions in a shortest
+// edit script turning a into b
+func editDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return len(a) + len(b) - 2*prev[len(b)]
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		edits int
+	}{
+		{name: "identical", a: "abc", b: "abc", edits: 0},
+		{name: "both empty", a: "", b: "", edits: 0},
+		{name: "insert all", a: "", b: "abc", edits: 3},
+		{name: "delete all", a: "abc", b: "", edits: 3},
+		{name: "replace one", a: "abc", b: "axc", edits: 2},
+		{name: "insert middle", a: "ac", b: "abc", edits: 1},
+		{name: "disjoint", a: "abc", b: "xyz", edits: 6},
+		{name: "odd delta", a: "abcabba", b: "cbabac", edits: 5},
+		{name: "moved block", a: "abcdef", b: "defabc", edits: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+			if tt.a == "" {
+				a = nil
+			}
+			if tt.b == "" {
+				b = nil
+			}
+			if got := applyOps(t, a, b, diffLines(a, b)); got != tt.edits {
+				t.Errorf("edits = %d, want %d", got, tt.edits)
+			}
+		})
+	}
+}
+
+func TestDiffLinesShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(40))
+		for i := range lines {
+			lines[i] = fmt.Sprintf("%d\n", rng.Intn(4))
+		}
+		return lines
+	}
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		if got, want := applyOps(t, a, b, diffLines(a, b)), editDistance(a, b); got != want {
+			t.Fatalf("diff of %q and %q has %d edits, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestDiffLinesDissimilarLarge(t *testing.T) {
+	// Texts sharing no lines once needed memory quadratic in their length
+	a := make([]string, 20000)
+	b := make([]string, 20000)
+	for i := range a {
+		a[i] = fmt.Sprintf("a%d\n", i)
+		b[i] = fmt.Sprintf("b%d\n", i)
+	}
+	if got := applyOps(t, a, b, diffLines(a, b)); got != len(a)+len(b) {
+		t.Errorf("edits = %d, want %d", got, len(a)+len(b))
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{name: "identical", old: "a\n", new: "a\n", want: ""},
+		{
+			name: "changed line",
+			old:  "a\nb\nc\n",
+			new:  "a\nx\nc\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name: "missing final newline",
+			old:  "a",
+			new:  "a\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+		{
+			name: "insert into empty",
+			old:  "",
+			new:  "a\n",
+			want: "--- a/f.go\n+++ b/f.go\n@@ -0,0 +1 @@\n+a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f.go", tt.old, tt.new); got != tt.want {
+				t.Errorf("unifiedDiff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// GetDocCommentsInput represents the input for doc comment extraction
+type GetDocCommentsInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// DocCommentsOutput represents the result of doc comment extraction
+type DocCommentsOutput struct {
+	Success           bool         `json:"success"`
+	Symbols           []DocComment `json:"symbols"`
+	Count             int          `json:"count"`
+	UndocumentedCount int          `json:"undocumented_count"`
+	Error             string       `json:"error,omitempty"`
+	Diagnostics       []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// DocComment is the doc comment of a single exported symbol
+type DocComment struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`               // "function", "method", "type", "const", "var"
+	Receiver string `json:"receiver,omitempty"` // Receiver type name, for methods
+	Line     int    `json:"line"`
+	Doc      string `json:"doc"` // Comment text without comment markers or directives; "" when undocumented
+}
+
+// GetDocComments returns the doc comment of each exported symbol in source
+// order. Types, constants and variables without their own comment take the
+// comment of their declaration, which documents ungrouped specs and whole
+// groups. Methods on unexported types are skipped, as they are not part of
+// the public API. Undocumented symbols are listed with an empty Doc.
+func GetDocComments(code string) (*DocCommentsOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &DocCommentsOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	result := &DocCommentsOutput{
+		Success: true,
+		Symbols: []DocComment{},
+	}
+	add := func(name *ast.Ident, kind, receiver string, doc *ast.CommentGroup) {
+		if !name.IsExported() {
+			return
+		}
+		text := ""
+		if doc != nil {
+			// Text drops the comment markers and directives such as //go:generate
+			text = strings.TrimSpace(doc.Text())
+		}
+		if text == "" {
+			result.UndocumentedCount++
+		}
+		result.Symbols = append(result.Symbols, DocComment{
+			Name:     name.Name,
+			Kind:     kind,
+			Receiver: receiver,
+			Line:     fset.Position(name.Pos()).Line,
+			Doc:      text,
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				receiver := receiverTypeName(d.Recv.List[0].Type)
+				if !ast.IsExported(receiver) {
+					continue
+				}
+				add(d.Name, "method", receiver, d.Doc)
+			} else {
+				add(d.Name, "function", "", d.Doc)
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name, "type", "", specDoc(s.Doc, d))
+
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range s.Names {
+						add(name, kind, "", specDoc(s.Doc, d))
+					}
+				}
+			}
+		}
+	}
+
+	result.Count = len(result.Symbols)
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// DocCoverageInput represents the input for documentation coverage
+type DocCoverageInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// DocCoverageOutput represents the result of documentation coverage analysis
+type DocCoverageOutput struct {
+	Success         bool         `json:"success"`
+	Symbols         []SymbolDocs `json:"symbols"`
+	ExportedCount   int          `json:"exported_count"`
+	DocumentedCount int          `json:"documented_count"`
+	CoveragePercent float64      `json:"coverage_percent"`
+	Error           string       `json:"error,omitempty"`
+	Diagnostics     []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// SymbolDocs describes the documentation of a single exported symbol
+type SymbolDocs struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"` // "function", "method", "type", "const", "var"
+	Line       int    `json:"line"`
+	Documented bool   `json:"documented"`
+	WordCount  int    `json:"word_count"`
+}
+
+// DocCoverage reports, for each exported symbol, whether it has a doc comment
+// and how long it is, along with the percentage of the public API documented
+func DocCoverage(code string) (*DocCoverageOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &DocCoverageOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	symbols := []SymbolDocs{}
+	forEachExported(file, func(name *ast.Ident, kind string, doc *ast.CommentGroup) {
+		words := 0
+		if doc != nil {
+			words = len(strings.Fields(doc.Text()))
+		}
+		symbols = append(symbols, SymbolDocs{
+			Name:       name.Name,
+			Kind:       kind,
+			Line:       fset.Position(name.Pos()).Line,
+			Documented: words > 0,
+			WordCount:  words,
+		})
+	})
+
+	documented := 0
+	for _, sym := range symbols {
+		if sym.Documented {
+			documented++
+		}
+	}
+
+	coverage := 100.0
+	if len(symbols) > 0 {
+		coverage = float64(documented) * 100 / float64(len(symbols))
+	}
+
+	return &DocCoverageOutput{
+		Success:         true,
+		Symbols:         symbols,
+		ExportedCount:   len(symbols),
+		DocumentedCount: documented,
+		CoveragePercent: coverage,
+	}, nil
+}
+
+// forEachExported calls fn for each exported top-level symbol of file, and
+// each exported method of an exported type, with its kind ("function",
+// "method", "type", "const" or "var") and doc comment, if any
+func forEachExported(file *ast.File, fn func(name *ast.Ident, kind string, doc *ast.CommentGroup)) {
+	add := func(name *ast.Ident, kind string, doc *ast.CommentGroup) {
+		if name.IsExported() {
+			fn(name, kind, doc)
+		}
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				// Methods on unexported types are not part of the public API
+				if !ast.IsExported(receiverTypeName(d.Recv.List[0].Type)) {
+					continue
+				}
+				add(d.Name, "method", d.Doc)
+			} else {
+				add(d.Name, "function", d.Doc)
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name, "type", specDoc(s.Doc, d))
+
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range s.Names {
+						add(name, kind, specDoc(s.Doc, d))
+					}
+				}
+			}
+		}
+	}
+}
+
+// specDoc returns the doc comment for a spec, falling back to the enclosing
+// declaration's doc comment (which documents ungrouped specs and whole groups)
+func specDoc(doc *ast.CommentGroup, decl *ast.GenDecl) *ast.CommentGroup {
+	if doc != nil {
+		return doc
+	}
+	return decl.Doc
+}
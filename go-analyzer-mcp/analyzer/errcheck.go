@@ -0,0 +1,241 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FindUncheckedErrorsInput represents the input for unchecked error detection
+type FindUncheckedErrorsInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// UncheckedErrorsOutput represents the result of unchecked error detection
+type UncheckedErrorsOutput struct {
+	Success           bool             `json:"success"`
+	Unchecked         []UncheckedError `json:"unchecked"`
+	Count             int              `json:"count"`
+	UnresolvedImports []string         `json:"unresolved_imports,omitempty"` // Imports outside the standard library, whose calls are not checked
+	Error             string           `json:"error,omitempty"`
+	Diagnostics       []Diagnostic     `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// UncheckedError represents a call whose error result is dropped
+type UncheckedError struct {
+	Call     string `json:"call"`               // Called function, e.g. "os.Remove" or "(*os.File).Close"
+	Function string `json:"function,omitempty"` // Enclosing function declaration
+	Kind     string `json:"kind"`               // "ignored" for a call statement, "blank" for an error assigned to _
+	Message  string `json:"message"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// errcheckExcluded lists functions whose errors are conventionally ignored
+var errcheckExcluded = map[string]bool{
+	"fmt.Print":   true,
+	"fmt.Printf":  true,
+	"fmt.Println": true,
+}
+
+// errcheckExcludedReceivers lists in-memory buffers, whose writes never fail
+var errcheckExcludedReceivers = []string{"(*bytes.Buffer).", "(*strings.Builder)."}
+
+// gcImporter loads standard library packages from the toolchain's export
+// data. It keeps a cache and is shared, so access is serialized.
+var (
+	gcImporterMu sync.Mutex
+	gcImporter   = importer.Default()
+)
+
+// stdImporter imports standard library packages with their types and stubs
+// every other import, recording which ones it stubbed
+type stdImporter struct {
+	stubbed []string
+}
+
+func (imp *stdImporter) Import(path string) (*types.Package, error) {
+	gcImporterMu.Lock()
+	pkg, err := gcImporter.Import(path)
+	gcImporterMu.Unlock()
+	if err == nil {
+		return pkg, nil
+	}
+	imp.stubbed = append(imp.stubbed, path)
+	return stubImporter{}.Import(path)
+}
+
+// FindUncheckedErrors flags calls whose error result is dropped, either
+// because the call is a statement of its own or because the error is
+// assigned to the blank identifier. Errors assigned to a variable count as
+// handled. Calls are resolved with go/types: functions declared in the file
+// and in the standard library are checked, calls into other packages are
+// not. Printing with fmt.Print, fmt.Printf and fmt.Println and writing to a
+// bytes.Buffer or strings.Builder are not reported.
+func FindUncheckedErrors(code string) (*UncheckedErrorsOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &UncheckedErrorsOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	typesInfo := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	imp := &stdImporter{}
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	pkg, _ := conf.Check(file.Name.Name, fset, []*ast.File{file}, typesInfo)
+
+	result := &UncheckedErrorsOutput{
+		Success:           true,
+		Unchecked:         []UncheckedError{},
+		UnresolvedImports: imp.stubbed,
+	}
+	report := func(call *ast.CallExpr, pos token.Pos, kind string) {
+		name := calledFuncName(call, typesInfo, pkg)
+		if uncheckedErrorExcluded(name) {
+			return
+		}
+		message := fmt.Sprintf("error returned by %s is not checked", name)
+		if kind == "blank" {
+			message = fmt.Sprintf("error returned by %s is assigned to _", name)
+		}
+		position := fset.Position(pos)
+		result.Unchecked = append(result.Unchecked, UncheckedError{
+			Call:     name,
+			Function: enclosingFuncName(file, pos),
+			Kind:     kind,
+			Message:  message,
+			Line:     position.Line,
+			Column:   position.Column,
+		})
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		var lhs, rhs []ast.Expr
+		switch stmt := n.(type) {
+		case *ast.ExprStmt:
+			if call, ok := ast.Unparen(stmt.X).(*ast.CallExpr); ok && len(errorResults(call, typesInfo)) > 0 {
+				report(call, call.Pos(), "ignored")
+			}
+			return true
+		case *ast.AssignStmt:
+			lhs, rhs = stmt.Lhs, stmt.Rhs
+		case *ast.ValueSpec:
+			for _, name := range stmt.Names {
+				lhs = append(lhs, name)
+			}
+			rhs = stmt.Values
+		default:
+			return true
+		}
+
+		// Either one call assigned to several variables, or one value each
+		if len(rhs) == 1 && len(lhs) > 1 {
+			if call, ok := ast.Unparen(rhs[0]).(*ast.CallExpr); ok {
+				for _, i := range errorResults(call, typesInfo) {
+					if i < len(lhs) && isBlank(lhs[i]) {
+						report(call, lhs[i].Pos(), "blank")
+					}
+				}
+			}
+			return true
+		}
+		for i := range min(len(lhs), len(rhs)) {
+			if call, ok := ast.Unparen(rhs[i]).(*ast.CallExpr); ok && isBlank(lhs[i]) && len(errorResults(call, typesInfo)) > 0 {
+				report(call, lhs[i].Pos(), "blank")
+			}
+		}
+		return true
+	})
+
+	sort.SliceStable(result.Unchecked, func(i, j int) bool {
+		a, b := result.Unchecked[i], result.Unchecked[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	result.Count = len(result.Unchecked)
+	return result, nil
+}
+
+// errorResults returns the indexes of the results of call that have type
+// error. Conversions and calls that could not be resolved have none.
+func errorResults(call *ast.CallExpr, typesInfo *types.Info) []int {
+	if fn, ok := typesInfo.Types[call.Fun]; !ok || fn.IsType() || fn.IsBuiltin() {
+		return nil
+	}
+	tv, ok := typesInfo.Types[call]
+	if !ok || tv.Type == nil {
+		return nil
+	}
+
+	errorType := types.Universe.Lookup("error").Type()
+	var indexes []int
+	if tuple, ok := tv.Type.(*types.Tuple); ok {
+		for i := range tuple.Len() {
+			if types.Identical(tuple.At(i).Type(), errorType) {
+				indexes = append(indexes, i)
+			}
+		}
+	} else if types.Identical(tv.Type, errorType) {
+		indexes = append(indexes, 0)
+	}
+	return indexes
+}
+
+// calledFuncName names the function called by call, qualified by its
+// receiver or, outside pkg, its package path when it is known
+func calledFuncName(call *ast.CallExpr, typesInfo *types.Info, pkg *types.Package) string {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	}
+	if ident != nil {
+		if fn, ok := typesInfo.Uses[ident].(*types.Func); ok {
+			if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+				return fmt.Sprintf("(%s).%s", types.TypeString(sig.Recv().Type(), types.RelativeTo(pkg)), fn.Name())
+			}
+			if fn.Pkg() == nil || fn.Pkg() == pkg {
+				return fn.Name()
+			}
+			return fn.Pkg().Path() + "." + fn.Name()
+		}
+	}
+	return types.ExprString(call.Fun)
+}
+
+// uncheckedErrorExcluded reports whether the errors of the named function are
+// conventionally ignored
+func uncheckedErrorExcluded(name string) bool {
+	if errcheckExcluded[name] {
+		return true
+	}
+	for _, receiver := range errcheckExcludedReceivers {
+		if strings.HasPrefix(name, receiver) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlank reports whether expr is the blank identifier
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
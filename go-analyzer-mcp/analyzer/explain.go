@@ -0,0 +1,235 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// ExplainInput represents the input for explained analysis
+type ExplainInput struct {
+	Code string `json:"code" jsonschema:"Go source code to analyze"`
+}
+
+// ExplainOutput represents the findings of the AST-based checks, with fixes
+type ExplainOutput struct {
+	Success     bool         `json:"success"`
+	Findings    []Diagnostic `json:"findings"` // Sorted by position; Check names the check
+	Count       int          `json:"count"`
+	FixCount    int          `json:"fix_count"` // Findings with a SuggestedFix
+	Error       string       `json:"error,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// Explain runs the AST-based checks over code and returns their findings as
+// diagnostics tagged with the check that reported them. Checks that can
+// produce a concrete edit set SuggestedFix to the replacement code.
+func Explain(code string) (*ExplainOutput, error) {
+	if err := checkSource(code); err != nil {
+		return nil, err
+	}
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &ExplainOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	findings := []Diagnostic{}
+	add := func(check string, line, column int, message, severity, fix string) {
+		findings = append(findings, Diagnostic{
+			File:         "temp.go",
+			Line:         line,
+			Column:       column,
+			Message:      message,
+			Severity:     severity,
+			Check:        check,
+			SuggestedFix: fix,
+		})
+	}
+
+	detached, err := FindDetachedContext(code)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range detached.Findings {
+		add("detached_context", f.Line, f.Column,
+			f.Call+" ignores the context parameter "+f.ContextParam+" of "+f.Function, "warning", f.SuggestedFix)
+	}
+
+	waitGroups, err := FindWaitGroupMisuse(code)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range waitGroups.Findings {
+		add("waitgroup_misuse", f.Line, f.Column, f.Message, "warning", f.SuggestedFix)
+	}
+
+	mutations, err := FindDeferredReturnMutation(code)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range mutations.Mutations {
+		add("deferred_return_mutation", f.Line, f.Column, f.Message, "warning", "")
+	}
+
+	nolint, err := CheckNolintJustification(code)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range nolint.Unjustified {
+		add("nolint_justification", f.Line, f.Column, f.Message, "warning", "")
+	}
+
+	findings = append(findings, findRedundantElse(file, fset, code)...)
+	findings = append(findings, findBoolLiteralComparisons(file, fset)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+
+	result := &ExplainOutput{
+		Success:  true,
+		Findings: findings,
+		Count:    len(findings),
+	}
+	for _, f := range findings {
+		if f.SuggestedFix != "" {
+			result.FixCount++
+		}
+	}
+	return result, nil
+}
+
+// findRedundantElse flags else blocks following an if body that always ends
+// in return, break, continue or goto. The suggested fix is the if statement
+// with the else block's statements moved after it. Ifs with an init
+// statement are skipped, since its variables would go out of scope.
+func findRedundantElse(file *ast.File, fset *token.FileSet, code string) []Diagnostic {
+	var findings []Diagnostic
+	ast.Inspect(file, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.IfStmt)
+		if !ok || stmt.Init != nil || !endsInJump(stmt.Body) {
+			return true
+		}
+		elseBlock, ok := stmt.Else.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+
+		pos := fset.Position(elseBlock.Pos())
+		findings = append(findings, Diagnostic{
+			File:         "temp.go",
+			Line:         pos.Line,
+			Column:       pos.Column,
+			Message:      "if block ends with a jump, so the else is unnecessary; drop it and outdent its body",
+			Severity:     "warning",
+			Check:        "redundant_else",
+			SuggestedFix: flattenElse(stmt, elseBlock, fset, code),
+		})
+		return true
+	})
+	return findings
+}
+
+// endsInJump reports whether the last statement of block leaves it
+// unconditionally
+func endsInJump(block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	switch last := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BranchStmt:
+		return last.Tok != token.FALLTHROUGH
+	}
+	return false
+}
+
+// flattenElse renders stmt without its else, followed by the statements of
+// elseBlock outdented by one level
+func flattenElse(stmt *ast.IfStmt, elseBlock *ast.BlockStmt, fset *token.FileSet, code string) string {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	fix := code[offset(stmt.Pos()):offset(stmt.Body.End())]
+	if len(elseBlock.List) == 0 {
+		return fix
+	}
+
+	body := code[offset(elseBlock.List[0].Pos()):offset(elseBlock.List[len(elseBlock.List)-1].End())]
+	lines := strings.Split(body, "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], "\t")
+	}
+
+	// Continue at the indentation of the if statement
+	lineStart := strings.LastIndex(code[:offset(stmt.Pos())], "\n") + 1
+	indent := code[lineStart:offset(stmt.Pos())]
+	if strings.TrimSpace(indent) != "" {
+		indent = ""
+	}
+	return fix + "\n" + indent + strings.Join(lines, "\n")
+}
+
+// findBoolLiteralComparisons flags comparisons against true or false, such
+// as ok == true, suggesting the simplified expression
+func findBoolLiteralComparisons(file *ast.File, fset *token.FileSet) []Diagnostic {
+	var findings []Diagnostic
+	ast.Inspect(file, func(n ast.Node) bool {
+		expr, ok := n.(*ast.BinaryExpr)
+		if !ok || (expr.Op != token.EQL && expr.Op != token.NEQ) {
+			return true
+		}
+
+		other, literal := expr.X, boolLiteral(expr.Y)
+		if literal == "" {
+			other, literal = expr.Y, boolLiteral(expr.X)
+		}
+		if literal == "" || boolLiteral(other) != "" {
+			return true
+		}
+
+		// x == true and x != false simplify to x; the others negate it
+		fix := types.ExprString(other)
+		if (literal == "true") != (expr.Op == token.EQL) {
+			switch other.(type) {
+			case *ast.Ident, *ast.SelectorExpr, *ast.CallExpr, *ast.IndexExpr, *ast.ParenExpr:
+				fix = "!" + fix
+			default:
+				fix = "!(" + fix + ")"
+			}
+		}
+
+		pos := fset.Position(expr.Pos())
+		findings = append(findings, Diagnostic{
+			File:         "temp.go",
+			Line:         pos.Line,
+			Column:       pos.Column,
+			Message:      "comparison with " + literal + " can be simplified to " + fix,
+			Severity:     "warning",
+			Check:        "bool_literal_comparison",
+			SuggestedFix: fix,
+		})
+		return true
+	})
+	return findings
+}
+
+// boolLiteral returns "true" or "false" when expr is that predeclared
+// identifier, and "" otherwise. Identifiers resolved to a declaration in the
+// file shadow the predeclared ones and are not literals.
+func boolLiteral(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok && ident.Obj == nil && (ident.Name == "true" || ident.Name == "false") {
+		return ident.Name
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultFanoutThreshold is the import count above which a file is flagged
+const DefaultFanoutThreshold = 15
+
+// ImportFanoutInput represents the input for an import fan-out report
+type ImportFanoutInput struct {
+	Directory    string   `json:"directory" jsonschema:"Directory containing the Go files to analyze, relative to the source root"`
+	Threshold    int      `json:"threshold,omitempty" jsonschema:"Flag files importing more than this many distinct packages (default: 15)"`
+	ModulePath   string   `json:"modulePath,omitempty" jsonschema:"Optional module path; imports under it are classified as intra-module"`
+	Recursive    bool     `json:"recursive,omitempty" jsonschema:"Also analyze subdirectories, skipping vendor, testdata and hidden directories"`
+	IncludeTests bool     `json:"includeTests,omitempty" jsonschema:"Include _test.go files (default: false)"`
+	BuildTags    []string `json:"buildTags,omitempty" jsonschema:"Optional build tags; when any build option is set, files excluded by their build constraints are skipped"`
+	GOOS         string   `json:"goos,omitempty" jsonschema:"Optional target operating system used to evaluate build constraints, e.g. 'windows'"`
+	GOARCH       string   `json:"goarch,omitempty" jsonschema:"Optional target architecture used to evaluate build constraints, e.g. 'arm64'"`
+}
+
+// ImportFanoutOutput represents the result of an import fan-out report
+type ImportFanoutOutput struct {
+	Success       bool         `json:"success"`
+	Directory     string       `json:"directory"`
+	Threshold     int          `json:"threshold"`
+	Files         []FileFanout `json:"files"` // Sorted by import count, highest first
+	FileCount     int          `json:"file_count"`
+	FlaggedCount  int          `json:"flagged_count"`
+	ExcludedFiles []string     `json:"excluded_files,omitempty"` // Skipped because of their build constraints
+	Error         string       `json:"error,omitempty"`
+}
+
+// FileFanout represents the import fan-out of a single file
+type FileFanout struct {
+	File            string `json:"file"` // Relative to the analyzed directory
+	ImportCount     int    `json:"import_count"`
+	StdlibCount     int    `json:"stdlib_count"`
+	ThirdPartyCount int    `json:"third_party_count"`
+	ModuleCount     int    `json:"module_count"`
+	Flagged         bool   `json:"flagged"`
+	Error           string `json:"error,omitempty"` // Set when the file could not be read or parsed
+}
+
+// ImportFanout reports, for every Go file in a directory, how many distinct
+// packages it imports, flagging files above the threshold. Files that fail to
+// parse are listed with their error rather than failing the whole report.
+// When build tags or a GOOS/GOARCH are given, files whose build constraints
+// or file name suffixes exclude them from that build are skipped. The
+// directory must lie within SourceRoot.
+func ImportFanout(input ImportFanoutInput) (*ImportFanoutOutput, error) {
+	threshold := input.Threshold
+	if threshold <= 0 {
+		threshold = DefaultFanoutThreshold
+	}
+
+	if err := validateBuildContext(input.BuildTags, input.GOOS, input.GOARCH); err != nil {
+		return &ImportFanoutOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	dir, err := resolveSourcePath("directory", input.Directory)
+	if err != nil {
+		return &ImportFanoutOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return &ImportFanoutOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if !info.IsDir() {
+		return &ImportFanoutOutput{
+			Success: false,
+			Error:   fmt.Sprintf("%s is not a directory", input.Directory),
+		}, nil
+	}
+
+	paths, err := goFilesIn(dir, input.Recursive, input.IncludeTests)
+	if err != nil {
+		return &ImportFanoutOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	result := &ImportFanoutOutput{
+		Success:   true,
+		Directory: input.Directory,
+		Threshold: threshold,
+		Files:     []FileFanout{},
+	}
+
+	// Build constraints are only evaluated when a target is given, so that by
+	// default every file is reported
+	var buildCtx *build.Context
+	if len(input.BuildTags) > 0 || input.GOOS != "" || input.GOARCH != "" {
+		ctx := targetBuildContext(input.BuildTags, input.GOOS, input.GOARCH)
+		buildCtx = &ctx
+	}
+
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		fanout := FileFanout{File: filepath.ToSlash(rel)}
+
+		if buildCtx != nil {
+			match, err := buildCtx.MatchFile(filepath.Dir(path), filepath.Base(path))
+			if err == nil && !match {
+				result.ExcludedFiles = append(result.ExcludedFiles, fanout.File)
+				continue
+			}
+		}
+
+		code, err := os.ReadFile(path)
+		if err != nil {
+			fanout.Error = err.Error()
+			result.Files = append(result.Files, fanout)
+			continue
+		}
+
+		imports, err := GetImports(string(code), input.ModulePath)
+		if err != nil {
+			return nil, err
+		}
+		if !imports.Success {
+			fanout.Error = imports.Error
+			result.Files = append(result.Files, fanout)
+			continue
+		}
+
+		// Count each package once, even if imported under several names
+		seen := map[string]bool{}
+		for _, imp := range imports.Imports {
+			if seen[imp.Path] {
+				continue
+			}
+			seen[imp.Path] = true
+			fanout.ImportCount++
+			switch imp.Kind {
+			case "stdlib":
+				fanout.StdlibCount++
+			case "third_party":
+				fanout.ThirdPartyCount++
+			case "module":
+				fanout.ModuleCount++
+			}
+		}
+
+		if fanout.ImportCount > threshold {
+			fanout.Flagged = true
+			result.FlaggedCount++
+		}
+		result.Files = append(result.Files, fanout)
+	}
+
+	sort.SliceStable(result.Files, func(i, j int) bool {
+		return result.Files[i].ImportCount > result.Files[j].ImportCount
+	})
+	result.FileCount = len(result.Files)
+
+	return result, nil
+}
+
+// goFilesIn lists the Go files in dir in lexical order, descending into
+// subdirectories when recursive is set. Like the go command, it skips vendor
+// and testdata directories and those starting with "." or "_".
+func goFilesIn(dir string, recursive, includeTests bool) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+
+		if d.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if !recursive || name == "vendor" || name == "testdata" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(name, ".go") || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			return nil
+		}
+		if !includeTests && strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
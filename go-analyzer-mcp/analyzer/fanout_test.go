@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportFanout(t *testing.T) {
+	withSourceRoot(t, map[string]string{
+		"pkg/a.go":      "package pkg\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\t\"strings\"\n)\n",
+		"pkg/b.go":      "package pkg\n\nimport \"fmt\"\n",
+		"pkg/b_test.go": "package pkg\n\nimport \"testing\"\n",
+		"pkg/sub/c.go":  "package sub\n",
+		"pkg/bad.go":    "package pkg\n\nimport (\n",
+		"pkg/win.go":    "//go:build windows\n\npackage pkg\n",
+		"file.go":       "package root\n",
+	})
+
+	tests := []struct {
+		name    string
+		input   ImportFanoutInput
+		files   []string // Reported files, highest fan-out first
+		flagged int
+		wantErr string
+	}{
+		{
+			name:    "directory",
+			input:   ImportFanoutInput{Directory: "pkg", Threshold: 2},
+			files:   []string{"a.go", "b.go", "bad.go", "win.go"},
+			flagged: 1,
+		},
+		{
+			name:  "recursive with tests",
+			input: ImportFanoutInput{Directory: "pkg", Recursive: true, IncludeTests: true},
+			files: []string{"a.go", "b.go", "b_test.go", "bad.go", "sub/c.go", "win.go"},
+		},
+		{
+			name:  "build target",
+			input: ImportFanoutInput{Directory: "pkg", GOOS: "linux"},
+			files: []string{"a.go", "b.go", "bad.go"},
+		},
+		{name: "not a directory", input: ImportFanoutInput{Directory: "file.go"}, wantErr: "is not a directory"},
+		{name: "parent", input: ImportFanoutInput{Directory: ".."}, wantErr: "directory .. is outside the source root"},
+		{name: "absolute outside", input: ImportFanoutInput{Directory: "/etc"}, wantErr: "directory /etc is outside the source root"},
+		{name: "missing", input: ImportFanoutInput{Directory: "nope"}, wantErr: "directory nope does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ImportFanout(tt.input)
+			if err != nil {
+				t.Fatalf("ImportFanout: %v", err)
+			}
+			if tt.wantErr != "" {
+				if result.Success || !strings.Contains(result.Error, tt.wantErr) {
+					t.Fatalf("ImportFanout error = %q, want one containing %q", result.Error, tt.wantErr)
+				}
+				return
+			}
+			if !result.Success {
+				t.Fatalf("ImportFanout failed: %s", result.Error)
+			}
+			var files []string
+			for _, file := range result.Files {
+				files = append(files, file.File)
+			}
+			if strings.Join(files, " ") != strings.Join(tt.files, " ") {
+				t.Errorf("files = %v, want %v", files, tt.files)
+			}
+			if result.FlaggedCount != tt.flagged {
+				t.Errorf("FlaggedCount = %d, want %d", result.FlaggedCount, tt.flagged)
+			}
+		})
+	}
+}
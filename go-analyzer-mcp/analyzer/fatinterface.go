@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+)
+
+// DefaultMaxInterfaceMethods is the method count above which an interface is
+// flagged when no limit is given
+const DefaultMaxInterfaceMethods = 5
+
+// FindFatInterfacesInput represents the input for fat interface detection
+type FindFatInterfacesInput struct {
+	Code       string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath   string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	MaxMethods int    `json:"maxMethods,omitempty" jsonschema:"Flag interfaces with more than this many methods (default: 5)"`
+}
+
+// FatInterfaceOutput represents the result of fat interface detection
+type FatInterfaceOutput struct {
+	Success     bool           `json:"success"`
+	Interfaces  []FatInterface `json:"interfaces"`
+	Count       int            `json:"count"`
+	MaxMethods  int            `json:"max_methods"` // The limit applied
+	Error       string         `json:"error,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// FatInterface represents an interface declaring more methods than allowed
+type FatInterface struct {
+	Name        string   `json:"name"`
+	MethodCount int      `json:"method_count"`
+	Methods     []string `json:"methods"`            // Including those of embedded interfaces declared in the file
+	Embedded    []string `json:"embedded,omitempty"` // Embedded interfaces declared elsewhere, whose methods are not counted
+	Message     string   `json:"message"`
+	Line        int      `json:"line"`
+	Column      int      `json:"column"`
+}
+
+// FindFatInterfaces flags interface types declared in code with more than
+// maxMethods methods, or DefaultMaxInterfaceMethods when maxMethods is not
+// positive. Methods of embedded interfaces declared in the same file count
+// towards the total; embedded interfaces from other packages are listed but
+// not counted.
+func FindFatInterfaces(code string, maxMethods int) (*FatInterfaceOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &FatInterfaceOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	if maxMethods <= 0 {
+		maxMethods = DefaultMaxInterfaceMethods
+	}
+	result := &FatInterfaceOutput{
+		Success:    true,
+		Interfaces: []FatInterface{},
+		MaxMethods: maxMethods,
+	}
+
+	interfaces := map[string]*ast.InterfaceType{}
+	var specs []*ast.TypeSpec
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok {
+			if iface, ok := spec.Type.(*ast.InterfaceType); ok {
+				interfaces[spec.Name.Name] = iface
+				specs = append(specs, spec)
+			}
+		}
+		return true
+	})
+
+	for _, spec := range specs {
+		var methods, embedded []string
+		seen := map[string]bool{}
+		collectInterfaceMethods(interfaces[spec.Name.Name], interfaces, seen, map[string]bool{spec.Name.Name: true}, &methods, &embedded)
+		if len(methods) <= maxMethods {
+			continue
+		}
+
+		pos := fset.Position(spec.Name.Pos())
+		result.Interfaces = append(result.Interfaces, FatInterface{
+			Name:        spec.Name.Name,
+			MethodCount: len(methods),
+			Methods:     methods,
+			Embedded:    embedded,
+			Message: fmt.Sprintf("interface %s has %d methods (limit %d); consider splitting it into smaller interfaces that callers can depend on separately",
+				spec.Name.Name, len(methods), maxMethods),
+			Line:   pos.Line,
+			Column: pos.Column,
+		})
+	}
+
+	result.Count = len(result.Interfaces)
+	return result, nil
+}
+
+// collectInterfaceMethods appends the methods of iface, following embedded
+// interfaces declared in the file. visiting guards against embedding cycles;
+// seen drops methods reached through more than one embedding.
+func collectInterfaceMethods(iface *ast.InterfaceType, interfaces map[string]*ast.InterfaceType, seen, visiting map[string]bool, methods, embedded *[]string) {
+	for _, field := range iface.Methods.List {
+		if len(field.Names) > 0 {
+			for _, name := range field.Names {
+				if !seen[name.Name] {
+					seen[name.Name] = true
+					*methods = append(*methods, name.Name)
+				}
+			}
+			continue
+		}
+
+		// Embedded element: a local interface, a foreign one, or a constraint
+		switch t := field.Type.(type) {
+		case *ast.Ident:
+			if inner, ok := interfaces[t.Name]; ok && !visiting[t.Name] {
+				visiting[t.Name] = true
+				collectInterfaceMethods(inner, interfaces, seen, visiting, methods, embedded)
+			}
+		case *ast.SelectorExpr:
+			*embedded = append(*embedded, types.ExprString(t))
+		}
+	}
+}
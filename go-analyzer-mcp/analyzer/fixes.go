@@ -0,0 +1,423 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"go/version"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/assign"
+	"golang.org/x/tools/go/analysis/passes/composite"
+	"golang.org/x/tools/go/analysis/passes/hostport"
+	"golang.org/x/tools/go/analysis/passes/modernize"
+	"golang.org/x/tools/go/analysis/passes/printf"
+	"golang.org/x/tools/go/analysis/passes/sigchanyzer"
+	"golang.org/x/tools/go/analysis/passes/sortslice"
+	"golang.org/x/tools/go/analysis/passes/stringintconv"
+	"golang.org/x/tools/go/analysis/passes/timeformat"
+	"golang.org/x/tools/go/analysis/passes/unreachable"
+)
+
+// ApplyFixesInput represents the input for applying suggested fixes
+type ApplyFixesInput struct {
+	Code      string   `json:"code,omitempty" jsonschema:"Go source code to fix"`
+	FilePath  string   `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Analyzers []string `json:"analyzers,omitempty" jsonschema:"Analyzers whose fixes to apply (default: all analyzers with fixes)"`
+	GoVersion string   `json:"goVersion,omitempty" jsonschema:"Go language version the fixed code must build with, such as go1.21, which limits the modernize fixes (default: the server's Go version)"`
+}
+
+// ApplyFixesOutput represents the result of applying suggested fixes
+type ApplyFixesOutput struct {
+	Success           bool         `json:"success"`
+	FixedCode         string       `json:"fixed_code,omitempty"`
+	Changed           bool         `json:"changed"`
+	Fixes             []AppliedFix `json:"fixes"`             // Fixes applied, in source order
+	Skipped           []AppliedFix `json:"skipped,omitempty"` // Fixes that overlap an applied fix; run again to apply them
+	Count             int          `json:"count"`
+	Diff              string       `json:"diff,omitempty"` // Unified diff from the input to the fixed code
+	UnresolvedImports []string     `json:"unresolved_imports,omitempty"`
+	AnalyzerErrors    []string     `json:"analyzer_errors,omitempty"` // Analyzers that failed on the code and contributed no fixes
+	Error             string       `json:"error,omitempty"`
+	Diagnostics       []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// AppliedFix represents a suggested fix of an analyzer diagnostic
+type AppliedFix struct {
+	Analyzer string `json:"analyzer"`
+	Message  string `json:"message"`       // The diagnostic the fix resolves
+	Fix      string `json:"fix,omitempty"` // What the fix does
+	Line     int    `json:"line"`          // Position of the diagnostic in the input
+	Column   int    `json:"column"`
+}
+
+// fixAnalyzers are the analyzers whose suggested fixes ApplyFixes can apply,
+// keyed by name: the modernize suite and the go vet analyzers with fixes
+var fixAnalyzers = func() map[string]*analysis.Analyzer {
+	analyzers := map[string]*analysis.Analyzer{}
+	for _, a := range append([]*analysis.Analyzer{
+		assign.Analyzer, composite.Analyzer, hostport.Analyzer, printf.Analyzer,
+		sigchanyzer.Analyzer, sortslice.Analyzer, stringintconv.Analyzer,
+		timeformat.Analyzer, unreachable.Analyzer,
+	}, modernize.Suite...) {
+		if analyzers[a.Name] != nil {
+			panic("duplicate fix analyzer " + a.Name)
+		}
+		analyzers[a.Name] = a
+	}
+	return analyzers
+}()
+
+// FixAnalyzers returns the names of the analyzers ApplyFixes can apply, sorted
+func FixAnalyzers() []string {
+	names := make([]string, 0, len(fixAnalyzers))
+	for name := range fixAnalyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyFixes runs analyzers from golang.org/x/tools in process over code and
+// applies the text edits of their suggested fixes, returning the fixed code
+// and the fixes applied. Code that was gofmt-formatted is formatted again
+// after the fixes; other code keeps its layout. The analyzers default to
+// every analyzer in FixAnalyzers. Code is type-checked against the standard
+// library; other imports are stubbed and listed in UnresolvedImports. The
+// analyzers expect well-typed code, so uses of stubbed imports may make one
+// fail, in which case it is listed in AnalyzerErrors and the others still
+// run. When a diagnostic offers several fixes the first is used, and a fix
+// whose edits overlap those of an earlier applied fix is skipped, since the
+// fixes are applied in one pass. Facts are only shared within the file, so
+// analyzers that learn about functions in other packages, such as printf
+// wrappers, see less than under go vet.
+func ApplyFixes(input ApplyFixesInput) (*ApplyFixesOutput, error) {
+	var err error
+	input.Code, err = LoadSource(input.Code, input.FilePath)
+	if err == nil {
+		err = checkSource(input.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	analyzers, err := selectFixAnalyzers(input.Analyzers)
+	if err != nil {
+		return nil, err
+	}
+	goVersion := input.GoVersion
+	if goVersion == "" {
+		goVersion = version.Lang(runtime.Version())
+	} else if !strings.HasPrefix(goVersion, "go") {
+		goVersion = "go" + goVersion
+	}
+	if !version.IsValid(goVersion) {
+		return nil, fmt.Errorf("invalid goVersion %q: use a Go version such as go1.22", input.GoVersion)
+	}
+
+	code, info := normalizeSource(input.Code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &ApplyFixesOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	typesInfo := &types.Info{
+		Types:        map[ast.Expr]types.TypeAndValue{},
+		Instances:    map[*ast.Ident]types.Instance{},
+		Defs:         map[*ast.Ident]types.Object{},
+		Uses:         map[*ast.Ident]types.Object{},
+		Implicits:    map[ast.Node]types.Object{},
+		Selections:   map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:       map[ast.Node]*types.Scope{},
+		FileVersions: map[*ast.File]string{},
+	}
+	imp := &stdImporter{}
+	conf := types.Config{Importer: imp, GoVersion: goVersion, Error: func(error) {}}
+	pkg, _ := conf.Check(file.Name.Name, fset, []*ast.File{file}, typesInfo)
+
+	run := &analysisRun{
+		fset:      fset,
+		file:      file,
+		pkg:       pkg,
+		typesInfo: typesInfo,
+		code:      code,
+		results:   map[*analysis.Analyzer]any{},
+		failed:    map[*analysis.Analyzer]error{},
+		facts:     map[factKey]analysis.Fact{},
+	}
+	result := &ApplyFixesOutput{
+		Success:           true,
+		Fixes:             []AppliedFix{},
+		UnresolvedImports: imp.stubbed,
+	}
+	var found []suggestedFix
+	for _, a := range analyzers {
+		diagnostics, err := run.analyze(a)
+		if err != nil {
+			result.AnalyzerErrors = append(result.AnalyzerErrors, err.Error())
+			continue
+		}
+		for _, diag := range diagnostics {
+			if len(diag.SuggestedFixes) == 0 {
+				continue
+			}
+			pos := fset.Position(diag.Pos)
+			found = append(found, suggestedFix{
+				AppliedFix: AppliedFix{
+					Analyzer: a.Name,
+					Message:  diag.Message,
+					Fix:      diag.SuggestedFixes[0].Message,
+					Line:     pos.Line,
+					Column:   pos.Column,
+				},
+				edits: diag.SuggestedFixes[0].TextEdits,
+			})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	var accepted []textEdit
+	for _, fix := range found {
+		edits, ok := run.textEdits(fix.edits)
+		if !ok || overlapsEdits(edits, accepted) {
+			result.Skipped = append(result.Skipped, fix.AppliedFix)
+			continue
+		}
+		accepted = append(accepted, edits...)
+		result.Fixes = append(result.Fixes, fix.AppliedFix)
+	}
+	result.Count = len(result.Fixes)
+
+	// Fixes may leave the code unformatted; code that was formatted before
+	// is formatted again, other code is left as the fixes made it
+	fixed := applyTextEdits(code, accepted)
+	if formatted, err := format.Source([]byte(code)); err == nil && string(formatted) == code {
+		if formatted, err := format.Source([]byte(fixed)); err == nil {
+			fixed = string(formatted)
+		}
+	}
+	result.FixedCode = info.restore(fixed)
+	result.Changed = result.FixedCode != input.Code
+	if result.Changed {
+		result.Diff = unifiedDiff("temp.go", input.Code, result.FixedCode)
+	}
+	return result, nil
+}
+
+// selectFixAnalyzers returns the named fix analyzers in name order, or all of
+// them when names is empty
+func selectFixAnalyzers(names []string) ([]*analysis.Analyzer, error) {
+	if len(names) == 0 {
+		names = FixAnalyzers()
+	}
+	selected := map[string]bool{}
+	var analyzers []*analysis.Analyzer
+	for _, name := range names {
+		a, ok := fixAnalyzers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown analyzer %q (valid analyzers: %s)", name, strings.Join(FixAnalyzers(), ", "))
+		}
+		if !selected[name] {
+			selected[name] = true
+			analyzers = append(analyzers, a)
+		}
+	}
+	sort.Slice(analyzers, func(i, j int) bool { return analyzers[i].Name < analyzers[j].Name })
+	return analyzers, nil
+}
+
+// suggestedFix is the first suggested fix of a diagnostic
+type suggestedFix struct {
+	AppliedFix
+	edits []analysis.TextEdit
+}
+
+// textEdit replaces code[start:end] with text
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// overlapsEdits reports whether any of edits overlaps one of accepted. Two
+// insertions at the same offset overlap unless they are identical, in which
+// case applyTextEdits keeps one of them.
+func overlapsEdits(edits, accepted []textEdit) bool {
+	for _, e := range edits {
+		for _, a := range accepted {
+			if e == a {
+				continue
+			}
+			if e.start < a.end && a.start < e.end || e.start == a.start && (e.start == e.end || a.start == a.end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyTextEdits applies non-overlapping edits to code, dropping duplicates
+func applyTextEdits(code string, edits []textEdit) string {
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].end < edits[j].end
+	})
+	var buf strings.Builder
+	offset := 0
+	for i, e := range edits {
+		if i > 0 && e == edits[i-1] {
+			continue
+		}
+		buf.WriteString(code[offset:e.start])
+		buf.WriteString(e.text)
+		offset = e.end
+	}
+	buf.WriteString(code[offset:])
+	return buf.String()
+}
+
+// analysisRun runs analyzers over one type-checked file, sharing the results
+// of required analyzers and the facts they export
+type analysisRun struct {
+	fset      *token.FileSet
+	file      *ast.File
+	pkg       *types.Package
+	typesInfo *types.Info
+	code      string
+	results   map[*analysis.Analyzer]any
+	failed    map[*analysis.Analyzer]error
+	facts     map[factKey]analysis.Fact
+}
+
+// factKey identifies a fact by its subject, an object or nil for the package,
+// and its type
+type factKey struct {
+	obj types.Object
+	typ reflect.Type
+}
+
+// analyze runs a, after the analyzers it requires, and returns its
+// diagnostics, which are only returned on its first run. An analyzer that
+// fails or panics on code it does not expect, for example because of stubbed
+// imports, is reported as an error, as are the analyzers requiring it.
+func (r *analysisRun) analyze(a *analysis.Analyzer) (diagnostics []analysis.Diagnostic, err error) {
+	if _, done := r.results[a]; done {
+		return nil, nil
+	}
+	if err := r.failed[a]; err != nil {
+		return nil, err
+	}
+	resultOf := map[*analysis.Analyzer]any{}
+	for _, req := range a.Requires {
+		if _, err := r.analyze(req); err != nil {
+			return nil, err
+		}
+		resultOf[req] = r.results[req]
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:   a,
+		Fset:       r.fset,
+		Files:      []*ast.File{r.file},
+		Pkg:        r.pkg,
+		TypesInfo:  r.typesInfo,
+		TypesSizes: types.SizesFor("gc", runtime.GOARCH),
+		ResultOf:   resultOf,
+		Report:     func(d analysis.Diagnostic) { diagnostics = append(diagnostics, d) },
+		ReadFile: func(filename string) ([]byte, error) {
+			if filename == r.fset.File(r.file.Pos()).Name() {
+				return []byte(r.code), nil
+			}
+			return nil, fmt.Errorf("cannot read %s", filename)
+		},
+		ImportObjectFact:  func(obj types.Object, fact analysis.Fact) bool { return r.importFact(obj, fact) },
+		ExportObjectFact:  func(obj types.Object, fact analysis.Fact) { r.exportFact(obj, fact) },
+		ImportPackageFact: func(pkg *types.Package, fact analysis.Fact) bool { return pkg == r.pkg && r.importFact(nil, fact) },
+		ExportPackageFact: func(fact analysis.Fact) { r.exportFact(nil, fact) },
+		AllObjectFacts: func() []analysis.ObjectFact {
+			var facts []analysis.ObjectFact
+			for key, fact := range r.facts {
+				if key.obj != nil {
+					facts = append(facts, analysis.ObjectFact{Object: key.obj, Fact: fact})
+				}
+			}
+			return facts
+		},
+		AllPackageFacts: func() []analysis.PackageFact {
+			var facts []analysis.PackageFact
+			for key, fact := range r.facts {
+				if key.obj == nil {
+					facts = append(facts, analysis.PackageFact{Package: r.pkg, Fact: fact})
+				}
+			}
+			return facts
+		},
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("analyzer %s failed: %v", a.Name, p)
+		}
+		if err != nil {
+			r.failed[a] = err
+		}
+	}()
+	result, err := a.Run(pass)
+	if err != nil {
+		return nil, fmt.Errorf("analyzer %s failed: %w", a.Name, err)
+	}
+	r.results[a] = result
+	return diagnostics, nil
+}
+
+// importFact copies the fact of fact's type recorded for obj into fact
+func (r *analysisRun) importFact(obj types.Object, fact analysis.Fact) bool {
+	stored, ok := r.facts[factKey{obj, reflect.TypeOf(fact)}]
+	if ok {
+		reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(stored).Elem())
+	}
+	return ok
+}
+
+// exportFact records fact for obj
+func (r *analysisRun) exportFact(obj types.Object, fact analysis.Fact) {
+	r.facts[factKey{obj, reflect.TypeOf(fact)}] = fact
+}
+
+// textEdits converts the edits of a suggested fix to offsets in the code. It
+// reports false when an edit falls outside the file.
+func (r *analysisRun) textEdits(edits []analysis.TextEdit) ([]textEdit, bool) {
+	tokFile := r.fset.File(r.file.Pos())
+	converted := make([]textEdit, 0, len(edits))
+	for _, e := range edits {
+		end := e.End
+		if !end.IsValid() {
+			end = e.Pos
+		}
+		if r.fset.File(e.Pos) != tokFile || r.fset.File(end) != tokFile {
+			return nil, false
+		}
+		start, stop := tokFile.Offset(e.Pos), tokFile.Offset(end)
+		if start > stop || stop > len(r.code) {
+			return nil, false
+		}
+		converted = append(converted, textEdit{start, stop, string(e.NewText)})
+	}
+	return converted, true
+}
@@ -2,28 +2,169 @@ package analyzer
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 // FormatCodeInput represents the input for code formatting
 type FormatCodeInput struct {
-	Code string `json:"code" jsonschema:"Go source code to format"`
+	Code       string `json:"code,omitempty" jsonschema:"Go source code to format"`
+	FilePath   string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	TabWidth   int    `json:"tabWidth,omitempty" jsonschema:"Optional display width: expand leading tabs to this many spaces (default: 0, keep tabs)"`
+	Simplify   bool   `json:"simplify,omitempty" jsonschema:"Apply gofmt -s simplifications (requires gofmt on PATH)"`
+	ReturnDiff bool   `json:"returnDiff,omitempty" jsonschema:"Also return a unified diff from the input to the formatted code"`
+	FileName   string `json:"fileName,omitempty" jsonschema:"Optional filename used in diff headers (default: temp.go)"`
+	BestEffort bool   `json:"bestEffort,omitempty" jsonschema:"When the code does not parse, format the declarations before the first syntax error and leave the rest unchanged"`
+	Formatter  string `json:"formatter,omitempty" jsonschema:"Formatter to run: gofmt, goimports to also organize imports, or gofumpt for stricter formatting; gofmt is used when the binary is missing (default: gofmt)"`
 }
 
 // FormatCodeOutput represents the result of code formatting
 type FormatCodeOutput struct {
-	Success        bool   `json:"success"`
-	FormattedCode  string `json:"formatted_code,omitempty"`
-	Error          string `json:"error,omitempty"`
+	Success         bool         `json:"success"`
+	FormattedCode   string       `json:"formatted_code,omitempty"`
+	CanonicalCode   string       `json:"canonical_code,omitempty"`   // Tab-indented gofmt output, set when TabWidth expands tabs
+	Changed         bool         `json:"changed"`                    // Whether formatting changed the input
+	Diff            string       `json:"diff,omitempty"`             // Unified diff from the input, when ReturnDiff is set and the code changed
+	Formatter       string       `json:"formatter,omitempty"`        // The formatter that ran: gofmt, goimports or gofumpt
+	UsedGoimports   bool         `json:"used_goimports,omitempty"`   // Whether goimports organized the imports
+	Partial         bool         `json:"partial,omitempty"`          // Only the code before UnformattedFrom was formatted, in best-effort mode
+	UnformattedFrom int          `json:"unformatted_from,omitempty"` // First input line of the region left unchanged
+	Note            string       `json:"note,omitempty"`             // Explains which region was left unformatted, or why gofmt ran instead of the requested formatter
+	BOM             bool         `json:"bom,omitempty"`              // The input started with a UTF-8 byte order mark, kept in the output
+	CRLF            bool         `json:"crlf,omitempty"`             // The input had CRLF line endings, kept in the output
+	Error           string       `json:"error,omitempty"`
+	SyntaxError     *SyntaxError `json:"syntax_error,omitempty"` // First syntax error, when the code does not parse
+	Diagnostics     []Diagnostic `json:"diagnostics,omitempty"`  // Syntax errors when formatting fails
 }
 
-// FormatCode formats Go code using gofmt
-func FormatCode(code string) (*FormatCodeOutput, error) {
-	// Try using go/format package first (faster, no subprocess)
-	formatted, err := format.Source([]byte(code))
+// SyntaxError is the position and message of a syntax error
+type SyntaxError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// FormatWithImportsInput represents the input for formatting with import organization
+type FormatWithImportsInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to format"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// Formatters accepted in FormatCodeInput.Formatter
+const (
+	FormatterGofmt     = "gofmt"
+	FormatterGoimports = "goimports"
+	FormatterGofumpt   = "gofumpt"
+)
+
+// Formatters returns the formatters accepted in FormatCodeInput.Formatter
+func Formatters() []string {
+	return []string{FormatterGofmt, FormatterGoimports, FormatterGofumpt}
+}
+
+// GoimportsPath is the goimports binary used by the goimports formatter. It
+// may be a bare command name resolved on PATH or a path to the binary.
+var GoimportsPath = "goimports"
+
+// GofumptPath is the gofumpt binary used by the gofumpt formatter, resolved
+// like GoimportsPath
+var GofumptPath = "gofumpt"
+
+// FormatCode formats Go code using gofmt. When input.Simplify is set the code is
+// run through gofmt -s, since go/format cannot simplify. input.Formatter may
+// select goimports or gofumpt instead, which then run on the gofmt output; when
+// the binary is missing the gofmt output is returned with a Note, and Formatter
+// records what ran. When input.TabWidth is
+// positive the returned FormattedCode has its leading tabs expanded for
+// display, and CanonicalCode holds the unmodified gofmt output. Changed and
+// the optional Diff always compare the input with the tab-indented output.
+// With input.BestEffort, code that does not parse is formatted up to the last
+// complete top-level declaration before the first syntax error; the result is
+// then Partial and Diagnostics lists the syntax errors. A leading byte order
+// mark and CRLF line endings in the input are kept in the output.
+func FormatCode(input FormatCodeInput) (*FormatCodeOutput, error) {
+	var err error
+	input.Code, input.FileName, err = loadInputFile(input.Code, input.FileName, input.FilePath)
 	if err == nil {
+		err = checkSource(input.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if input.TabWidth < 0 {
+		return &FormatCodeOutput{
+			Success: false,
+			Error:   "tabWidth must not be negative",
+		}, nil
+	}
+
+	base := formatSource
+	if input.Simplify {
+		base = simplifySource
+	}
+	formatter, ran, note, err := selectFormatter(input.Formatter, base)
+	if err != nil {
+		return &FormatCodeOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	code, info := normalizeSource(input.Code)
+	result, err := formatter(code)
+	if err == nil && !result.Success && input.BestEffort && len(result.Diagnostics) > 0 {
+		// goimports would drop imports used only in the unparsed rest, so
+		// the prefix is formatted with gofmt alone
+		result, err = formatPrefix(code, result, base)
+		if err == nil && result.Partial && ran != FormatterGofmt {
+			result.Note += ", with gofmt instead of " + ran
+			ran, note = FormatterGofmt, ""
+		}
+	}
+	if err != nil || !result.Success {
+		return result, err
+	}
+	result.Formatter = ran
+	result.UsedGoimports = ran == FormatterGoimports
+	if note != "" {
+		result.Note = note
+	}
+
+	result.FormattedCode = info.restore(result.FormattedCode)
+	result.BOM, result.CRLF = info.bom, info.crlf
+	result.Changed = result.FormattedCode != input.Code
+	if input.ReturnDiff && result.Changed {
+		fileName := input.FileName
+		if fileName == "" {
+			fileName = "temp.go"
+		}
+		result.Diff = unifiedDiff(fileName, input.Code, result.FormattedCode)
+	}
+
+	if input.TabWidth == 0 {
+		return result, nil
+	}
+
+	result.CanonicalCode = result.FormattedCode
+	result.FormattedCode = expandLeadingTabs(result.FormattedCode, input.TabWidth)
+	return result, nil
+}
+
+// formatSource formats code with go/format, falling back to the gofmt binary
+func formatSource(code string) (*FormatCodeOutput, error) {
+	// Try using go/format package first (faster, no subprocess)
+	formatted, srcErr := format.Source([]byte(code))
+	if srcErr == nil {
 		return &FormatCodeOutput{
 			Success:       true,
 			FormattedCode: string(formatted),
@@ -31,43 +172,338 @@ func FormatCode(code string) (*FormatCodeOutput, error) {
 	}
 
 	// Fall back to gofmt command if go/format fails
-	cmd := exec.Command("gofmt")
-	cmd.Stdin = bytes.NewReader([]byte(code))
-	
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	diagnostics := parseErrorsToDiagnostics(srcErr)
+	gofmt, err := exec.LookPath("gofmt")
+	if err != nil {
+		return &FormatCodeOutput{
+			Success:     false,
+			Error:       fmt.Sprintf("%v (gofmt, the fallback formatter, is not installed or not on PATH)", srcErr),
+			SyntaxError: firstSyntaxError(diagnostics),
+			Diagnostics: diagnostics,
+		}, nil
+	}
 
-	if err := cmd.Run(); err != nil {
+	stdout, stderr, err := runFormatter(gofmt, code)
+	if err != nil {
 		return &FormatCodeOutput{
-			Success: false,
-			Error:   fmt.Sprintf("gofmt error: %v - %s", err, stderr.String()),
+			Success:     false,
+			Error:       fmt.Sprintf("gofmt error: %v - %s", err, stderr),
+			SyntaxError: firstSyntaxError(diagnostics),
+			Diagnostics: diagnostics,
 		}, nil
 	}
 
 	return &FormatCodeOutput{
 		Success:       true,
-		FormattedCode: stdout.String(),
+		FormattedCode: stdout,
 	}, nil
 }
 
-// FormatCodeWithImports formats code and organizes imports using goimports if available
-func FormatCodeWithImports(code string) (*FormatCodeOutput, error) {
-	// Try goimports if available
-	cmd := exec.Command("goimports")
-	cmd.Stdin = bytes.NewReader([]byte(code))
-	
+// FormatterTimeout bounds each run of a formatter binary, so that a formatter
+// that stops reading its input or hangs fails the request instead of
+// blocking it
+var FormatterTimeout = 30 * time.Second
+
+// runFormatter runs binary with args on input and returns what it wrote to
+// stdout and stderr. The input is written from a goroutine, which closes
+// stdin when done, so a formatter that fails before reading all of a large
+// input cannot leave the write blocked. The binary is killed once
+// FormatterTimeout has passed.
+func runFormatter(binary, input string, args ...string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), FormatterTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binary, args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	// Output pipes held open by a child of the formatter must not keep Wait
+	// from returning after it was killed
+	cmd.WaitDelay = time.Second
 
-	if err := cmd.Run(); err != nil {
-		// Fall back to regular format if goimports not available
-		return FormatCode(code)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return "", "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", "", err
+	}
+	written := make(chan error, 1)
+	go func() {
+		_, err := io.WriteString(stdin, input)
+		if closeErr := stdin.Close(); err == nil {
+			err = closeErr
+		}
+		written <- err
+	}()
+
+	// Wait closes stdin once the formatter exits, which ends a write it
+	// left blocked
+	err = cmd.Wait()
+	writeErr := <-written
+	switch {
+	case ctx.Err() != nil:
+		err = fmt.Errorf("%s did not finish within %v", filepath.Base(binary), FormatterTimeout)
+	case err == nil && writeErr != nil:
+		err = fmt.Errorf("writing input to %s: %w", filepath.Base(binary), writeErr)
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// formatPrefix formats the longest run of complete top-level declarations
+// that ends before the first syntax error and appends the rest of code as is.
+// failed is the result of formatting all of code, and is returned unchanged
+// when not even the package clause can be formatted.
+func formatPrefix(code string, failed *FormatCodeOutput, formatter func(string) (*FormatCodeOutput, error)) (*FormatCodeOutput, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, parser.ParseComments)
+	var errList scanner.ErrorList
+	if !errors.As(err, &errList) || len(errList) == 0 || file == nil || file.Name == nil {
+		return failed, nil
+	}
+	errList.Sort()
+	errOffset := errList[0].Pos.Offset
+	errLine := errList[0].Pos.Line
+
+	// Candidate cut points: the end of the package clause and of each
+	// declaration that precedes the error, extended to the end of its line
+	cutAfter := func(end token.Pos) int {
+		offset := fset.Position(end).Offset
+		if nl := strings.IndexByte(code[offset:], '\n'); nl >= 0 {
+			return offset + nl + 1
+		}
+		return len(code)
+	}
+	cuts := []int{cutAfter(file.Name.End())}
+	for _, decl := range file.Decls {
+		// Declarations cut short by the error may end past the end of the file
+		end := fset.Position(decl.End())
+		if _, bad := decl.(*ast.BadDecl); bad || !end.IsValid() || end.Offset > errOffset {
+			break
+		}
+		cuts = append(cuts, cutAfter(decl.End()))
+	}
+
+	for i := len(cuts) - 1; i >= 0; i-- {
+		cut := cuts[i]
+		if cut > errOffset {
+			continue
+		}
+		prefix, err := formatter(code[:cut])
+		if err != nil {
+			return nil, err
+		}
+		if !prefix.Success {
+			continue
+		}
+
+		from := strings.Count(code[:cut], "\n") + 1
+		return &FormatCodeOutput{
+			Success:         true,
+			FormattedCode:   prefix.FormattedCode + code[cut:],
+			Partial:         true,
+			UnformattedFrom: from,
+			Note: fmt.Sprintf("formatted lines 1-%d; lines %d onwards were left unchanged because of a syntax error at line %d",
+				from-1, from, errLine),
+			SyntaxError: failed.SyntaxError,
+			Diagnostics: failed.Diagnostics,
+		}, nil
+	}
+	return failed, nil
+}
+
+// selectFormatter returns the formatter named by name, which runs base and
+// then the named binary on its output, along with the name of the formatter
+// that will run. When the binary is missing, base is returned instead, with a
+// note saying so.
+func selectFormatter(name string, base func(string) (*FormatCodeOutput, error)) (func(string) (*FormatCodeOutput, error), string, string, error) {
+	var path string
+	switch name {
+	case "", FormatterGofmt:
+		return base, FormatterGofmt, "", nil
+	case FormatterGoimports:
+		path = GoimportsPath
+	case FormatterGofumpt:
+		path = GofumptPath
+	default:
+		return nil, "", "", fmt.Errorf("unknown formatter %q (valid formatters: %s)", name, strings.Join(Formatters(), ", "))
+	}
+
+	binary, err := exec.LookPath(path)
+	if err != nil {
+		return base, FormatterGofmt, name + " is not installed; formatted with gofmt", nil
+	}
+	return func(code string) (*FormatCodeOutput, error) {
+		// Running base first reports syntax errors with their positions
+		result, err := base(code)
+		if err != nil || !result.Success {
+			return result, err
+		}
+
+		stdout, stderr, err := runFormatter(binary, result.FormattedCode)
+		if err != nil {
+			return &FormatCodeOutput{
+				Success: false,
+				Error:   fmt.Sprintf("%s error: %v - %s", name, err, stderr),
+			}, nil
+		}
+		return &FormatCodeOutput{
+			Success:       true,
+			FormattedCode: stdout,
+		}, nil
+	}, name, "", nil
+}
+
+// firstSyntaxError returns the first of diagnostics, which hold the syntax
+// errors reported by go/format in position order, or nil when they have no
+// position
+func firstSyntaxError(diagnostics []Diagnostic) *SyntaxError {
+	if len(diagnostics) == 0 || diagnostics[0].Line == 0 {
+		return nil
+	}
+	return &SyntaxError{
+		Line:    diagnostics[0].Line,
+		Column:  diagnostics[0].Column,
+		Message: diagnostics[0].Message,
+	}
+}
+
+// simplifySource formats code with gofmt -s. Unlike formatSource there is no
+// in-process fallback, so a missing gofmt binary is reported as an error.
+func simplifySource(code string) (*FormatCodeOutput, error) {
+	gofmt, err := exec.LookPath("gofmt")
+	if err != nil {
+		return &FormatCodeOutput{
+			Success: false,
+			Error:   "simplification requested but gofmt is not installed or not on PATH",
+		}, nil
+	}
+
+	stdout, stderr, err := runFormatter(gofmt, code, "-s")
+	if err != nil {
+		// gofmt reports positions as text; go/format gives them structured
+		_, srcErr := format.Source([]byte(code))
+		diagnostics := parseErrorsToDiagnostics(srcErr)
+		return &FormatCodeOutput{
+			Success:     false,
+			Error:       fmt.Sprintf("gofmt error: %v - %s", err, stderr),
+			SyntaxError: firstSyntaxError(diagnostics),
+			Diagnostics: diagnostics,
+		}, nil
 	}
 
 	return &FormatCodeOutput{
 		Success:       true,
-		FormattedCode: stdout.String(),
+		FormattedCode: stdout,
 	}, nil
 }
+
+// FormatCodeWithImports formats code and organizes imports using goimports if
+// available, as FormatCode does with the goimports formatter. UsedGoimports
+// reports whether it ran or plain formatting was used.
+func FormatCodeWithImports(code string) (*FormatCodeOutput, error) {
+	return FormatCode(FormatCodeInput{Code: code, Formatter: FormatterGoimports})
+}
+
+// expandLeadingTabs replaces the leading tabs on each line with width spaces
+func expandLeadingTabs(code string, width int) string {
+	indent := strings.Repeat(" ", width)
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		tabs := len(line) - len(strings.TrimLeft(line, "\t"))
+		if tabs > 0 {
+			lines[i] = strings.Repeat(indent, tabs) + line[tabs:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxDivergentLines bounds the line numbers reported by CheckFormat
+const maxDivergentLines = 10
+
+// CheckFormatInput represents the input for a format check
+type CheckFormatInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to check"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	OmitDiff bool   `json:"omitDiff,omitempty" jsonschema:"Return only whether the code is formatted and where it first diverges, without the diff"`
+}
+
+// CheckFormatOutput represents the result of a format check
+type CheckFormatOutput struct {
+	Success        bool   `json:"success"`
+	Formatted      bool   `json:"formatted"`
+	Diff           string `json:"diff,omitempty"`
+	DivergentLines []int  `json:"divergent_lines,omitempty"` // Input lines where the first divergences from gofmt start
+	Error          string `json:"error,omitempty"`
+}
+
+// CheckFormat reports whether code is already gofmt-clean. When it is not,
+// DivergentLines lists the first lines where it differs from the gofmt
+// output and, unless input.OmitDiff is set, Diff holds a unified diff. As
+// format_code keeps them, a byte order mark and CRLF line endings are not
+// formatting differences.
+func CheckFormat(input CheckFormatInput) (*CheckFormatOutput, error) {
+	var err error
+	input.Code, err = LoadSource(input.Code, input.FilePath)
+	if err == nil {
+		err = checkSource(input.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	code, info := normalizeSource(input.Code)
+	source, err := format.Source([]byte(code))
+	if err != nil {
+		return &CheckFormatOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	formatted := info.restore(string(source))
+	if formatted == input.Code {
+		return &CheckFormatOutput{
+			Success:   true,
+			Formatted: true,
+		}, nil
+	}
+
+	// One edit script serves both the divergent lines and the diff
+	ops := diffLines(splitDiffLines(input.Code), splitDiffLines(formatted))
+	result := &CheckFormatOutput{
+		Success:        true,
+		Formatted:      false,
+		DivergentLines: divergentLines(ops, maxDivergentLines),
+	}
+	if !input.OmitDiff {
+		result.Diff = formatDiff("temp.go", ops)
+	}
+	return result, nil
+}
+
+// CheckFormatted reports whether code is byte-for-byte identical to its gofmt
+// output, with the byte order mark and line endings of code kept. Code that
+// cannot be parsed is reported as an error.
+func CheckFormatted(code string) (bool, error) {
+	if err := checkSource(code); err != nil {
+		return false, err
+	}
+	src, info := normalizeSource(code)
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return false, err
+	}
+	return info.restore(string(formatted)) == code, nil
+}
+
+// CheckFormattedInput represents the input for a pass/fail format check
+type CheckFormattedInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to check"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// CheckFormattedOutput represents the result of a pass/fail format check:
+// CheckFormat with its diff omitted, for gates that only need pass/fail
+type CheckFormattedOutput struct {
+	Formatted      bool  `json:"formatted"`
+	DivergentLines []int `json:"divergent_lines,omitempty"` // Input lines where the first divergences from gofmt start
+}
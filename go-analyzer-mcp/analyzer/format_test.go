@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      string
+		omitDiff  bool
+		formatted bool
+		lines     []int
+		wantDiff  bool
+	}{
+		{name: "formatted", code: "package p\n\nfunc f() {}\n", formatted: true},
+		{name: "formatted with CRLF", code: "package p\r\n\r\nfunc f() {}\r\n", formatted: true},
+		{name: "bad spacing", code: "package p\n\nfunc  f() {}\n", lines: []int{3}, wantDiff: true},
+		{name: "omit diff", code: "package p\n\nfunc  f() {}\n", omitDiff: true, lines: []int{3}},
+		{
+			name:     "two runs",
+			code:     "package p\n\nfunc f() {\nx := 1\n\t_ = x\n}\n\nfunc  g() {}\n",
+			omitDiff: true,
+			lines:    []int{4, 8},
+		},
+		{name: "missing blank line", code: "package p\nfunc f() {}\n", omitDiff: true, lines: []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CheckFormat(CheckFormatInput{Code: tt.code, OmitDiff: tt.omitDiff})
+			if err != nil {
+				t.Fatalf("CheckFormat: %v", err)
+			}
+			if !result.Success {
+				t.Fatalf("CheckFormat failed: %s", result.Error)
+			}
+			if result.Formatted != tt.formatted {
+				t.Errorf("Formatted = %v, want %v", result.Formatted, tt.formatted)
+			}
+			if !slices.Equal(result.DivergentLines, tt.lines) {
+				t.Errorf("DivergentLines = %v, want %v", result.DivergentLines, tt.lines)
+			}
+			if got := result.Diff != ""; got != tt.wantDiff {
+				t.Errorf("diff present = %v, want %v", got, tt.wantDiff)
+			}
+
+			formatted, err := CheckFormatted(tt.code)
+			if err != nil {
+				t.Fatalf("CheckFormatted: %v", err)
+			}
+			if formatted != tt.formatted {
+				t.Errorf("CheckFormatted = %v, want %v", formatted, tt.formatted)
+			}
+		})
+	}
+}
+
+func TestCheckFormatSyntaxError(t *testing.T) {
+	result, err := CheckFormat(CheckFormatInput{Code: "package p\nfunc {"})
+	if err != nil {
+		t.Fatalf("CheckFormat: %v", err)
+	}
+	if result.Success {
+		t.Error("CheckFormat succeeded on code that does not parse")
+	}
+	if _, err := CheckFormatted("package p\nfunc {"); err == nil {
+		t.Error("CheckFormatted returned no error for code that does not parse")
+	}
+}
+
+func TestFormatCodeSyntaxError(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   FormatCodeInput
+		line    int
+		column  int
+		message string
+	}{
+		{
+			name:    "missing brace",
+			input:   FormatCodeInput{Code: "package p\n\nfunc f() {\n\tx := 1\n"},
+			line:    4,
+			column:  9,
+			message: "expected '}', found 'EOF'",
+		},
+		{
+			name:    "bad declaration",
+			input:   FormatCodeInput{Code: "package p\n\nfunc {\n}\n"},
+			line:    3,
+			column:  6,
+			message: "expected 'IDENT'",
+		},
+		{
+			name:    "first of several errors",
+			input:   FormatCodeInput{Code: "package p\n\nvar = 1\n\nvar = 2\n"},
+			line:    3,
+			column:  5,
+			message: "expected 'IDENT'",
+		},
+		{
+			name:    "with gofmt -s",
+			input:   FormatCodeInput{Code: "package p\n\nfunc {\n}\n", Simplify: true},
+			line:    3,
+			column:  6,
+			message: "expected 'IDENT'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.input.Simplify {
+				if _, err := exec.LookPath("gofmt"); err != nil {
+					t.Skip("gofmt not on PATH")
+				}
+			}
+			result, err := FormatCode(tt.input)
+			if err != nil {
+				t.Fatalf("FormatCode: %v", err)
+			}
+			if result.Success {
+				t.Fatal("FormatCode succeeded on code that does not parse")
+			}
+			syntaxErr := result.SyntaxError
+			if syntaxErr == nil {
+				t.Fatal("no syntax error reported")
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column || !strings.Contains(syntaxErr.Message, tt.message) {
+				t.Errorf("SyntaxError = %+v, want %d:%d %q", syntaxErr, tt.line, tt.column, tt.message)
+			}
+			if len(result.Diagnostics) == 0 || result.Diagnostics[0].Line != tt.line || result.Diagnostics[0].Column != tt.column {
+				t.Errorf("Diagnostics = %+v, want the syntax error first", result.Diagnostics)
+			}
+		})
+	}
+}
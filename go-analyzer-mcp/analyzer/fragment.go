@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// Parse modes accepted by the tools that take code fragments
+const (
+	ModeFile     = "file"     // A complete file, the default
+	ModeFragment = "fragment" // Declarations or statements, completed by WrapFragment
+	ModeExpr     = "expr"     // A single expression
+)
+
+// Modes returns the accepted parse modes
+func Modes() []string {
+	return []string{ModeFile, ModeFragment, ModeExpr}
+}
+
+// Kinds of fragment that code is wrapped as
+const (
+	FragmentDeclarations = "declarations" // Declarations without a package clause
+	FragmentStatements   = "statements"   // Statements, such as a function body
+	FragmentExpression   = "expression"   // An expression, in mode expr
+)
+
+// Wrappers written before a fragment. The /*line*/ directive gives the
+// first character of the fragment its own position, so positions reported
+// by the parser and in results are those in the fragment.
+const (
+	declarationsWrapper = "package main; /*line temp.go:1:1*/"
+	statementsWrapper   = "package main; func _() { /*line temp.go:1:1*/"
+	expressionWrapper   = "package main; func _() { _ = /*line temp.go:1:1*/"
+)
+
+// WrapFragment completes code that is not a Go file but a fragment of one,
+// such as a snippet pasted without its package clause or a function body.
+// Declarations are given a synthetic package main clause, and statements are
+// also wrapped in a function named _, closed on the line after them.
+// The wrapper is written on the first line of the fragment, so line numbers
+// are unchanged. It returns the completed code and the kind of fragment, or
+// code and "" when code has a package clause. When code parses as neither
+// kind, the wrapping under which the parser gets further is returned, so the
+// syntax errors reported are those of the fragment.
+func WrapFragment(code string) (string, string) {
+	if hasPackageClause(code) {
+		return code, ""
+	}
+
+	declarations := declarationsWrapper + code
+	declErr := fragmentParseError(declarations)
+	if declErr == nil {
+		return declarations, FragmentDeclarations
+	}
+	statements := closeWrapper(statementsWrapper, code)
+	stmtErr := fragmentParseError(statements)
+	if stmtErr == nil || stmtErr.Pos.Line > declErr.Pos.Line ||
+		stmtErr.Pos.Line == declErr.Pos.Line && stmtErr.Pos.Column > declErr.Pos.Column {
+		return statements, FragmentStatements
+	}
+	return declarations, FragmentDeclarations
+}
+
+// wrapSource prepares code for parsing in mode, returning the code to parse
+// and the kind of fragment it was wrapped as, or "" for a file. An
+// expression is wrapped in a function named _, as the operand of an
+// assignment to the blank identifier. Only code, not files, can be parsed in
+// a mode other than file.
+func wrapSource(code string, files map[string]string, mode string) (string, string, error) {
+	if mode != "" && mode != ModeFile && len(files) > 0 {
+		return "", "", fmt.Errorf("mode %s applies to code only, not to files", mode)
+	}
+	switch mode {
+	case "", ModeFile:
+		return code, "", nil
+	case ModeFragment:
+		wrapped, fragment := WrapFragment(code)
+		return wrapped, fragment, nil
+	case ModeExpr:
+		return closeWrapper(expressionWrapper, code), FragmentExpression, nil
+	}
+	return "", "", fmt.Errorf("unknown mode %q (valid modes: %s)", mode, strings.Join(Modes(), ", "))
+}
+
+// closeWrapper wraps code in a function opened by wrapper, closing it on the
+// line after the code
+func closeWrapper(wrapper, code string) string {
+	if !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+	return wrapper + code + "}"
+}
+
+// fragmentHint is added to the error of code parsed as a file without a
+// package clause, which is most likely a snippet
+const fragmentHint = "; the code has no package clause, so to analyze a snippet set mode to fragment, or to expr for an expression"
+
+// parseFailure returns the error message and diagnostics for code that failed
+// to parse with err; code is empty when files were parsed. When code was
+// parsed as a file and has no package clause, the parser stops at the start
+// of the code, and the error suggests a fragment mode instead.
+func parseFailure(code, fragment string, err error) (string, []Diagnostic) {
+	message, diagnostics := err.Error(), parseErrorsToDiagnostics(err)
+	if fragment == "" && code != "" && len(diagnostics) == 1 && diagnostics[0].Line > 0 && !hasPackageClause(code) {
+		message += fragmentHint
+		diagnostics[0].Message += fragmentHint
+	}
+	return message, diagnostics
+}
+
+// hasPackageClause reports whether code starts with a package clause
+func hasPackageClause(code string) bool {
+	_, err := parser.ParseFile(token.NewFileSet(), "temp.go", code, parser.PackageClauseOnly)
+	return err == nil
+}
+
+// fragmentParseError parses wrapped code and returns its first syntax error,
+// or nil when it parses
+func fragmentParseError(code string) *scanner.Error {
+	_, err := parser.ParseFile(token.NewFileSet(), "temp.go", code, 0)
+	var list scanner.ErrorList
+	if err == nil || !errors.As(err, &list) || len(list) == 0 {
+		return nil
+	}
+	list.Sort()
+	return list[0]
+}
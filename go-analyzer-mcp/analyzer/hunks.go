@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+)
+
+// LineRange is an inclusive range of 1-based line numbers, such as the new
+// side of a diff hunk
+type LineRange struct {
+	Start int `json:"start" jsonschema:"First line of the range (1-based)"`
+	End   int `json:"end,omitempty" jsonschema:"Last line of the range, inclusive (default: start)"`
+}
+
+// MetricsForHunksInput represents the input for hunk-scoped metrics
+type MetricsForHunksInput struct {
+	Code     string      `json:"code,omitempty" jsonschema:"Go source code after the change"`
+	FilePath string      `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Hunks    []LineRange `json:"hunks" jsonschema:"Changed line ranges in the new version of the file"`
+}
+
+// HunkMetricsOutput represents the functions touched by each hunk
+type HunkMetricsOutput struct {
+	Success     bool          `json:"success"`
+	Hunks       []HunkMetrics `json:"hunks"`
+	Error       string        `json:"error,omitempty"`
+	Diagnostics []Diagnostic  `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// HunkMetrics represents the functions one hunk touches
+type HunkMetrics struct {
+	Range         LineRange         `json:"range"`
+	Functions     []FunctionMetrics `json:"functions"`      // Functions overlapping the hunk, in source order
+	MaxComplexity int               `json:"max_complexity"` // Highest cyclomatic complexity among Functions
+}
+
+// MetricsForHunks reports, for each hunk, the functions whose lines from the
+// func keyword to the closing brace overlap it, with their metrics. Hunks that
+// touch only doc comments or code outside functions report no functions.
+func MetricsForHunks(code string, hunks []LineRange) (*HunkMetricsOutput, error) {
+	code, _ = normalizeSource(code)
+	hunks = slices.Clone(hunks)
+	for i, hunk := range hunks {
+		if hunk.End == 0 {
+			hunks[i].End = hunk.Start
+		}
+		if hunk.Start < 1 || hunks[i].End < hunk.Start {
+			return &HunkMetricsOutput{
+				Success: false,
+				Error:   fmt.Sprintf("invalid hunk %d-%d: lines start at 1 and end must not precede start", hunk.Start, hunk.End),
+			}, nil
+		}
+	}
+
+	metrics, err := CalculateMetrics(CalculateMetricsInput{Code: code})
+	if err != nil {
+		return nil, err
+	}
+	if !metrics.Success {
+		return &HunkMetricsOutput{
+			Success:     false,
+			Error:       metrics.Error,
+			Diagnostics: metrics.Diagnostics,
+		}, nil
+	}
+
+	result := &HunkMetricsOutput{
+		Success: true,
+		Hunks:   []HunkMetrics{},
+	}
+	for _, hunk := range hunks {
+		touched := HunkMetrics{
+			Range:     hunk,
+			Functions: []FunctionMetrics{},
+		}
+		for _, fm := range metrics.FunctionMetrics {
+			if fm.Line <= hunk.End && hunk.Start <= fm.EndLine {
+				touched.Functions = append(touched.Functions, fm)
+				touched.MaxComplexity = max(touched.MaxComplexity, fm.CyclomaticComplexity)
+			}
+		}
+		result.Hunks = append(result.Hunks, touched)
+	}
+	return result, nil
+}
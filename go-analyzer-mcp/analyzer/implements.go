@@ -0,0 +1,205 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CheckImplementsInput represents the input for an interface implementation check
+type CheckImplementsInput struct {
+	Code          string `json:"code,omitempty" jsonschema:"Go source code declaring the type"`
+	FilePath      string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	TypeName      string `json:"typeName" jsonschema:"Name of the type declared in the code, optionally with a leading * for its pointer type"`
+	InterfaceName string `json:"interfaceName" jsonschema:"Name of the interface: declared in the code, qualified by an imported standard library package such as io.Reader, or error"`
+}
+
+// ImplementsOutput represents the result of an interface implementation check
+type ImplementsOutput struct {
+	Success           bool            `json:"success"`
+	TypeName          string          `json:"type_name"`
+	InterfaceName     string          `json:"interface_name"`
+	Implements        bool            `json:"implements"`         // Whether the type, as named in TypeName, implements the interface
+	PointerImplements bool            `json:"pointer_implements"` // Whether a pointer to the type implements the interface
+	Missing           []MissingMethod `json:"missing"`            // Why the type does not implement the interface
+	UnresolvedImports []string        `json:"unresolved_imports,omitempty"`
+	Error             string          `json:"error,omitempty"`
+	Diagnostics       []Diagnostic    `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// MissingMethod represents an interface method that a type lacks or declares
+// differently
+type MissingMethod struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`           // "missing", "wrong_signature" or "pointer_receiver"
+	Want    string `json:"want"`           // Signature required by the interface
+	Have    string `json:"have,omitempty"` // Signature declared by the type, for wrong_signature and pointer_receiver
+	Message string `json:"message"`
+}
+
+// CheckImplements reports whether the type typeName declared in code
+// implements the interface interfaceName, which is declared in code, in an
+// imported standard library package (e.g. "io.Reader"), or is the predeclared
+// error. Types are resolved with go/types, so embedded fields and interfaces
+// are taken into account. When the type does not implement the interface,
+// Missing lists each interface method that it does not have, that has a
+// different signature, or that has a pointer receiver, in which case only a
+// pointer to the type implements the interface. A typeName of the form "*T"
+// checks the pointer type.
+func CheckImplements(code, typeName, interfaceName string) (*ImplementsOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &ImplementsOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	imp := &stdImporter{}
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	pkg, _ := conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+
+	result := &ImplementsOutput{
+		TypeName:          typeName,
+		InterfaceName:     interfaceName,
+		Missing:           []MissingMethod{},
+		UnresolvedImports: imp.stubbed,
+	}
+	fail := func(format string, args ...any) (*ImplementsOutput, error) {
+		result.Error = fmt.Sprintf(format, args...)
+		return result, nil
+	}
+
+	baseName, pointer := strings.CutPrefix(strings.TrimSpace(typeName), "*")
+	typeObj, err := lookupTypeName(pkg, file, baseName, imp.stubbed)
+	if err != nil {
+		return fail("%v", err)
+	}
+	ifaceObj, err := lookupTypeName(pkg, file, strings.TrimSpace(interfaceName), imp.stubbed)
+	if err != nil {
+		return fail("%v", err)
+	}
+	for _, obj := range []*types.TypeName{typeObj, ifaceObj} {
+		if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+			return fail("%s is generic; generic types cannot be checked without type arguments", obj.Name())
+		}
+	}
+	iface, ok := ifaceObj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return fail("%s is not an interface", interfaceName)
+	}
+	if !iface.IsMethodSet() {
+		return fail("%s has type constraints and can only be used as a constraint", interfaceName)
+	}
+
+	typ := typeObj.Type()
+	_, isInterface := typ.Underlying().(*types.Interface)
+	if pointer {
+		if isInterface {
+			return fail("%s is an interface; a pointer to an interface has no methods", baseName)
+		}
+		typ = types.NewPointer(typ)
+	}
+	result.Success = true
+	result.Implements = types.Implements(typ, iface)
+	if !isInterface {
+		result.PointerImplements = types.Implements(types.NewPointer(typeObj.Type()), iface)
+	}
+	if result.Implements {
+		return result, nil
+	}
+
+	qualifier := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+	signature := func(fn *types.Func) string {
+		return fn.Name() + strings.TrimPrefix(types.TypeString(fn.Type(), qualifier), "func")
+	}
+
+	// The pointer method set also holds the methods with pointer receivers,
+	// to tell them apart from methods that are missing altogether
+	methods := types.NewMethodSet(typ)
+	allMethods := methods
+	if !isInterface && !pointer {
+		allMethods = types.NewMethodSet(types.NewPointer(typ))
+	}
+	for i := range iface.NumMethods() {
+		want := iface.Method(i)
+		missing := MissingMethod{
+			Name: want.Name(),
+			Kind: "missing",
+			Want: signature(want),
+		}
+		sel := allMethods.Lookup(want.Pkg(), want.Name())
+		switch {
+		case sel == nil:
+			missing.Message = fmt.Sprintf("method %s is missing", missing.Want)
+		case !types.Identical(sel.Obj().Type(), want.Type()):
+			missing.Kind = "wrong_signature"
+			missing.Have = signature(sel.Obj().(*types.Func))
+			missing.Message = fmt.Sprintf("method %s has signature %s, want %s", want.Name(), missing.Have, missing.Want)
+		case methods.Lookup(want.Pkg(), want.Name()) == nil:
+			missing.Kind = "pointer_receiver"
+			missing.Have = signature(sel.Obj().(*types.Func))
+			missing.Message = fmt.Sprintf("method %s has a pointer receiver, so only *%s has it", want.Name(), baseName)
+		default:
+			continue
+		}
+		result.Missing = append(result.Missing, missing)
+	}
+	return result, nil
+}
+
+// lookupTypeName resolves a type name in the package scope of the checked
+// file, in the universe (for error), or, for a qualified name such as
+// io.Reader, in the package imported under that name
+func lookupTypeName(pkg *types.Package, file *ast.File, name string, stubbed []string) (*types.TypeName, error) {
+	if name == "" {
+		return nil, fmt.Errorf("type name must not be empty")
+	}
+
+	var obj types.Object
+	if pkgName, typeName, qualified := strings.Cut(name, "."); qualified {
+		path := ""
+		for _, spec := range file.Imports {
+			specPath, err := strconv.Unquote(spec.Path.Value)
+			if err == nil && importName(file, specPath) == pkgName {
+				path = specPath
+				break
+			}
+		}
+		if path == "" {
+			return nil, fmt.Errorf("package %s is not imported by the code", pkgName)
+		}
+		if slices.Contains(stubbed, path) {
+			return nil, fmt.Errorf("package %s could not be loaded; only standard library packages are available", path)
+		}
+		for _, imported := range pkg.Imports() {
+			if imported.Path() == path {
+				obj = imported.Scope().Lookup(typeName)
+			}
+		}
+	} else {
+		obj = pkg.Scope().Lookup(name)
+		if obj == nil {
+			obj = types.Universe.Lookup(name)
+		}
+	}
+
+	if obj == nil {
+		return nil, fmt.Errorf("type %s is not declared", name)
+	}
+	typeName, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a type", name)
+	}
+	return typeName, nil
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"sort"
+	"strconv"
+)
+
+// ImportGraphInput represents the input for building an import graph
+type ImportGraphInput struct {
+	Files map[string]string `json:"files" jsonschema:"Files of one package keyed by file name"`
+}
+
+// ImportGraphOutput represents the import graph of a set of files
+type ImportGraphOutput struct {
+	Success         bool             `json:"success"`
+	PackageName     string           `json:"package_name"`
+	Files           []FileImports    `json:"files"`    // Files in name order
+	Packages        []PackageImports `json:"packages"` // Imported packages, most imported first
+	FileCount       int              `json:"file_count"`
+	PackageCount    int              `json:"package_count"`
+	SingleFileCount int              `json:"single_file_count"`
+	PervasiveCount  int              `json:"pervasive_count"`
+	Error           string           `json:"error,omitempty"`
+	Diagnostics     []Diagnostic     `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// FileImports lists the packages imported by one file
+type FileImports struct {
+	File    string   `json:"file"`
+	Imports []string `json:"imports"` // Import paths in source order
+}
+
+// PackageImports represents one package imported across the files
+type PackageImports struct {
+	Path  string   `json:"path"`
+	Kind  string   `json:"kind"`  // "stdlib", "third_party", or "module" for relative paths
+	Count int      `json:"count"` // Number of files importing the package
+	Files []string `json:"files"`
+	Usage string   `json:"usage"` // "single", "shared" or "pervasive"
+}
+
+// BuildImportGraph lists the packages imported by each of files, which must
+// belong to one package, and how many files import each package. A package
+// imported by a single file has usage "single"; one imported by at least half
+// of the files, and by more than one, is "pervasive"; others are "shared".
+// Single-file imports mark code that could move to another package with
+// little coupling, while pervasive ones tie the whole package together.
+func BuildImportGraph(files map[string]string) (*ImportGraphOutput, error) {
+	if err := checkSources(files); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return &ImportGraphOutput{
+			Success: false,
+			Error:   "files must contain at least one file",
+		}, nil
+	}
+
+	sources, _, err := parseSources("", files, false)
+	if err != nil {
+		return &ImportGraphOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	result := &ImportGraphOutput{
+		Success:     true,
+		PackageName: sourcePackage(sources[0]),
+		Files:       make([]FileImports, 0, len(sources)),
+		Packages:    []PackageImports{},
+		FileCount:   len(sources),
+	}
+	byPath := make(map[string]*PackageImports)
+	for _, src := range sources {
+		fileImports := FileImports{File: src.Name, Imports: []string{}}
+		for _, imp := range src.AST.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			fileImports.Imports = append(fileImports.Imports, path)
+
+			pkg := byPath[path]
+			if pkg == nil {
+				pkg = &PackageImports{Path: path, Kind: classifyImport(path, "")}
+				byPath[path] = pkg
+			}
+			// A package imported twice by one file, e.g. under two names,
+			// counts once for it
+			if len(pkg.Files) == 0 || pkg.Files[len(pkg.Files)-1] != src.Name {
+				pkg.Files = append(pkg.Files, src.Name)
+			}
+		}
+		result.Files = append(result.Files, fileImports)
+	}
+
+	for _, pkg := range byPath {
+		pkg.Count = len(pkg.Files)
+		switch {
+		case pkg.Count == 1:
+			pkg.Usage = "single"
+			result.SingleFileCount++
+		case pkg.Count*2 >= result.FileCount:
+			pkg.Usage = "pervasive"
+			result.PervasiveCount++
+		default:
+			pkg.Usage = "shared"
+		}
+		result.Packages = append(result.Packages, *pkg)
+	}
+	sort.Slice(result.Packages, func(i, j int) bool {
+		a, b := result.Packages[i], result.Packages[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Path < b.Path
+	})
+	result.PackageCount = len(result.Packages)
+
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GetImportsInput represents the input for import extraction
+type GetImportsInput struct {
+	Code       string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath   string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	ModulePath string `json:"modulePath,omitempty" jsonschema:"Optional module path; imports under it are classified as intra-module"`
+}
+
+// ImportsOutput represents the result of import extraction
+type ImportsOutput struct {
+	Success         bool         `json:"success"`
+	PackageName     string       `json:"package_name"`
+	Imports         []ImportInfo `json:"imports"`
+	StdlibCount     int          `json:"stdlib_count"`
+	ThirdPartyCount int          `json:"third_party_count"`
+	ModuleCount     int          `json:"module_count"`
+	Error           string       `json:"error,omitempty"`
+	Diagnostics     []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// ImportInfo represents a single import declaration
+type ImportInfo struct {
+	Path  string `json:"path"`
+	Alias string `json:"alias,omitempty"`
+	Kind  string `json:"kind"` // "stdlib", "third_party", or "module"
+	Dot   bool   `json:"dot,omitempty"`
+	Blank bool   `json:"blank,omitempty"`
+	Line  int    `json:"line"`
+}
+
+// GetImports reports the package name of a file and lists its imports,
+// classified as standard library, third-party, or intra-module
+func GetImports(code, modulePath string) (*ImportsOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &ImportsOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	result := &ImportsOutput{
+		Success:     true,
+		PackageName: file.Name.Name,
+		Imports:     []ImportInfo{},
+	}
+
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		info := ImportInfo{
+			Path: path,
+			Kind: classifyImport(path, modulePath),
+			Line: fset.Position(imp.Pos()).Line,
+		}
+		if imp.Name != nil {
+			info.Alias = imp.Name.Name
+			info.Dot = imp.Name.Name == "."
+			info.Blank = imp.Name.Name == "_"
+		}
+
+		switch info.Kind {
+		case "stdlib":
+			result.StdlibCount++
+		case "third_party":
+			result.ThirdPartyCount++
+		case "module":
+			result.ModuleCount++
+		}
+		result.Imports = append(result.Imports, info)
+	}
+
+	return result, nil
+}
+
+// GetPackageInfoInput represents the input for get_package_info
+type GetPackageInfoInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// PackageInfoOutput represents the package clause and imports of a file
+type PackageInfoOutput struct {
+	Success     bool            `json:"success"`
+	PackageName string          `json:"package_name"`
+	Imports     []PackageImport `json:"imports"`
+	Error       string          `json:"error,omitempty"`
+	Diagnostics []Diagnostic    `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// PackageImport represents a single import declaration as GetPackageInfo
+// reports it
+type PackageImport struct {
+	Path  string `json:"path"`
+	Alias string `json:"alias,omitempty"` // "." for a dot import, "_" for a blank import, otherwise the local name given
+	Line  int    `json:"line"`
+}
+
+// GetPackageInfo reports the package name of a file and its imports, without
+// the classification GetImports adds
+func GetPackageInfo(code string) (*PackageInfoOutput, error) {
+	imports, err := GetImports(code, "")
+	if err != nil {
+		return nil, err
+	}
+	if !imports.Success {
+		return &PackageInfoOutput{
+			Success:     false,
+			Error:       imports.Error,
+			Diagnostics: imports.Diagnostics,
+		}, nil
+	}
+
+	result := &PackageInfoOutput{
+		Success:     true,
+		PackageName: imports.PackageName,
+		Imports:     make([]PackageImport, 0, len(imports.Imports)),
+	}
+	for _, imp := range imports.Imports {
+		result.Imports = append(result.Imports, PackageImport{
+			Path:  imp.Path,
+			Alias: imp.Alias,
+			Line:  imp.Line,
+		})
+	}
+	return result, nil
+}
+
+// classifyImport classifies an import path. Standard library paths have no dot
+// in their first path element; paths under modulePath (or relative paths) are
+// intra-module; everything else is third-party.
+func classifyImport(path, modulePath string) string {
+	if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		return "module"
+	}
+	if modulePath != "" && (path == modulePath || strings.HasPrefix(path, modulePath+"/")) {
+		return "module"
+	}
+
+	first, _, _ := strings.Cut(path, "/")
+	if !strings.Contains(first, ".") {
+		return "stdlib"
+	}
+	return "third_party"
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetPackageInfo(t *testing.T) {
+	const code = `package sample
+
+import (
+	"fmt"
+	. "math"
+	_ "embed"
+	str "strings"
+
+	"github.com/example/lib"
+)
+
+import "os"
+`
+	result, err := GetPackageInfo(code)
+	if err != nil {
+		t.Fatalf("GetPackageInfo: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("GetPackageInfo failed: %s", result.Error)
+	}
+	if result.PackageName != "sample" {
+		t.Errorf("PackageName = %q, want sample", result.PackageName)
+	}
+	want := []PackageImport{
+		{Path: "fmt", Line: 4},
+		{Path: "math", Alias: ".", Line: 5},
+		{Path: "embed", Alias: "_", Line: 6},
+		{Path: "strings", Alias: "str", Line: 7},
+		{Path: "github.com/example/lib", Line: 9},
+		{Path: "os", Line: 12},
+	}
+	if !slices.Equal(result.Imports, want) {
+		t.Errorf("Imports = %+v, want %+v", result.Imports, want)
+	}
+}
+
+func TestGetPackageInfoSyntaxError(t *testing.T) {
+	result, err := GetPackageInfo("package p\nimport (")
+	if err != nil {
+		t.Fatalf("GetPackageInfo: %v", err)
+	}
+	if result.Success || len(result.Diagnostics) == 0 {
+		t.Errorf("GetPackageInfo = %+v, want a failure with diagnostics", result)
+	}
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxInputSize is the input size limit used when none is configured
+const DefaultMaxInputSize = 1 << 20
+
+// MaxInputSize is the largest source, in bytes, accepted by one request:
+// the code, a file read through filePath, or all files together. Servers may
+// change it at startup; 0 removes the limit.
+var MaxInputSize = DefaultMaxInputSize
+
+var (
+	// ErrInputTooLarge is returned for source larger than MaxInputSize
+	ErrInputTooLarge = errors.New("input too large")
+	// ErrBinaryInput is returned for input that cannot be Go source
+	ErrBinaryInput = errors.New("input is not text")
+)
+
+// checkSource rejects code larger than MaxInputSize, and code containing NUL
+// bytes, which the Go compiler does not accept and which mark binary data.
+// Entry points call it before writing code to disk or parsing it.
+func checkSource(code string) error {
+	if MaxInputSize > 0 && len(code) > MaxInputSize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrInputTooLarge, len(code), MaxInputSize)
+	}
+	if strings.IndexByte(code, 0) >= 0 {
+		return fmt.Errorf("%w: it contains NUL bytes, which Go source cannot", ErrBinaryInput)
+	}
+	return nil
+}
+
+// checkSources applies checkSource to each of files, and MaxInputSize to
+// their combined size
+func checkSources(files map[string]string) error {
+	total := 0
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		code := files[name]
+		if err := checkSource(code); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		total += len(code)
+	}
+	if MaxInputSize > 0 && total > MaxInputSize {
+		return fmt.Errorf("%w: the files total %d bytes, exceeding the limit of %d bytes", ErrInputTooLarge, total, MaxInputSize)
+	}
+	return nil
+}
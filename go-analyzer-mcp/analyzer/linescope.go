@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// lineScope restricts results to the declarations overlapping lines start
+// to end, inclusive. A zero bound leaves that end of the file open, so the
+// zero lineScope covers every line.
+type lineScope struct {
+	start, end int
+}
+
+// newLineScope validates the startLine and endLine inputs of a tool, which
+// apply to code only, since the files of a multi-file request have lines of
+// their own
+func newLineScope(start, end int, files map[string]string) (lineScope, error) {
+	switch {
+	case start < 0 || end < 0:
+		return lineScope{}, errors.New("startLine and endLine must not be negative")
+	case end > 0 && end < start:
+		return lineScope{}, errors.New("endLine must not precede startLine")
+	case (start > 0 || end > 0) && len(files) > 0:
+		return lineScope{}, errors.New("startLine and endLine apply to code only, not to files")
+	}
+	return lineScope{start: start, end: end}, nil
+}
+
+// whole reports whether the scope covers every line
+func (s lineScope) whole() bool {
+	return s.start == 0 && s.end == 0
+}
+
+// overlaps reports whether any of lines first to last is in the scope
+func (s lineScope) overlaps(first, last int) bool {
+	return (s.start == 0 || last >= s.start) && (s.end == 0 || first <= s.end)
+}
+
+// covers reports whether node overlaps the scope, so that a declaration
+// starting before the scope but reaching into it is included
+func (s lineScope) covers(fset *token.FileSet, node ast.Node) bool {
+	return s.whole() || s.overlaps(fset.Position(node.Pos()).Line, fset.Position(node.End()).Line)
+}
+
+// widen extends the scope to the whole of the top-level declarations of file
+// that overlap it, so that a function cut by the scope is measured in full
+func (s lineScope) widen(file *ast.File, fset *token.FileSet) lineScope {
+	if s.whole() {
+		return s
+	}
+	for _, decl := range file.Decls {
+		first, last := fset.Position(decl.Pos()).Line, fset.Position(decl.End()).Line
+		if !s.overlaps(first, last) {
+			continue
+		}
+		if s.start > 0 {
+			s.start = min(s.start, first)
+		}
+		if s.end > 0 {
+			s.end = max(s.end, last)
+		}
+	}
+	return s
+}
+
+// clip returns the kinds of the lines in the scope, given those of every
+// line of a file
+func (s lineScope) clip(kinds []lineKind) []lineKind {
+	first, last := s.bounds(len(kinds))
+	return kinds[first:last]
+}
+
+// text returns the lines of code in the scope
+func (s lineScope) text(code string) string {
+	if s.whole() {
+		return code
+	}
+	lines := strings.SplitAfter(code, "\n")
+	first, last := s.bounds(len(lines))
+	return strings.Join(lines[first:last], "")
+}
+
+// bounds returns the slice bounds of the scope over n lines, clamped to them
+func (s lineScope) bounds(n int) (int, int) {
+	first, last := 0, n
+	if s.start > 0 {
+		first = min(s.start-1, n)
+	}
+	if s.end > 0 {
+		last = max(min(s.end, n), first)
+	}
+	return first, last
+}
@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Lint backends reported in LintCodeOutput.Backend
+const (
+	BackendGolangciLint = "golangci-lint"
+	BackendGoVet        = "go vet"
+)
+
+// linterNamePattern matches golangci-lint linter names
+var linterNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// LintCodeInput represents the input for linting
+type LintCodeInput struct {
+	Code     string   `json:"code,omitempty" jsonschema:"Go source code to lint"`
+	FilePath string   `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Linters  []string `json:"linters,omitempty" jsonschema:"Optional golangci-lint linters to run instead of its default set, e.g. 'errcheck' or 'staticcheck'; ignored when falling back to go vet"`
+}
+
+// LintCodeOutput represents the result of linting
+type LintCodeOutput struct {
+	Success      bool         `json:"success"`
+	Backend      string       `json:"backend"` // "golangci-lint", or "go vet" when golangci-lint is not on PATH
+	Diagnostics  []Diagnostic `json:"diagnostics"`
+	ErrorCount   int          `json:"error_count"`
+	WarningCount int          `json:"warning_count"`
+}
+
+// golangciReport is the part of golangci-lint's JSON output that is used
+type golangciReport struct {
+	Issues []struct {
+		FromLinter string
+		Text       string
+		Severity   string
+		Pos        struct {
+			Filename string
+			Line     int
+			Column   int
+		}
+	}
+}
+
+// LintCode lints code with golangci-lint when it is on PATH, restricted to
+// linters if any are given. Otherwise it falls back to go vet and linters is
+// ignored. golangci-lint diagnostics name the linter that reported them in
+// their message and Check.
+func LintCode(code string, linters []string) (*LintCodeOutput, error) {
+	if err := checkSource(code); err != nil {
+		return nil, err
+	}
+	code, _ = normalizeSource(code)
+	for _, linter := range linters {
+		if !linterNamePattern.MatchString(linter) {
+			return nil, fmt.Errorf("invalid linter name %q", linter)
+		}
+	}
+
+	binary, err := exec.LookPath("golangci-lint")
+	if err != nil {
+		vet, err := AnalyzeCode(AnalyzeCodeInput{Code: code})
+		if err != nil {
+			return nil, err
+		}
+		return &LintCodeOutput{
+			Success:      vet.Success,
+			Backend:      BackendGoVet,
+			Diagnostics:  vet.Diagnostics,
+			ErrorCount:   vet.ErrorCount,
+			WarningCount: vet.WarningCount,
+		}, nil
+	}
+
+	tempDir, release, err := workDirs.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	const fileName = "temp.go"
+	if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(code), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	cmd := exec.Command(binary, golangciArgs(binary, linters, fileName)...)
+	cmd.Dir = tempDir
+	cmd.Env = subprocessEnv(untrustedCodeEnv...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var report golangciReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("golangci-lint failed: %v - %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("failed to parse golangci-lint output: %w", err)
+	}
+
+	result := &LintCodeOutput{
+		Backend:     BackendGolangciLint,
+		Diagnostics: []Diagnostic{},
+	}
+	for _, issue := range report.Issues {
+		severity := "warning"
+		if strings.EqualFold(issue.Severity, "error") || issue.FromLinter == "typecheck" {
+			severity = "error"
+			result.ErrorCount++
+		} else {
+			result.WarningCount++
+		}
+		result.Diagnostics = append(result.Diagnostics, Diagnostic{
+			File:     filepath.ToSlash(issue.Pos.Filename),
+			Line:     issue.Pos.Line,
+			Column:   issue.Pos.Column,
+			Message:  issue.FromLinter + ": " + issue.Text,
+			Severity: severity,
+			Check:    issue.FromLinter,
+		})
+	}
+	result.Success = len(result.Diagnostics) == 0
+
+	hideScratchDir(result.Diagnostics, tempDir)
+	fingerprintDiagnostics(result.Diagnostics, "golangci-lint", func(file string) string {
+		if file == fileName {
+			return code
+		}
+		return ""
+	})
+	return result, nil
+}
+
+// golangciArgs builds the golangci-lint command line for fileName. Version 2
+// renamed the output and linter selection flags, so the installed version is
+// checked first.
+func golangciArgs(binary string, linters []string, fileName string) []string {
+	version, _ := exec.Command(binary, "--version").Output()
+	if bytes.Contains(version, []byte("version 2.")) {
+		args := []string{"run", "--output.json.path=stdout", "--show-stats=false", "--issues-exit-code=0"}
+		if len(linters) > 0 {
+			args = append(args, "--default=none", "--enable="+strings.Join(linters, ","))
+		}
+		return append(args, fileName)
+	}
+
+	args := []string{"run", "--out-format=json", "--issues-exit-code=0"}
+	if len(linters) > 0 {
+		args = append(args, "--disable-all", "--enable="+strings.Join(linters, ","))
+	}
+	return append(args, fileName)
+}
@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"go/ast"
+	"sort"
+)
+
+// DefaultMaxFunctionLines is the length above which a function is reported
+// when no limit is given
+const DefaultMaxFunctionLines = 50
+
+// FindLongFunctionsInput represents the input for long function detection
+type FindLongFunctionsInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	MaxLines int    `json:"maxLines,omitempty" jsonschema:"Report functions longer than this many lines (default: 50)"`
+}
+
+// LongFunctionsOutput represents the functions longer than the limit
+type LongFunctionsOutput struct {
+	Success     bool           `json:"success"`
+	Functions   []LongFunction `json:"functions"` // Longest first
+	Count       int            `json:"count"`
+	MaxLines    int            `json:"max_lines"` // The limit applied
+	Error       string         `json:"error,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// LongFunction represents a function longer than the limit
+type LongFunction struct {
+	Name     string `json:"name"`
+	Receiver string `json:"receiver,omitempty"` // Receiver type name, for methods
+	Line     int    `json:"line"`
+	EndLine  int    `json:"end_line"` // Line of the closing brace
+	Lines    int    `json:"lines"`    // Physical lines, as lines_of_code in calculate_metrics
+}
+
+// FindLongFunctions lists the function declarations of code spanning more
+// than maxLines lines, or DefaultMaxFunctionLines when maxLines is not
+// positive, longest first. Lengths are counted as in CalculateMetrics, from
+// the func keyword to the closing brace, but no other metrics are computed.
+func FindLongFunctions(code string, maxLines int) (*LongFunctionsOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &LongFunctionsOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	if maxLines <= 0 {
+		maxLines = DefaultMaxFunctionLines
+	}
+	result := &LongFunctionsOutput{
+		Success:   true,
+		Functions: []LongFunction{},
+		MaxLines:  maxLines,
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start := fset.Position(fn.Pos()).Line
+		end := fset.Position(fn.End()).Line
+		if lines := end - start + 1; lines > maxLines {
+			receiver := ""
+			if fn.Recv != nil && len(fn.Recv.List) > 0 {
+				receiver = receiverTypeName(fn.Recv.List[0].Type)
+			}
+			result.Functions = append(result.Functions, LongFunction{
+				Name:     fn.Name.Name,
+				Receiver: receiver,
+				Line:     start,
+				EndLine:  end,
+				Lines:    lines,
+			})
+		}
+	}
+
+	sort.SliceStable(result.Functions, func(i, j int) bool {
+		return result.Functions[i].Lines > result.Functions[j].Lines
+	})
+	result.Count = len(result.Functions)
+	return result, nil
+}
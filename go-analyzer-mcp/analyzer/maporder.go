@@ -0,0 +1,344 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// mapOrderNote qualifies every map order dependency report
+const mapOrderNote = "Heuristic: map types are inferred from declarations in the file, and a slice built from a map may be returned where callers do not care about its order. Sort the keys, or the slice, before relying on its order."
+
+// FindMapOrderDependencyInput represents the input for map order dependency detection
+type FindMapOrderDependencyInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// MapOrderOutput represents the result of map order dependency detection
+type MapOrderOutput struct {
+	Success      bool                 `json:"success"`
+	Dependencies []MapOrderDependency `json:"dependencies"`
+	Count        int                  `json:"count"`
+	Note         string               `json:"note,omitempty"` // Caveats of the heuristic
+	Error        string               `json:"error,omitempty"`
+	Diagnostics  []Diagnostic         `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// MapOrderDependency represents a slice built by ranging over a map and then
+// used as if its order were deterministic
+type MapOrderDependency struct {
+	Function string `json:"function"` // Enclosing function, or "func literal"
+	Map      string `json:"map"`      // Ranged expression
+	Slice    string `json:"slice"`    // Slice appended to in the loop
+	Use      string `json:"use"`      // "returned" or "compared"
+	Message  string `json:"message"`
+	Line     int    `json:"line"` // Position of the range statement
+	Column   int    `json:"column"`
+	UseLine  int    `json:"use_line"`
+}
+
+// FindMapOrderDependency flags range loops over maps that append to a slice
+// which the function later returns or compares, since map iteration order is
+// random and so is the order of the slice. Slices passed to a sort or
+// slices.Sort function before being used are not reported. Whether an
+// expression is a map is decided from declarations in the file alone, so the
+// results are heuristic.
+func FindMapOrderDependency(code string) (*MapOrderOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &MapOrderOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	// Struct fields of map type, matched by name in selector expressions
+	mapFields := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if st, ok := n.(*ast.StructType); ok {
+			for _, field := range st.Fields.List {
+				if isMapType(field.Type) {
+					for _, name := range field.Names {
+						mapFields[name.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	result := &MapOrderOutput{
+		Success:      true,
+		Dependencies: []MapOrderDependency{},
+		Note:         mapOrderNote,
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		var name string
+		var typ *ast.FuncType
+		var body *ast.BlockStmt
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			name, typ, body = fn.Name.Name, fn.Type, fn.Body
+		case *ast.FuncLit:
+			name, typ, body = "func literal", fn.Type, fn.Body
+		default:
+			return true
+		}
+		if body != nil {
+			result.Dependencies = append(result.Dependencies, mapOrderDependencies(name, typ, body, mapFields, fset)...)
+		}
+		return true
+	})
+
+	sort.SliceStable(result.Dependencies, func(i, j int) bool {
+		return result.Dependencies[i].Line < result.Dependencies[j].Line
+	})
+	result.Count = len(result.Dependencies)
+	return result, nil
+}
+
+// mapOrderDependencies reports the map range loops of one function whose
+// appended slices are later returned or compared. Nested functions are
+// skipped; they are checked on their own.
+func mapOrderDependencies(name string, typ *ast.FuncType, body *ast.BlockStmt, mapFields map[string]bool, fset *token.FileSet) []MapOrderDependency {
+	var found []MapOrderDependency
+	inspectSkippingFuncLits(body, func(n ast.Node) {
+		loop, ok := n.(*ast.RangeStmt)
+		if !ok || !isMapExpr(loop.X, mapFields) {
+			return
+		}
+		for _, slice := range appendedSlices(loop) {
+			use, usePos := orderedUse(slice, typ, body, loop.End())
+			if use == "" {
+				continue
+			}
+			pos := fset.Position(loop.Pos())
+			mapName := types.ExprString(loop.X)
+			found = append(found, MapOrderDependency{
+				Function: name,
+				Map:      mapName,
+				Slice:    slice.Name,
+				Use:      use,
+				Message: fmt.Sprintf("%s is built by ranging over map %s and then %s, but map iteration order is random; sort it, or the keys, first if its order matters",
+					slice.Name, mapName, use),
+				Line:    pos.Line,
+				Column:  pos.Column,
+				UseLine: fset.Position(usePos).Line,
+			})
+		}
+	})
+	return found
+}
+
+// inspectSkippingFuncLits calls visit for every node under root except those
+// inside function literals
+func inspectSkippingFuncLits(root ast.Node, visit func(ast.Node)) {
+	ast.Inspect(root, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		if n != nil {
+			visit(n)
+		}
+		return true
+	})
+}
+
+// isMapType reports whether expr is a map type or names a map type declared
+// in the file
+func isMapType(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.MapType:
+		return true
+	case *ast.ParenExpr:
+		return isMapType(t.X)
+	case *ast.Ident:
+		if t.Obj != nil {
+			if spec, ok := t.Obj.Decl.(*ast.TypeSpec); ok {
+				_, isMap := spec.Type.(*ast.MapType)
+				return isMap
+			}
+		}
+	}
+	return false
+}
+
+// isMapExpr reports whether expr evidently has a map type: a map literal or
+// make call, a variable declared with or initialized from one, or a struct
+// field declared with a map type
+func isMapExpr(expr ast.Expr, mapFields map[string]bool) bool {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return isMapExpr(e.X, mapFields)
+	case *ast.CompositeLit:
+		return e.Type != nil && isMapType(e.Type)
+	case *ast.CallExpr:
+		fn, ok := e.Fun.(*ast.Ident)
+		return ok && fn.Name == "make" && len(e.Args) > 0 && isMapType(e.Args[0])
+	case *ast.SelectorExpr:
+		return mapFields[e.Sel.Name]
+	case *ast.Ident:
+		if e.Obj == nil || e.Obj.Kind != ast.Var {
+			return false
+		}
+		switch decl := e.Obj.Decl.(type) {
+		case *ast.Field:
+			return isMapType(decl.Type)
+		case *ast.ValueSpec:
+			if decl.Type != nil {
+				return isMapType(decl.Type)
+			}
+			for i, name := range decl.Names {
+				if name.Obj == e.Obj && i < len(decl.Values) && len(decl.Values) == len(decl.Names) {
+					return isMapExpr(decl.Values[i], mapFields)
+				}
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range decl.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && ident.Obj == e.Obj && len(decl.Rhs) == len(decl.Lhs) {
+					return isMapExpr(decl.Rhs[i], mapFields)
+				}
+			}
+		}
+	}
+	return false
+}
+
+// appendedSlices returns the variables declared outside loop that its body
+// extends with x = append(x, ...), in order of first append
+func appendedSlices(loop *ast.RangeStmt) []*ast.Ident {
+	var appended []*ast.Ident
+	seen := map[*ast.Object]bool{}
+	inspectSkippingFuncLits(loop.Body, func(n ast.Node) {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != len(assign.Rhs) {
+			return
+		}
+		for i, lhs := range assign.Lhs {
+			target, ok := lhs.(*ast.Ident)
+			if !ok || target.Obj == nil || seen[target.Obj] || !isAppendTo(assign.Rhs[i], target.Obj) {
+				continue
+			}
+			if decl, ok := target.Obj.Decl.(ast.Node); ok && decl.Pos() >= loop.Pos() && decl.Pos() < loop.End() {
+				continue // Declared inside the loop
+			}
+			seen[target.Obj] = true
+			appended = append(appended, target)
+		}
+	})
+	return appended
+}
+
+// isAppendTo reports whether expr is a call append(x, ...) for the variable obj
+func isAppendTo(expr ast.Expr, obj *ast.Object) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return false
+	}
+	if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "append" {
+		return false
+	}
+	arg, ok := call.Args[0].(*ast.Ident)
+	return ok && arg.Obj == obj
+}
+
+// orderedUse finds the first use of slice after the loop ending at after in
+// which its order matters: being returned, compared with DeepEqual or an
+// Equal function, or having an element compared. It returns "" when there is
+// none, or when the slice is sorted first.
+func orderedUse(slice *ast.Ident, typ *ast.FuncType, body *ast.BlockStmt, after token.Pos) (string, token.Pos) {
+	namedResult := false
+	if typ.Results != nil {
+		for _, field := range typ.Results.List {
+			for _, name := range field.Names {
+				namedResult = namedResult || name.Obj == slice.Obj
+			}
+		}
+	}
+
+	// refers reports whether expr holds the slice itself, possibly inside a
+	// composite literal. Calls and operators are looked into only when
+	// calls is set, and then never len or cap, which ignore order.
+	refers := func(expr ast.Expr, calls bool) bool {
+		found := false
+		ast.Inspect(expr, func(n ast.Node) bool {
+			switch e := n.(type) {
+			case *ast.CallExpr:
+				if fn, ok := e.Fun.(*ast.Ident); !calls || ok && (fn.Name == "len" || fn.Name == "cap") {
+					return false
+				}
+			case *ast.BinaryExpr, *ast.IndexExpr, *ast.SliceExpr:
+				return calls
+			case *ast.Ident:
+				found = found || e.Obj == slice.Obj
+			}
+			return !found
+		})
+		return found
+	}
+
+	use, usePos, sortPos := "", token.NoPos, token.NoPos
+	inspectSkippingFuncLits(body, func(n ast.Node) {
+		if n.Pos() < after {
+			return
+		}
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok || len(node.Args) == 0 {
+				return
+			}
+			pkg, _ := sel.X.(*ast.Ident)
+			switch {
+			case pkg != nil && (pkg.Name == "sort" || pkg.Name == "slices" && strings.HasPrefix(sel.Sel.Name, "Sort")):
+				if refers(node.Args[0], true) && (sortPos == token.NoPos || node.Pos() < sortPos) {
+					sortPos = node.Pos()
+				}
+			case sel.Sel.Name == "DeepEqual" || sel.Sel.Name == "Equal":
+				for _, arg := range node.Args {
+					if ident, ok := arg.(*ast.Ident); ok && ident.Obj == slice.Obj {
+						use, usePos = firstUse(use, usePos, "compared", node.Pos())
+					}
+				}
+			}
+		case *ast.BinaryExpr:
+			switch node.Op {
+			case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+				for _, operand := range []ast.Expr{node.X, node.Y} {
+					if index, ok := operand.(*ast.IndexExpr); ok {
+						if ident, ok := index.X.(*ast.Ident); ok && ident.Obj == slice.Obj {
+							use, usePos = firstUse(use, usePos, "compared", node.Pos())
+						}
+					}
+				}
+			}
+		case *ast.ReturnStmt:
+			returned := len(node.Results) == 0 && namedResult
+			for _, res := range node.Results {
+				returned = returned || refers(res, false)
+			}
+			if returned {
+				use, usePos = firstUse(use, usePos, "returned", node.Pos())
+			}
+		}
+	})
+
+	if use == "" || sortPos != token.NoPos && sortPos < usePos {
+		return "", token.NoPos
+	}
+	return use, usePos
+}
+
+// firstUse keeps whichever of the current and the new use comes first
+func firstUse(use string, usePos token.Pos, newUse string, newPos token.Pos) (string, token.Pos) {
+	if use == "" || newPos < usePos {
+		return newUse, newPos
+	}
+	return use, usePos
+}
@@ -1,47 +1,190 @@
 package analyzer
 
 import (
+	"cmp"
+	"fmt"
 	"go/ast"
+	"go/scanner"
 	"go/token"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 // CalculateMetricsInput represents the input for metrics calculation
 type CalculateMetricsInput struct {
-	Code string `json:"code" jsonschema:"Go source code to analyze"`
+	Code                string            `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath            string            `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Files               map[string]string `json:"files,omitempty" jsonschema:"Alternative to code: several files of one package keyed by file name, analyzed together"`
+	FileName            string            `json:"fileName,omitempty" jsonschema:"Name of the file in code; a _test.go suffix marks it as test code"`
+	ComplexityThreshold *int              `json:"complexityThreshold,omitempty" jsonschema:"Flag functions whose cyclomatic complexity is at or above this value (default: 10, 0 disables)"`
+	MinComplexity       int               `json:"minComplexity,omitempty" jsonschema:"Only list the function metrics of functions with at least this cyclomatic complexity, most complex first; the overall metrics still cover every function (default: 0, list all in source order)"`
+	HistogramBounds     []int             `json:"histogramBounds,omitempty" jsonschema:"Increasing upper bounds of the complexity histogram buckets, with a last bucket for the functions above them (default: [5, 10, 20], for 1-5, 6-10, 11-20 and 21+)"`
+	BestEffort          bool              `json:"bestEffort,omitempty" jsonschema:"When the code has syntax errors, measure the parts that parse and report the errors instead of failing"`
+	NoCache             bool              `json:"noCache,omitempty" jsonschema:"Calculate the metrics again instead of returning the cached result of an identical earlier request"`
+	Mode                string            `json:"mode,omitempty" jsonschema:"How to parse the code: 'file' (default), 'fragment' for declarations or statements without a package clause, or 'expr' for an expression; statements and expressions are measured as the body of a function named _"`
+	StartLine           int               `json:"startLine,omitempty" jsonschema:"Only measure the lines from this one on and the functions reaching them, e.g. the top of an editor viewport (default: 0, from the start of the code)"`
+	EndLine             int               `json:"endLine,omitempty" jsonschema:"Only measure the lines up to this one and the functions starting at or before it (default: 0, to the end of the code)"`
+}
+
+// DefaultComplexityThreshold is used when CalculateMetricsInput.ComplexityThreshold is unset
+const DefaultComplexityThreshold = 10
+
+// DefaultHistogramBounds returns the bucket bounds used when
+// CalculateMetricsInput.HistogramBounds is unset
+func DefaultHistogramBounds() []int {
+	return []int{5, 10, 20}
 }
 
 // CalculateMetricsOutput represents the result of metrics calculation
 type CalculateMetricsOutput struct {
-	Success              bool              `json:"success"`
-	Metrics              *CodeMetrics      `json:"metrics,omitempty"`
-	FunctionMetrics      []FunctionMetrics `json:"function_metrics,omitempty"`
-	Error                string            `json:"error,omitempty"`
+	Success                 bool              `json:"success"`
+	Metrics                 *CodeMetrics      `json:"metrics,omitempty"`
+	Production              *CodeMetrics      `json:"production,omitempty"`                // Non-test files only, when test files are present
+	Test                    *CodeMetrics      `json:"test,omitempty"`                      // _test.go files only
+	FunctionMetrics         []FunctionMetrics `json:"function_metrics,omitempty"`          // In source order, or most complex first when filtered by MinComplexity
+	MinComplexity           int               `json:"min_complexity,omitempty"`            // Complexity FunctionMetrics was filtered by, if any
+	ComplexityThreshold     int               `json:"complexity_threshold"`                // 0 when flagging is disabled
+	HighComplexityFunctions []FunctionMetrics `json:"high_complexity_functions,omitempty"` // Functions at or above ComplexityThreshold
+	ComplexityHistogram     map[string]int    `json:"complexity_histogram"`                // Number of functions by cyclomatic complexity range, e.g. "6-10" or "21+"
+	HistogramBounds         []int             `json:"histogram_bounds"`                    // Upper bounds of the histogram buckets but the last
+	Todos                   []TodoComment     `json:"todos"`                               // TODO, FIXME, XXX and HACK comments, in file and line order
+	Lines                   *LineRange        `json:"lines,omitempty"`                     // Lines measured when restricted by StartLine and EndLine
+	Partial                 bool              `json:"partial,omitempty"`                   // In best-effort mode, the code had syntax errors, listed in Diagnostics
+	Fragment                string            `json:"fragment,omitempty"`                  // "declarations", "statements" or "expression" when the code was parsed as a fragment
+	Error                   string            `json:"error,omitempty"`
+	Diagnostics             []Diagnostic      `json:"diagnostics,omitempty"` // Syntax errors when parsing fails or, in best-effort mode, that were skipped
 }
 
 // CodeMetrics represents overall code metrics
 type CodeMetrics struct {
-	LinesOfCode          int     `json:"lines_of_code"`
-	CommentLines         int     `json:"comment_lines"`
-	BlankLines           int     `json:"blank_lines"`
-	FunctionCount        int     `json:"function_count"`
-	TypeCount            int     `json:"type_count"`
-	AverageComplexity    float64 `json:"average_complexity"`
-	MaxComplexity        int     `json:"max_complexity"`
-	TotalComplexity      int     `json:"total_complexity"`
+	LinesOfCode          int             `json:"lines_of_code"`        // Physical lines, including blanks and comments
+	SourceLinesOfCode    int             `json:"source_lines_of_code"` // Lines containing code, even with a trailing comment
+	CommentLines         int             `json:"comment_lines"`        // Lines containing only comments
+	BlankLines           int             `json:"blank_lines"`
+	TodoCount            int             `json:"todo_count"` // Comment lines with a TODO, FIXME, XXX or HACK marker
+	FunctionCount        int             `json:"function_count"`
+	TypeCount            int             `json:"type_count"`
+	AverageComplexity    float64         `json:"average_complexity"`
+	MaxComplexity        int             `json:"max_complexity"`
+	TotalComplexity      int             `json:"total_complexity"`
+	LongestFunctionLines int             `json:"longest_function_lines"`
+	LongestFunctionName  string          `json:"longest_function_name,omitempty"`
+	TestFunctionCount    int             `json:"test_function_count"` // Test, benchmark and fuzz functions are only counted in _test.go files
+	BenchmarkCount       int             `json:"benchmark_count"`
+	FuzzTargetCount      int             `json:"fuzz_target_count"`
+	Halstead             HalsteadMetrics `json:"halstead"`              // Over the whole token stream of all files
+	MaintainabilityIndex float64         `json:"maintainability_index"` // 0-100, from the overall Halstead volume, total complexity and SLOC
+}
+
+// HalsteadMetrics holds Halstead counts derived from the token stream.
+// Operators are operator tokens and keywords (closing brackets are counted
+// with their opening bracket); operands are identifiers and literals.
+type HalsteadMetrics struct {
+	DistinctOperators int     `json:"distinct_operators"`
+	DistinctOperands  int     `json:"distinct_operands"`
+	TotalOperators    int     `json:"total_operators"`
+	TotalOperands     int     `json:"total_operands"`
+	Volume            float64 `json:"volume"` // (TotalOperators+TotalOperands) * log2(DistinctOperators+DistinctOperands)
 }
 
 // FunctionMetrics represents metrics for a single function
 type FunctionMetrics struct {
-	Name               string `json:"name"`
-	Line               int    `json:"line"`
-	CyclomaticComplexity int    `json:"cyclomatic_complexity"`
-	LinesOfCode        int    `json:"lines_of_code"`
+	Name                 string          `json:"name"`
+	Receiver             string          `json:"receiver,omitempty"` // Receiver type name, for methods
+	File                 string          `json:"file,omitempty"`     // Originating file, for multi-file requests
+	Line                 int             `json:"line"`
+	EndLine              int             `json:"end_line"` // Line of the closing brace
+	CyclomaticComplexity int             `json:"cyclomatic_complexity"`
+	CognitiveComplexity  int             `json:"cognitive_complexity"` // SonarSource cognitive complexity, which penalizes nesting
+	LinesOfCode          int             `json:"lines_of_code"`
+	MaxNestingDepth      int             `json:"max_nesting_depth"`
+	ParameterCount       int             `json:"parameter_count"` // Not counting the receiver; a, b int counts as 2
+	ReturnCount          int             `json:"return_count"`    // Results, counting named results like parameters
+	IsExported           bool            `json:"is_exported"`
+	IsMethod             bool            `json:"is_method"`
+	SourceLinesOfCode    int             `json:"source_lines_of_code"`
+	Halstead             HalsteadMetrics `json:"halstead"`
+	MaintainabilityIndex float64         `json:"maintainability_index"`
 }
 
-// CalculateMetrics calculates code metrics
-func CalculateMetrics(code string) (*CalculateMetricsOutput, error) {
-	file, fset, err := ParseAST(code)
+// CalculateMetrics calculates code metrics for code, or for several files of
+// one package when input.Files is set, in which case the totals cover all files.
+// When any file is a _test.go file, the totals are also split into production
+// and test code. Functions at or above the complexity threshold are also
+// listed separately, as are TODO, FIXME, XXX and HACK comments, which mark
+// technical debt. With input.MinComplexity set, FunctionMetrics only lists
+// the functions at or above it, most complex first. input.Mode selects how
+// code is parsed, as for GetSymbols; the line counts and Halstead metrics of
+// a fragment are its own, without the wrapper, and so are the extent and
+// metrics of the function named _ that wraps statements or an expression.
+// input.StartLine and input.EndLine restrict the
+// metrics to the top-level declarations overlapping those lines, which are
+// measured whole: the range is widened to cover them, and the line counts,
+// Halstead volume, TODOs, functions and complexities are those of the wider
+// range, reported in Lines. Results are cached by input unless
+// input.NoCache is set.
+func CalculateMetrics(input CalculateMetricsInput) (*CalculateMetricsOutput, error) {
+	var err error
+	input.Code, input.FileName, err = loadInputFile(input.Code, input.FileName, input.FilePath)
+	if err == nil {
+		err = checkSource(input.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	input.FilePath = ""
+	refresh := input.NoCache
+	input.NoCache = false
+	return cachedResult("calculate_metrics", input, refresh, calculateMetrics)
+}
+
+// calculateMetrics implements CalculateMetrics without the cache
+func calculateMetrics(input CalculateMetricsInput) (*CalculateMetricsOutput, error) {
+	input.Code, _ = normalizeSource(input.Code)
+	threshold := DefaultComplexityThreshold
+	if input.ComplexityThreshold != nil {
+		threshold = *input.ComplexityThreshold
+	}
+	if threshold < 0 {
+		return &CalculateMetricsOutput{
+			Success: false,
+			Error:   "complexityThreshold must not be negative",
+		}, nil
+	}
+	if input.MinComplexity < 0 {
+		return &CalculateMetricsOutput{
+			Success: false,
+			Error:   "minComplexity must not be negative",
+		}, nil
+	}
+	bounds := input.HistogramBounds
+	if len(bounds) == 0 {
+		bounds = DefaultHistogramBounds()
+	}
+	for i, bound := range bounds {
+		if bound < 1 || i > 0 && bound <= bounds[i-1] {
+			return &CalculateMetricsOutput{
+				Success: false,
+				Error:   "histogramBounds must be positive and increasing",
+			}, nil
+		}
+	}
+	if input.FileName != "" && len(input.Files) > 0 {
+		return &CalculateMetricsOutput{
+			Success: false,
+			Error:   "fileName applies to code only; files are named by their keys",
+		}, nil
+	}
+	scope, err := newLineScope(input.StartLine, input.EndLine, input.Files)
+	if err != nil {
+		return &CalculateMetricsOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	code, fragment, err := wrapSource(input.Code, input.Files, input.Mode)
 	if err != nil {
 		return &CalculateMetricsOutput{
 			Success: false,
@@ -49,44 +192,247 @@ func CalculateMetrics(code string) (*CalculateMetricsOutput, error) {
 		}, nil
 	}
 
-	metrics := &CodeMetrics{}
+	sources, fset, err := parseSources(code, input.Files, input.BestEffort)
+	if sources == nil {
+		message, diagnostics := parseFailure(code, fragment, err)
+		return &CalculateMetricsOutput{
+			Success:     false,
+			Error:       message,
+			Diagnostics: diagnostics,
+		}, nil
+	}
+
+	result := measureSources(sources, fset, input, fragment, scope, threshold, bounds)
+	if err != nil {
+		result.Partial = true
+		result.Diagnostics = parseErrorsToDiagnostics(err)
+	}
+	return result, nil
+}
+
+// measureSources calculates the metrics of the parsed sources within scope for
+// CalculateMetrics, with threshold and bounds already validated and fragment
+// the kind of fragment input.Code was wrapped as, if any
+func measureSources(sources []sourceFile, fset *token.FileSet, input CalculateMetricsInput, fragment string, scope lineScope, threshold int, bounds []int) *CalculateMetricsOutput {
+	metrics := newMetricsTally()
+	production, test := newMetricsTally(), newMetricsTally()
 	functionMetrics := []FunctionMetrics{}
+	todos := []TodoComment{}
+	todoMarkers, canonical, _ := todoPattern(defaultTodoMarkers)
+
+	// Only code has a scope, so there is a single source. The function
+	// wrapping statements and expressions spans all of them, so their scope
+	// is not widened to it.
+	if !scope.whole() && fragment != FragmentStatements && fragment != FragmentExpression {
+		scope = scope.widen(sources[0].AST, fset)
+	}
+
+	for _, src := range sources {
+		// Test code is told apart by the name of its file
+		fileName, name := "", src.Name
+		if len(input.Files) > 0 {
+			fileName = src.Name
+		} else if input.FileName != "" {
+			name = input.FileName
+		}
+
+		bucket := production
+		if strings.HasSuffix(name, "_test.go") {
+			bucket = test
+		}
+		fileMetrics, functions := calculateFileMetrics(src, fileName, bucket == test, scope, fset)
+		text := src.Code
+		if fragment != "" {
+			// Measure the fragment, not the wrapper around it
+			text = input.Code
+			fileMetrics.countLines(scope.clip(classifyLines(input.Code)))
+			if (fragment == FragmentStatements || fragment == FragmentExpression) && len(functions) == 1 {
+				unwrapFunctionMetrics(&functions[0], input.Code)
+				fileMetrics.LongestFunctionLines = functions[0].LinesOfCode
+			}
+		}
+		fileTodos := slices.DeleteFunc(findTodoComments(src.AST, fset, fileName, todoMarkers, canonical), func(todo TodoComment) bool {
+			return !scope.overlaps(todo.Line, todo.Line)
+		})
+		fileMetrics.TodoCount = len(fileTodos)
+		todos = append(todos, fileTodos...)
+		for _, tally := range []*metricsTally{metrics, bucket} {
+			tally.add(fileMetrics, scope.text(text))
+		}
+		functionMetrics = append(functionMetrics, functions...)
+	}
 
-	// Count lines
-	lines := strings.Split(code, "\n")
-	metrics.LinesOfCode = len(lines)
-	
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			metrics.BlankLines++
-		} else if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") {
-			metrics.CommentLines++
+	var highComplexity []FunctionMetrics
+	if threshold > 0 {
+		for _, fm := range functionMetrics {
+			if fm.CyclomaticComplexity >= threshold {
+				highComplexity = append(highComplexity, fm)
+			}
 		}
 	}
 
+	histogram := complexityHistogram(functionMetrics, bounds)
+
+	if input.MinComplexity > 0 {
+		functionMetrics = slices.DeleteFunc(functionMetrics, func(fm FunctionMetrics) bool {
+			return fm.CyclomaticComplexity < input.MinComplexity
+		})
+		slices.SortStableFunc(functionMetrics, func(a, b FunctionMetrics) int {
+			return cmp.Compare(b.CyclomaticComplexity, a.CyclomaticComplexity)
+		})
+	}
+
+	result := &CalculateMetricsOutput{
+		Success:                 true,
+		Metrics:                 metrics.finish(),
+		FunctionMetrics:         functionMetrics,
+		ComplexityThreshold:     threshold,
+		HighComplexityFunctions: highComplexity,
+		ComplexityHistogram:     histogram,
+		HistogramBounds:         bounds,
+		MinComplexity:           input.MinComplexity,
+		Todos:                   todos,
+		Fragment:                fragment,
+	}
+	if !scope.whole() {
+		first, last := scope.bounds(strings.Count(sources[0].Code, "\n") + 1)
+		result.Lines = &LineRange{Start: first + 1, End: last}
+	}
+	if test.files > 0 {
+		if production.files > 0 {
+			result.Production = production.finish()
+		}
+		result.Test = test.finish()
+	}
+	return result
+}
+
+// complexityHistogram counts functions by cyclomatic complexity, in buckets
+// up to each of bounds and one above the last. Every bucket is present, even
+// when empty.
+func complexityHistogram(functions []FunctionMetrics, bounds []int) map[string]int {
+	labels := HistogramLabels(bounds)
+	histogram := make(map[string]int, len(labels))
+	for _, label := range labels {
+		histogram[label] = 0
+	}
+	for _, fm := range functions {
+		bucket, _ := slices.BinarySearch(bounds, fm.CyclomaticComplexity)
+		histogram[labels[bucket]]++
+	}
+	return histogram
+}
+
+// HistogramLabels returns the keys of the complexity histogram buckets with
+// the given upper bounds, in increasing order: "1-5", "6-10" and "11+" for
+// bounds 5 and 10. A bucket of a single complexity is labeled by it alone.
+func HistogramLabels(bounds []int) []string {
+	labels := make([]string, 0, len(bounds)+1)
+	low := 1
+	for _, bound := range bounds {
+		if bound == low {
+			labels = append(labels, strconv.Itoa(low))
+		} else {
+			labels = append(labels, fmt.Sprintf("%d-%d", low, bound))
+		}
+		low = bound + 1
+	}
+	return append(labels, fmt.Sprintf("%d+", low))
+}
+
+// calculateFileMetrics calculates the line, type and function counts of one
+// file, and the metrics of each of its functions. Tests, benchmarks and fuzz
+// targets are only counted when isTest is set. fileName is recorded in the
+// function metrics. Only the lines in scope are counted, and only the
+// functions and types overlapping it.
+func calculateFileMetrics(src sourceFile, fileName string, isTest bool, scope lineScope, fset *token.FileSet) (CodeMetrics, []FunctionMetrics) {
+	var metrics CodeMetrics
+	var functionMetrics []FunctionMetrics
+
+	kinds := classifyLines(src.Code)
+	metrics.countLines(scope.clip(kinds))
+
+	testingName := ""
+	if isTest {
+		testingName = importName(src.AST, "testing")
+	}
+
 	// Count types and functions
-	ast.Inspect(file, func(n ast.Node) bool {
+	ast.Inspect(src.AST, func(n ast.Node) bool {
+		if decl, ok := n.(ast.Decl); ok && !scope.covers(fset, decl) {
+			return false
+		}
 		switch decl := n.(type) {
 		case *ast.FuncDecl:
+			pos := fset.Position(decl.Pos())
+			end := fset.Position(decl.End())
+			if !end.IsValid() || end.Line > len(kinds) || end.Offset > len(src.Code) || end.Offset < pos.Offset {
+				// A declaration cut short by a syntax error, in best-effort
+				// mode, has no extent in the code to measure
+				return false
+			}
 			metrics.FunctionCount++
-			
+			switch testFunctionKind(decl, testingName) {
+			case "test":
+				metrics.TestFunctionCount++
+			case "benchmark":
+				metrics.BenchmarkCount++
+			case "fuzz":
+				metrics.FuzzTargetCount++
+			}
+
 			// Calculate cyclomatic complexity for this function
 			complexity := calculateComplexity(decl)
 			metrics.TotalComplexity += complexity
-			
+
 			if complexity > metrics.MaxComplexity {
 				metrics.MaxComplexity = complexity
 			}
 
-			pos := fset.Position(decl.Pos())
-			end := fset.Position(decl.End())
-			
+			lines := end.Line - pos.Line + 1
+
+			if lines > metrics.LongestFunctionLines {
+				metrics.LongestFunctionLines = lines
+				metrics.LongestFunctionName = decl.Name.Name
+			}
+
+			nesting := 0
+			if decl.Body != nil {
+				nesting = calculateNestingDepth(decl.Body, 0)
+			}
+
+			sloc := 0
+			for _, kind := range kinds[pos.Line-1 : end.Line] {
+				if kind == lineCode {
+					sloc++
+				}
+			}
+			counter := newHalsteadCounter()
+			counter.scan(src.Code[pos.Offset:end.Offset])
+			halstead := counter.metrics()
+
+			receiver := ""
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				receiver = receiverTypeName(decl.Recv.List[0].Type)
+			}
+
 			functionMetrics = append(functionMetrics, FunctionMetrics{
 				Name:                 decl.Name.Name,
+				Receiver:             receiver,
+				File:                 fileName,
 				Line:                 pos.Line,
+				EndLine:              end.Line,
 				CyclomaticComplexity: complexity,
-				LinesOfCode:          end.Line - pos.Line + 1,
+				CognitiveComplexity:  cognitiveComplexity(decl),
+				LinesOfCode:          lines,
+				MaxNestingDepth:      nesting,
+				ParameterCount:       len(fieldsOf(decl.Type.Params)),
+				ReturnCount:          len(fieldsOf(decl.Type.Results)),
+				IsExported:           decl.Name.IsExported(),
+				IsMethod:             decl.Recv != nil,
+				SourceLinesOfCode:    sloc,
+				Halstead:             halstead,
+				MaintainabilityIndex: maintainabilityIndex(halstead.Volume, complexity, sloc),
 			})
 
 		case *ast.GenDecl:
@@ -97,21 +443,243 @@ func CalculateMetrics(code string) (*CalculateMetricsOutput, error) {
 		return true
 	})
 
+	return metrics, functionMetrics
+}
+
+// unwrapFunctionMetrics corrects the metrics of the function named _ that
+// wraps a statements or expression fragment to those of code, the fragment
+// alone: the function ends on the line after the fragment, and its tokens
+// include those of the wrapper
+func unwrapFunctionMetrics(fm *FunctionMetrics, code string) {
+	kinds := classifyLines(strings.TrimSuffix(code, "\n"))
+	fm.EndLine = min(fm.EndLine, len(kinds))
+	fm.LinesOfCode = fm.EndLine - fm.Line + 1
+
+	fm.SourceLinesOfCode = 0
+	for _, kind := range kinds[fm.Line-1 : fm.EndLine] {
+		if kind == lineCode {
+			fm.SourceLinesOfCode++
+		}
+	}
+	counter := newHalsteadCounter()
+	counter.scan(code)
+	fm.Halstead = counter.metrics()
+	fm.MaintainabilityIndex = maintainabilityIndex(fm.Halstead.Volume, fm.CyclomaticComplexity, fm.SourceLinesOfCode)
+}
+
+// metricsTally sums the metrics of a group of files
+type metricsTally struct {
+	metrics  CodeMetrics
+	halstead *halsteadCounter // Over the token stream of all files
+	files    int
+}
+
+func newMetricsTally() *metricsTally {
+	return &metricsTally{halstead: newHalsteadCounter()}
+}
+
+// add adds the metrics of one file with the given source
+func (t *metricsTally) add(m CodeMetrics, code string) {
+	t.files++
+	t.halstead.scan(code)
+
+	t.metrics.LinesOfCode += m.LinesOfCode
+	t.metrics.SourceLinesOfCode += m.SourceLinesOfCode
+	t.metrics.CommentLines += m.CommentLines
+	t.metrics.BlankLines += m.BlankLines
+	t.metrics.TodoCount += m.TodoCount
+	t.metrics.FunctionCount += m.FunctionCount
+	t.metrics.TypeCount += m.TypeCount
+	t.metrics.TotalComplexity += m.TotalComplexity
+	t.metrics.TestFunctionCount += m.TestFunctionCount
+	t.metrics.BenchmarkCount += m.BenchmarkCount
+	t.metrics.FuzzTargetCount += m.FuzzTargetCount
+	t.metrics.MaxComplexity = max(t.metrics.MaxComplexity, m.MaxComplexity)
+	if m.LongestFunctionLines > t.metrics.LongestFunctionLines {
+		t.metrics.LongestFunctionLines = m.LongestFunctionLines
+		t.metrics.LongestFunctionName = m.LongestFunctionName
+	}
+}
+
+// finish derives the averages and indexes from the sums
+func (t *metricsTally) finish() *CodeMetrics {
+	metrics := t.metrics
+
 	// Calculate average complexity
 	if metrics.FunctionCount > 0 {
 		metrics.AverageComplexity = float64(metrics.TotalComplexity) / float64(metrics.FunctionCount)
 	}
 
-	return &CalculateMetricsOutput{
-		Success:         true,
-		Metrics:         metrics,
-		FunctionMetrics: functionMetrics,
-	}, nil
+	metrics.Halstead = t.halstead.metrics()
+	metrics.MaintainabilityIndex = maintainabilityIndex(metrics.Halstead.Volume, metrics.TotalComplexity, metrics.SourceLinesOfCode)
+	return &metrics
+}
+
+// testFunctionKind classifies fn as a "test", "benchmark" or "fuzz" target by
+// the naming and signature rules of go test, given the file's name for the
+// testing package. Other functions, and all functions when testingName is
+// empty, have kind "".
+func testFunctionKind(fn *ast.FuncDecl, testingName string) string {
+	params := fn.Type.Params
+	if testingName == "" || fn.Recv != nil || fn.Type.Results != nil || params == nil || len(params.List) != 1 || len(params.List[0].Names) > 1 {
+		return ""
+	}
+	star, ok := params.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok || !isPackageIdent(sel.X, testingName) {
+		return ""
+	}
+
+	for _, kind := range []struct{ prefix, typ, name string }{
+		{"Test", "T", "test"},
+		{"Benchmark", "B", "benchmark"},
+		{"Fuzz", "F", "fuzz"},
+	} {
+		if sel.Sel.Name == kind.typ && hasTestPrefix(fn.Name.Name, kind.prefix) {
+			return kind.name
+		}
+	}
+	return ""
+}
+
+// lineKind classifies a physical source line
+type lineKind int
+
+const (
+	lineBlank   lineKind = iota // Whitespace only
+	lineComment                 // Comments only, including the body of a block comment
+	lineCode                    // At least one non-comment token
+)
+
+// countLines sets the line counts of m from the kinds of a file's lines
+func (m *CodeMetrics) countLines(kinds []lineKind) {
+	m.LinesOfCode, m.SourceLinesOfCode, m.CommentLines, m.BlankLines = len(kinds), 0, 0, 0
+	for _, kind := range kinds {
+		switch kind {
+		case lineCode:
+			m.SourceLinesOfCode++
+		case lineComment:
+			m.CommentLines++
+		default:
+			m.BlankLines++
+		}
+	}
+}
+
+// classifyLines classifies every physical line of code using the Go token
+// scanner, so trailing comments, block comments spanning several lines, and
+// comment markers inside string literals are all handled correctly
+func classifyLines(code string) []lineKind {
+	kinds := make([]lineKind, strings.Count(code, "\n")+1)
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, scanner.ScanComments)
+
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// Skip semicolons inserted automatically at line ends
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+
+		kind := lineCode
+		if tok == token.COMMENT {
+			kind = lineComment
+		}
+
+		// Comments and raw strings may span several lines
+		start := file.Line(pos)
+		end := start + strings.Count(lit, "\n")
+		for line := start; line <= end && line <= len(kinds); line++ {
+			if kind > kinds[line-1] {
+				kinds[line-1] = kind
+			}
+		}
+	}
+
+	return kinds
+}
+
+// halsteadCounter accumulates Halstead operator and operand counts
+type halsteadCounter struct {
+	operators map[string]int
+	operands  map[string]int
+}
+
+func newHalsteadCounter() *halsteadCounter {
+	return &halsteadCounter{operators: map[string]int{}, operands: map[string]int{}}
+}
+
+// scan adds the tokens of code, which need not be a complete file
+func (h *halsteadCounter) scan(code string) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, 0)
+
+	for {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return
+		case tok == token.IDENT || tok.IsLiteral():
+			h.operands[lit]++
+		case tok == token.RPAREN || tok == token.RBRACK || tok == token.RBRACE:
+			// Counted with the opening bracket
+		case tok == token.SEMICOLON && lit == "\n":
+			// Inserted automatically at line ends
+		case tok.IsOperator() || tok.IsKeyword():
+			h.operators[tok.String()]++
+		}
+	}
+}
+
+// metrics computes the Halstead counts and volume
+func (h *halsteadCounter) metrics() HalsteadMetrics {
+	m := HalsteadMetrics{
+		DistinctOperators: len(h.operators),
+		DistinctOperands:  len(h.operands),
+	}
+	for _, n := range h.operators {
+		m.TotalOperators += n
+	}
+	for _, n := range h.operands {
+		m.TotalOperands += n
+	}
+	if vocabulary := m.DistinctOperators + m.DistinctOperands; vocabulary > 1 {
+		m.Volume = float64(m.TotalOperators+m.TotalOperands) * math.Log2(float64(vocabulary))
+	}
+	return m
+}
+
+// maintainabilityIndex applies the maintainability index formula on the 0-100
+// scale used by Visual Studio:
+//
+//	max(0, (171 - 5.2 ln(V) - 0.23 CC - 16.2 ln(SLOC)) * 100 / 171)
+//
+// where V is the Halstead volume and CC the cyclomatic complexity. Higher is
+// better: 20 and above is considered maintainable, 10 to 19 moderately so, and
+// below 10 hard to maintain. Volume and SLOC are floored at 1 so empty input
+// does not produce infinities, which puts trivial code at 100.
+func maintainabilityIndex(volume float64, complexity, sloc int) float64 {
+	raw := 171 - 5.2*math.Log(math.Max(volume, 1)) - 0.23*float64(complexity) - 16.2*math.Log(math.Max(float64(sloc), 1))
+	return math.Max(0, raw*100/171)
 }
 
 // calculateComplexity calculates cyclomatic complexity for a function
 func calculateComplexity(fn *ast.FuncDecl) int {
 	complexity := 1 // Base complexity
+	if fn.Body == nil {
+		return complexity
+	}
 
 	ast.Inspect(fn.Body, func(n ast.Node) bool {
 		switch n.(type) {
@@ -136,3 +704,195 @@ func calculateComplexity(fn *ast.FuncDecl) int {
 
 	return complexity
 }
+
+// cognitiveComplexity calculates the SonarSource cognitive complexity of a
+// function. If, switch, select and loop statements add one plus their nesting
+// level; else and else if add one without a nesting penalty. Function literals
+// raise the nesting level without adding to it. Each run of like boolean
+// operators adds one, as do labeled jumps and direct recursion.
+func cognitiveComplexity(fn *ast.FuncDecl) int {
+	if fn.Body == nil {
+		return 0
+	}
+	c := &cognitiveCounter{fn: fn, counted: map[*ast.BinaryExpr]bool{}}
+	c.walk(fn.Body, 0)
+	return c.total
+}
+
+// cognitiveCounter accumulates the cognitive complexity of one function
+type cognitiveCounter struct {
+	fn        *ast.FuncDecl
+	total     int
+	recursive bool                     // A recursive call has been counted
+	counted   map[*ast.BinaryExpr]bool // Operators already counted with their sequence
+}
+
+// walk adds the increments of node and everything below it, taking node to
+// sit at the given nesting level
+func (c *cognitiveCounter) walk(node ast.Node, nesting int) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			c.total += 1 + nesting
+			c.ifChain(n, nesting)
+			return false
+		case *ast.ForStmt:
+			c.total += 1 + nesting
+			c.walkEach(nesting+1, n.Init, n.Cond, n.Post, n.Body)
+			return false
+		case *ast.RangeStmt:
+			c.total += 1 + nesting
+			c.walkEach(nesting+1, n.X, n.Body)
+			return false
+		case *ast.SwitchStmt:
+			c.total += 1 + nesting
+			c.walkEach(nesting+1, n.Init, n.Tag, n.Body)
+			return false
+		case *ast.TypeSwitchStmt:
+			c.total += 1 + nesting
+			c.walkEach(nesting+1, n.Init, n.Assign, n.Body)
+			return false
+		case *ast.SelectStmt:
+			c.total += 1 + nesting
+			c.walk(n.Body, nesting+1)
+			return false
+		case *ast.FuncLit:
+			c.walk(n.Body, nesting+1)
+			return false
+		case *ast.BranchStmt:
+			if n.Label != nil {
+				c.total++
+			}
+		case *ast.BinaryExpr:
+			c.booleanSequences(n)
+		case *ast.CallExpr:
+			if !c.recursive && c.callsItself(n) {
+				c.recursive = true
+				c.total++
+			}
+		}
+		return true
+	})
+}
+
+// walkEach walks the non-nil nodes at the given nesting level
+func (c *cognitiveCounter) walkEach(nesting int, nodes ...ast.Node) {
+	for _, node := range nodes {
+		if node != nil {
+			c.walk(node, nesting)
+		}
+	}
+}
+
+// ifChain walks the parts of an if statement found at nesting, whose own
+// increment has been added. Each else and else if adds one more.
+func (c *cognitiveCounter) ifChain(stmt *ast.IfStmt, nesting int) {
+	c.walkEach(nesting+1, stmt.Init, stmt.Cond, stmt.Body)
+	switch e := stmt.Else.(type) {
+	case *ast.IfStmt:
+		c.total++
+		c.ifChain(e, nesting)
+	case *ast.BlockStmt:
+		c.total++
+		c.walk(e, nesting+1)
+	}
+}
+
+// booleanSequences adds one for each run of like && or || operators in the
+// expression rooted at expr. Parenthesized expressions start runs of their own.
+func (c *cognitiveCounter) booleanSequences(expr *ast.BinaryExpr) {
+	if c.counted[expr] {
+		return
+	}
+	var ops []token.Token
+	var collect func(e ast.Expr)
+	collect = func(e ast.Expr) {
+		be, ok := e.(*ast.BinaryExpr)
+		if !ok || be.Op != token.LAND && be.Op != token.LOR {
+			return
+		}
+		c.counted[be] = true
+		collect(be.X)
+		ops = append(ops, be.Op)
+		collect(be.Y)
+	}
+	collect(expr)
+	for i, op := range ops {
+		if i == 0 || op != ops[i-1] {
+			c.total++
+		}
+	}
+}
+
+// callsItself reports whether call is a direct call of the function being
+// measured: by name for functions, or through the receiver for methods
+func (c *cognitiveCounter) callsItself(call *ast.CallExpr) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return c.fn.Recv == nil && fun.Name == c.fn.Name.Name
+	case *ast.SelectorExpr:
+		if c.fn.Recv == nil || len(c.fn.Recv.List) == 0 || len(c.fn.Recv.List[0].Names) == 0 || fun.Sel.Name != c.fn.Name.Name {
+			return false
+		}
+		recv, ok := fun.X.(*ast.Ident)
+		return ok && recv.Name == c.fn.Recv.List[0].Names[0].Name
+	}
+	return false
+}
+
+// calculateNestingDepth returns the deepest nesting of block-introducing
+// statements (if, for, range, switch, select, function literals) below node.
+// A function literal counts as one level, and its body nests beneath it.
+func calculateNestingDepth(node ast.Node, depth int) int {
+	maxDepth := depth
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == node {
+			return true
+		}
+		var d int
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			d = ifNestingDepth(n, depth)
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt,
+			*ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+			d = calculateNestingDepth(n, depth+1)
+		default:
+			return true
+		}
+		if d > maxDepth {
+			maxDepth = d
+		}
+		return false
+	})
+
+	return maxDepth
+}
+
+// ifNestingDepth returns the nesting depth of an if statement found at depth.
+// Each "else if" of the chain sits at the same level as the leading if rather
+// than one level deeper.
+func ifNestingDepth(stmt *ast.IfStmt, depth int) int {
+	maxDepth := depth + 1
+	parts := []ast.Node{stmt.Body}
+	if stmt.Init != nil {
+		parts = append(parts, stmt.Init)
+	}
+	if stmt.Cond != nil {
+		parts = append(parts, stmt.Cond)
+	}
+	switch e := stmt.Else.(type) {
+	case *ast.IfStmt:
+		if d := ifNestingDepth(e, depth); d > maxDepth {
+			maxDepth = d
+		}
+	case *ast.BlockStmt:
+		parts = append(parts, e)
+	}
+	for _, part := range parts {
+		if d := calculateNestingDepth(part, depth+1); d > maxDepth {
+			maxDepth = d
+		}
+	}
+
+	return maxDepth
+}
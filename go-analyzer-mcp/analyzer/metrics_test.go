@@ -0,0 +1,387 @@
+package analyzer
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestCalculateMetricsBestEffortTruncated(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      string
+		files     map[string]string
+		functions int
+	}{
+		{name: "func keyword only", code: "package p\nfunc"},
+		{name: "func name only", code: "package p\nfunc f"},
+		{name: "cut off in signature", code: "package p\nfunc f(a int"},
+		{name: "cut off in body", code: "package p\nfunc f() {\n\tx := 1\n", functions: 1},
+		{name: "cut off in nested block", code: "package p\nfunc f() {\n\tif x {", functions: 1},
+		{name: "complete function before cut", code: "package p\n\nfunc g() {}\n\nfunc", functions: 1},
+		{
+			name: "truncated file among files",
+			files: map[string]string{
+				"a.go": "package p\n\nfunc a() {}\n",
+				"b.go": "package p\nfunc",
+			},
+			functions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CalculateMetrics(CalculateMetricsInput{Code: tt.code, Files: tt.files, BestEffort: true, NoCache: true})
+			if err != nil {
+				t.Fatalf("CalculateMetrics: %v", err)
+			}
+			if !result.Success {
+				t.Fatalf("CalculateMetrics failed: %s", result.Error)
+			}
+			if !result.Partial {
+				t.Error("result not marked partial")
+			}
+			if got := result.Metrics.FunctionCount; got != tt.functions {
+				t.Errorf("FunctionCount = %d, want %d", got, tt.functions)
+			}
+		})
+	}
+}
+
+func TestCalculateMetricsBestEffortBrokenStatement(t *testing.T) {
+	result, err := CalculateMetrics(CalculateMetricsInput{Code: brokenStatementCode, BestEffort: true, NoCache: true})
+	if err != nil {
+		t.Fatalf("CalculateMetrics: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("CalculateMetrics failed: %s", result.Error)
+	}
+	if !result.Partial {
+		t.Error("result not marked partial")
+	}
+	var names []string
+	for _, fn := range result.FunctionMetrics {
+		names = append(names, fn.Name)
+	}
+	if !slices.Equal(names, []string{"a", "f", "g"}) || result.Metrics.FunctionCount != 3 {
+		t.Errorf("functions = %v with FunctionCount %d, want [a f g] and 3", names, result.Metrics.FunctionCount)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Line != 7 {
+		t.Errorf("Diagnostics = %+v, want one on line 7", result.Diagnostics)
+	}
+}
+
+// functionMetrics returns the metrics of code's functions keyed by name
+func functionMetrics(t *testing.T, input CalculateMetricsInput) (*CalculateMetricsOutput, map[string]FunctionMetrics) {
+	t.Helper()
+	input.NoCache = true
+	result, err := CalculateMetrics(input)
+	if err != nil {
+		t.Fatalf("CalculateMetrics: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("CalculateMetrics failed: %s", result.Error)
+	}
+	functions := map[string]FunctionMetrics{}
+	for _, fn := range result.FunctionMetrics {
+		functions[fn.Name] = fn
+	}
+	return result, functions
+}
+
+func TestCalculateMetricsNesting(t *testing.T) {
+	const code = `package p
+
+func flat() {}
+
+func ifs(a, b bool) {
+	if a {
+		if b {
+			return
+		}
+	}
+}
+
+func loops(xs []int, ch chan int) {
+	for range xs {
+		switch {
+		case true:
+			select {
+			case <-ch:
+			}
+		}
+	}
+}
+
+func literal() {
+	f := func() {
+		if true {
+		}
+	}
+	f()
+}
+
+func elseChain(x int) {
+	if x == 1 {
+	} else if x == 2 {
+	} else {
+	}
+}
+`
+	result, functions := functionMetrics(t, CalculateMetricsInput{Code: code})
+
+	tests := []struct {
+		name  string
+		depth int
+		lines int
+	}{
+		{name: "flat", depth: 0, lines: 1},
+		{name: "ifs", depth: 2, lines: 7},
+		{name: "loops", depth: 3, lines: 10},
+		{name: "literal", depth: 2, lines: 7},
+		{name: "elseChain", depth: 1, lines: 6}, // else if stays at the level of its if
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, ok := functions[tt.name]
+			if !ok {
+				t.Fatalf("no metrics for %s", tt.name)
+			}
+			if fn.MaxNestingDepth != tt.depth {
+				t.Errorf("MaxNestingDepth = %d, want %d", fn.MaxNestingDepth, tt.depth)
+			}
+			if fn.LinesOfCode != tt.lines {
+				t.Errorf("LinesOfCode = %d, want %d", fn.LinesOfCode, tt.lines)
+			}
+		})
+	}
+
+	if result.Metrics.LongestFunctionName != "loops" || result.Metrics.LongestFunctionLines != 10 {
+		t.Errorf("longest function = %s with %d lines, want loops with 10",
+			result.Metrics.LongestFunctionName, result.Metrics.LongestFunctionLines)
+	}
+}
+
+func TestCalculateMetricsFragment(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     CalculateMetricsInput
+		endLine   int
+		operators int // Total Halstead operators of the fragment
+		operands  int
+	}{
+		{
+			name:      "statements",
+			input:     CalculateMetricsInput{Code: "x := 1\nif x > 0 {\n\tx++\n}\n_ = x\n", Mode: ModeFragment},
+			endLine:   5,
+			operators: 6,
+			operands:  7,
+		},
+		{
+			name:      "statements without final newline",
+			input:     CalculateMetricsInput{Code: "x := 1\n_ = x", Mode: ModeFragment},
+			endLine:   2,
+			operators: 2,
+			operands:  4,
+		},
+		{
+			name:      "expression",
+			input:     CalculateMetricsInput{Code: "a + b*2", Mode: ModeExpr},
+			endLine:   1,
+			operators: 2,
+			operands:  3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, functions := functionMetrics(t, tt.input)
+			fn, ok := functions["_"]
+			if !ok || len(functions) != 1 {
+				t.Fatalf("FunctionMetrics = %+v, want only the wrapper", result.FunctionMetrics)
+			}
+			if fn.Line != 1 || fn.EndLine != tt.endLine || fn.LinesOfCode != tt.endLine || fn.SourceLinesOfCode != tt.endLine {
+				t.Errorf("lines %d-%d, %d lines, %d source lines, want 1-%d", fn.Line, fn.EndLine, fn.LinesOfCode, fn.SourceLinesOfCode, tt.endLine)
+			}
+			if result.Metrics.LongestFunctionLines != tt.endLine {
+				t.Errorf("LongestFunctionLines = %d, want %d", result.Metrics.LongestFunctionLines, tt.endLine)
+			}
+			for _, h := range []HalsteadMetrics{fn.Halstead, result.Metrics.Halstead} {
+				if h.TotalOperators != tt.operators || h.TotalOperands != tt.operands {
+					t.Errorf("Halstead counts %d operators and %d operands, want %d and %d",
+						h.TotalOperators, h.TotalOperands, tt.operators, tt.operands)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyLines(t *testing.T) {
+	const (
+		b = lineBlank
+		c = lineComment
+		x = lineCode
+	)
+	tests := []struct {
+		name string
+		code string
+		want []lineKind
+	}{
+		{name: "code and blank", code: "package p\n\nvar v int", want: []lineKind{x, b, x}},
+		{name: "line comment", code: "// doc\npackage p", want: []lineKind{c, x}},
+		{name: "trailing comment", code: "package p // name", want: []lineKind{x}},
+		{name: "block comment", code: "/*\n  text\n*/\npackage p", want: []lineKind{c, c, c, x}},
+		{name: "code after block comment", code: "/* a\n*/ package p", want: []lineKind{c, x}},
+		{name: "comment marker in string", code: "package p\nvar s = \"// not a comment\"", want: []lineKind{x, x}},
+		{name: "raw string", code: "package p\nvar s = `\n/* text */\n\n`", want: []lineKind{x, x, x, x, x}},
+		{name: "whitespace only", code: "package p\n \t\nvar v int", want: []lineKind{x, b, x}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyLines(tt.code); !slices.Equal(got, tt.want) {
+				t.Errorf("classifyLines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateMetricsLineCounts(t *testing.T) {
+	const code = `// Package p is a sample
+package p
+
+/*
+Block comment
+*/
+
+var s = "// in a string" // trailing
+
+func f() {
+	// inside
+	_ = s
+}
+`
+	result, _ := functionMetrics(t, CalculateMetricsInput{Code: code})
+	m := result.Metrics
+	got := [4]int{m.LinesOfCode, m.SourceLinesOfCode, m.CommentLines, m.BlankLines}
+	// The empty line after the final newline is counted as a blank line
+	if want := [4]int{14, 5, 5, 4}; got != want {
+		t.Errorf("lines, source lines, comment lines, blank lines = %v, want %v", got, want)
+	}
+}
+
+func TestMaintainabilityIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		volume     float64
+		complexity int
+		sloc       int
+		want       float64
+	}{
+		{name: "empty", want: 100},
+		{name: "trivial", volume: 1, complexity: 0, sloc: 1, want: 100},
+		{name: "small", volume: 100, complexity: 1, sloc: 10, want: 64.0475},
+		{name: "medium", volume: 1000, complexity: 5, sloc: 50, want: 41.2602},
+		{name: "clamped", volume: 1e6, complexity: 200, sloc: 5000, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maintainabilityIndex(tt.volume, tt.complexity, tt.sloc); math.Abs(got-tt.want) > 1e-3 {
+				t.Errorf("maintainabilityIndex(%v, %d, %d) = %v, want %v", tt.volume, tt.complexity, tt.sloc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateMetricsMaintainabilityRange(t *testing.T) {
+	const code = `package p
+
+func simple() int { return 1 }
+
+func branchy(x int) int {
+	switch {
+	case x > 10 && x < 20:
+		return 1
+	case x > 20 || x < -20:
+		return 2
+	}
+	for i := 0; i < x; i++ {
+		if i%2 == 0 {
+			x--
+		}
+	}
+	return x
+}
+`
+	result, functions := functionMetrics(t, CalculateMetricsInput{Code: code})
+	for _, mi := range []float64{result.Metrics.MaintainabilityIndex, functions["simple"].MaintainabilityIndex, functions["branchy"].MaintainabilityIndex} {
+		if mi < 0 || mi > 100 {
+			t.Errorf("maintainability index %v is outside 0-100", mi)
+		}
+	}
+	if functions["branchy"].MaintainabilityIndex >= functions["simple"].MaintainabilityIndex {
+		t.Errorf("branchy function scores %v, not below the simple one at %v",
+			functions["branchy"].MaintainabilityIndex, functions["simple"].MaintainabilityIndex)
+	}
+}
+
+func TestCalculateMetricsProductionAndTest(t *testing.T) {
+	const (
+		prod = "package p\n\nfunc A(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n\n// TestLike is not a test outside _test.go files\nfunc TestLike(t int) {}\n"
+		test = "package p\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {}\n\nfunc BenchmarkA(b *testing.B) {}\n\nfunc FuzzA(f *testing.F) {}\n\nfunc helper() {}\n"
+	)
+	// countsOf returns the function, test, benchmark and fuzz target counts
+	countsOf := func(m *CodeMetrics) []int {
+		if m == nil {
+			return nil
+		}
+		return []int{m.FunctionCount, m.TestFunctionCount, m.BenchmarkCount, m.FuzzTargetCount}
+	}
+
+	tests := []struct {
+		name                   string
+		input                  CalculateMetricsInput
+		total, production, tst []int
+	}{
+		{
+			name:  "production only",
+			input: CalculateMetricsInput{Code: prod},
+			total: []int{2, 0, 0, 0},
+		},
+		{
+			name:  "test file only",
+			input: CalculateMetricsInput{Code: test, FileName: "a_test.go"},
+			total: []int{4, 1, 1, 1},
+			tst:   []int{4, 1, 1, 1},
+		},
+		{
+			name:       "both",
+			input:      CalculateMetricsInput{Files: map[string]string{"a.go": prod, "a_test.go": test}},
+			total:      []int{6, 1, 1, 1},
+			production: []int{2, 0, 0, 0},
+			tst:        []int{4, 1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := functionMetrics(t, tt.input)
+			for _, part := range []struct {
+				name      string
+				got, want []int
+			}{
+				{"total", countsOf(result.Metrics), tt.total},
+				{"production", countsOf(result.Production), tt.production},
+				{"test", countsOf(result.Test), tt.tst},
+			} {
+				if !slices.Equal(part.got, part.want) {
+					t.Errorf("%s function, test, benchmark and fuzz counts = %v, want %v", part.name, part.got, part.want)
+				}
+			}
+			if result.Production != nil && result.Test != nil {
+				if sum := result.Production.LinesOfCode + result.Test.LinesOfCode; sum != result.Metrics.LinesOfCode {
+					t.Errorf("production and test lines add up to %d, want the total %d", sum, result.Metrics.LinesOfCode)
+				}
+				if sum := result.Production.TotalComplexity + result.Test.TotalComplexity; sum != result.Metrics.TotalComplexity {
+					t.Errorf("production and test complexity add up to %d, want the total %d", sum, result.Metrics.TotalComplexity)
+				}
+			}
+		})
+	}
+}
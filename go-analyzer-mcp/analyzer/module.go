@@ -0,0 +1,232 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Limits applied to uploaded module archives
+const (
+	MaxModuleZipSize      = 32 << 20  // Compressed archive size in bytes
+	maxModuleUnzippedSize = 128 << 20 // Total extracted size in bytes
+	maxModuleFiles        = 10000     // Number of archive entries
+)
+
+// ModuleAnalysisOutput represents the result of vetting an uploaded module
+type ModuleAnalysisOutput struct {
+	Success      bool              `json:"success"`
+	ModulePath   string            `json:"module_path,omitempty"`
+	Files        []FileDiagnostics `json:"files"`   // Diagnostics grouped by file, in file name order
+	General      []Diagnostic      `json:"general"` // Diagnostics without a file position
+	ErrorCount   int               `json:"error_count"`
+	WarningCount int               `json:"warning_count"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// ModuleZipOptions selects the build configuration an uploaded module is vetted for
+type ModuleZipOptions struct {
+	BuildTags []string // Passed to go vet as -tags
+	GOOS      string   // Target operating system (default: the server's)
+	GOARCH    string   // Target architecture (default: the server's)
+}
+
+// FileDiagnostics holds the diagnostics reported for one file of a module
+type FileDiagnostics struct {
+	File        string       `json:"file"` // Relative to the module root
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// AnalyzeModuleZip extracts a zipped Go module into a scratch directory and
+// runs go vet ./... over it. The go.mod may sit at the archive root or inside
+// a single top-level directory. Entries that would escape the directory,
+// symlinks, and archives exceeding the size or entry limits are rejected.
+// Files are included according to their build constraints under opts. The
+// module is vetted in untrustedCodeEnv, without cgo and without downloads.
+func AnalyzeModuleZip(data []byte, opts ModuleZipOptions) (*ModuleAnalysisOutput, error) {
+	if err := validateBuildContext(opts.BuildTags, opts.GOOS, opts.GOARCH); err != nil {
+		return &ModuleAnalysisOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if len(data) > MaxModuleZipSize {
+		return &ModuleAnalysisOutput{
+			Success: false,
+			Error:   fmt.Sprintf("archive exceeds %d bytes", MaxModuleZipSize),
+		}, nil
+	}
+
+	tempDir, release, err := workDirs.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := extractZip(data, tempDir); err != nil {
+		return &ModuleAnalysisOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	root, err := findModuleRoot(tempDir)
+	if err != nil {
+		return &ModuleAnalysisOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	diagnostics, _ := runVet(root, append(buildTagsFlag(opts.BuildTags), "./..."), opts.GOOS, opts.GOARCH, untrustedCodeEnv...)
+	hideScratchDir(diagnostics, root)
+
+	result := &ModuleAnalysisOutput{
+		Success:    true,
+		ModulePath: modulePathOf(string(goMod)),
+		Files:      []FileDiagnostics{},
+		General:    []Diagnostic{},
+	}
+
+	sources := map[string]string{}
+	fingerprintDiagnostics(diagnostics, "vet", func(file string) string {
+		if src, ok := sources[file]; ok {
+			return src
+		}
+		data, _ := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		sources[file] = string(data)
+		return sources[file]
+	})
+
+	byFile := map[string][]Diagnostic{}
+	for _, diag := range diagnostics {
+		if diag.Severity == "error" {
+			result.ErrorCount++
+		} else {
+			result.WarningCount++
+		}
+		if diag.File == "" {
+			result.General = append(result.General, diag)
+			continue
+		}
+		byFile[diag.File] = append(byFile[diag.File], diag)
+	}
+
+	for file, diagnostics := range byFile {
+		result.Files = append(result.Files, FileDiagnostics{File: file, Diagnostics: diagnostics})
+	}
+	sort.Slice(result.Files, func(i, j int) bool {
+		return result.Files[i].File < result.Files[j].File
+	})
+
+	return result, nil
+}
+
+// extractZip unpacks data into dir, enforcing the entry and size limits
+func extractZip(data []byte, dir string) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+	if len(reader.File) > maxModuleFiles {
+		return fmt.Errorf("archive has more than %d entries", maxModuleFiles)
+	}
+
+	var remaining int64 = maxModuleUnzippedSize
+	for _, entry := range reader.File {
+		name := path.Clean(strings.ReplaceAll(entry.Name, `\`, "/"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || filepath.VolumeName(name) != "" {
+			return fmt.Errorf("archive entry %q escapes the module directory", entry.Name)
+		}
+		if entry.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("archive entry %q is a symlink", entry.Name)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if entry.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		written, err := extractZipFile(entry, target, remaining)
+		if err != nil {
+			return err
+		}
+		remaining -= written
+	}
+	return nil
+}
+
+// extractZipFile writes one archive entry to target, failing once more than
+// limit bytes have been written rather than trusting the declared size
+func extractZipFile(entry *zip.File, target string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+
+	src, err := entry.Open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archive entry %q: %w", entry.Name, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	written, err := io.Copy(dst, io.LimitReader(src, limit+1))
+	if err != nil {
+		return written, fmt.Errorf("failed to extract archive entry %q: %w", entry.Name, err)
+	}
+	if written > limit {
+		return written, fmt.Errorf("archive expands beyond %d bytes", maxModuleUnzippedSize)
+	}
+	return written, nil
+}
+
+// findModuleRoot returns dir if it holds a go.mod, or its single top-level
+// subdirectory if that does, as produced by zipping a module's folder
+func findModuleRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		return dir, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		sub := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(sub, "go.mod")); err == nil {
+			return sub, nil
+		}
+	}
+	return "", errors.New("archive does not contain a go.mod at its root")
+}
+
+// modulePathOf returns the path declared by the module directive of a go.mod
+func modulePathOf(goMod string) string {
+	for _, line := range strings.Split(goMod, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module")
+		if ok && rest != "" && strings.ContainsRune(" \t\"", rune(rest[0])) {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// nolintPattern matches a //nolint directive with an optional linter list
+var nolintPattern = regexp.MustCompile(`^//\s*nolint(?::([\w-]+(?:\s*,\s*[\w-]+)*))?($|[\s/].*$)`)
+
+// CheckNolintInput represents the input for nolint justification checking
+type CheckNolintInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// NolintOutput represents the result of nolint justification checking
+type NolintOutput struct {
+	Success        bool              `json:"success"`
+	Unjustified    []NolintDirective `json:"unjustified"`
+	Count          int               `json:"count"`           // Number of unjustified directives
+	DirectiveCount int               `json:"directive_count"` // Number of nolint directives found
+	Error          string            `json:"error,omitempty"`
+	Diagnostics    []Diagnostic      `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// NolintDirective represents a //nolint comment without a justification
+type NolintDirective struct {
+	Linters []string `json:"linters,omitempty"` // Empty when all linters are suppressed
+	Comment string   `json:"comment"`
+	Message string   `json:"message"`
+	Line    int      `json:"line"`
+	Column  int      `json:"column"`
+}
+
+// CheckNolintJustification flags //nolint directives that do not explain
+// themselves. A justification is a second comment after the directive, as in
+// "//nolint:errcheck // best-effort cleanup".
+func CheckNolintJustification(code string) (*NolintOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &NolintOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	result := &NolintOutput{
+		Success:     true,
+		Unjustified: []NolintDirective{},
+	}
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			match := nolintPattern.FindStringSubmatch(comment.Text)
+			if match == nil {
+				continue
+			}
+			result.DirectiveCount++
+
+			rest := strings.TrimSpace(match[2])
+			reason, hasSeparator := strings.CutPrefix(rest, "//")
+			if hasSeparator && strings.TrimSpace(reason) != "" {
+				continue
+			}
+
+			message := "nolint directive has no justification; add one after '//'"
+			if rest != "" && !hasSeparator {
+				message = "nolint justification must follow a '//' separator"
+			}
+
+			var linters []string
+			for _, linter := range strings.Split(match[1], ",") {
+				if linter = strings.TrimSpace(linter); linter != "" {
+					linters = append(linters, linter)
+				}
+			}
+
+			pos := fset.Position(comment.Pos())
+			result.Unjustified = append(result.Unjustified, NolintDirective{
+				Linters: linters,
+				Comment: comment.Text,
+				Message: message,
+				Line:    pos.Line,
+				Column:  pos.Column,
+			})
+		}
+	}
+
+	result.Count = len(result.Unjustified)
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import "strings"
+
+// utf8BOM is the UTF-8 encoding of the byte order mark U+FEFF
+const utf8BOM = "\ufeff"
+
+// sourceInfo records what normalizeSource found in submitted code
+type sourceInfo struct {
+	bom  bool // The code started with a UTF-8 byte order mark, now stripped
+	crlf bool // Every line of the code ends in CRLF
+}
+
+// normalizeSource prepares submitted code for analysis. Every public function
+// taking source code calls it first. A leading UTF-8 byte order mark is
+// stripped: the scanner skips it but still counts its three bytes in the
+// columns of the first line, and offsets into the code would disagree with
+// positions from the parser. CRLF line endings are recorded but left in
+// place, since the scanner treats carriage returns as whitespace and strips
+// them from comments and raw strings.
+func normalizeSource(code string) (string, sourceInfo) {
+	var info sourceInfo
+	if stripped, ok := strings.CutPrefix(code, utf8BOM); ok {
+		code = stripped
+		info.bom = true
+	}
+	if lf := strings.Count(code, "\n"); lf > 0 && strings.Count(code, "\r\n") == lf {
+		info.crlf = true
+	}
+	return code, info
+}
+
+// restore converts output, such as that of gofmt, back to the byte order
+// mark and line endings of the submitted code. The output may mix LF lines
+// from gofmt with CRLF lines copied from the input.
+func (info sourceInfo) restore(out string) string {
+	if info.crlf {
+		out = strings.ReplaceAll(strings.ReplaceAll(out, "\r\n", "\n"), "\n", "\r\n")
+	}
+	if info.bom {
+		out = utf8BOM + out
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import "testing"
+
+func TestNormalizeSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      string
+		want      string
+		bom, crlf bool
+	}{
+		{name: "plain", code: "package p\n", want: "package p\n"},
+		{name: "BOM", code: utf8BOM + "package p\n", want: "package p\n", bom: true},
+		{name: "CRLF", code: "package p\r\n\r\nvar v int\r\n", want: "package p\r\n\r\nvar v int\r\n", crlf: true},
+		{name: "BOM and CRLF", code: utf8BOM + "package p\r\n", want: "package p\r\n", bom: true, crlf: true},
+		{name: "mixed endings", code: "package p\r\nvar v int\n", want: "package p\r\nvar v int\n"},
+		{name: "no newline", code: "package p", want: "package p"},
+		{name: "BOM inside", code: "package p\n// " + utf8BOM + "\n", want: "package p\n// " + utf8BOM + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, info := normalizeSource(tt.code)
+			if got != tt.want || info.bom != tt.bom || info.crlf != tt.crlf {
+				t.Errorf("normalizeSource = %q, bom %v, crlf %v; want %q, bom %v, crlf %v", got, info.bom, info.crlf, tt.want, tt.bom, tt.crlf)
+			}
+		})
+	}
+}
+
+func TestSourceInfoRestore(t *testing.T) {
+	tests := []struct {
+		name string
+		info sourceInfo
+		out  string
+		want string
+	}{
+		{name: "unchanged", out: "package p\n", want: "package p\n"},
+		{name: "BOM", info: sourceInfo{bom: true}, out: "package p\n", want: utf8BOM + "package p\n"},
+		{name: "CRLF", info: sourceInfo{crlf: true}, out: "package p\n\nvar v int\n", want: "package p\r\n\r\nvar v int\r\n"},
+		{name: "mixed output", info: sourceInfo{crlf: true}, out: "a\r\nb\n", want: "a\r\nb\r\n"},
+		{name: "both", info: sourceInfo{bom: true, crlf: true}, out: "package p\n", want: utf8BOM + "package p\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.restore(tt.out); got != tt.want {
+				t.Errorf("restore = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBOMAndCRLFAcrossTools(t *testing.T) {
+	const code = utf8BOM + "package p\r\n\r\nfunc  F() {}\r\n"
+
+	formatted, err := FormatCode(FormatCodeInput{Code: code})
+	if err != nil {
+		t.Fatalf("FormatCode: %v", err)
+	}
+	if want := utf8BOM + "package p\r\n\r\nfunc F() {}\r\n"; formatted.FormattedCode != want || !formatted.BOM || !formatted.CRLF {
+		t.Errorf("FormatCode = %q, bom %v, crlf %v; want %q with both kept", formatted.FormattedCode, formatted.BOM, formatted.CRLF, want)
+	}
+
+	ok, err := CheckFormatted(formatted.FormattedCode)
+	if err != nil || !ok {
+		t.Errorf("CheckFormatted of formatted code = %v, %v; want true", ok, err)
+	}
+
+	// Columns on the first line are not shifted by the byte order mark
+	symbols, err := GetSymbols(GetSymbolsInput{Code: utf8BOM + "package p; func F() {}", NoCache: true})
+	if err != nil || !symbols.Success {
+		t.Fatalf("GetSymbols: %v", err)
+	}
+	if len(symbols.Symbols) != 1 || symbols.Symbols[0].Column != 12 {
+		t.Errorf("symbols = %+v, want F at column 12", symbols.Symbols)
+	}
+}
@@ -0,0 +1,289 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// RenameSymbolInput represents the input for renaming a symbol
+type RenameSymbolInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code of a single file"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	OldName  string `json:"oldName" jsonschema:"Name of the symbol to rename"`
+	NewName  string `json:"newName" jsonschema:"New name for the symbol"`
+}
+
+// RenameSymbolOutput represents the result of renaming a symbol
+type RenameSymbolOutput struct {
+	Success     bool         `json:"success"`
+	Code        string       `json:"code,omitempty"` // The rewritten source
+	Kind        string       `json:"kind,omitempty"` // Kind of the renamed symbol, e.g. "func" or "var"
+	Line        int          `json:"line,omitempty"` // Line of the renamed declaration
+	Occurrences int          `json:"occurrences"`    // Identifiers renamed, including the declaration
+	Error       string       `json:"error,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// stubImporter satisfies every import with an empty package, so a single
+// file can be type checked without its dependencies. Selectors into imported
+// packages stay unresolved, which does not affect the file's own symbols.
+type stubImporter struct{}
+
+func (stubImporter) Import(path string) (*types.Package, error) {
+	pkg := types.NewPackage(path, defaultImportName(path))
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// RenameSymbol renames the symbol declared as oldName in code, together with
+// every identifier that refers to it, and returns the source printed with
+// go/printer. References are resolved with go/types, so a local that shadows
+// the symbol is left alone. When oldName is declared more than once, only a
+// package-level declaration is chosen. The rename fails when newName is not
+// a valid identifier, is already declared in the symbol's scope, or would
+// capture or be captured by another declaration at one of the references.
+func RenameSymbol(code, oldName, newName string) (*RenameSymbolOutput, error) {
+	code, info := normalizeSource(code)
+	if !token.IsIdentifier(newName) || newName == "_" {
+		return &RenameSymbolOutput{
+			Success: false,
+			Error:   fmt.Sprintf("%q is not a valid Go identifier", newName),
+		}, nil
+	}
+	if newName == oldName {
+		return &RenameSymbolOutput{
+			Success: false,
+			Error:   "newName is the same as oldName",
+		}, nil
+	}
+
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &RenameSymbolOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	typesInfo := &types.Info{
+		Defs:   map[*ast.Ident]types.Object{},
+		Uses:   map[*ast.Ident]types.Object{},
+		Scopes: map[ast.Node]*types.Scope{},
+	}
+	conf := types.Config{Importer: stubImporter{}, Error: func(error) {}}
+	pkg, _ := conf.Check(file.Name.Name, fset, []*ast.File{file}, typesInfo)
+
+	target, err := renameTarget(typesInfo, pkg, oldName, fset)
+	if err == nil {
+		err = checkRenameConflicts(typesInfo, pkg, target, newName, fset)
+	}
+	if err != nil {
+		return &RenameSymbolOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Embedded fields of a renamed type are named after it, so references
+	// to the fields are renamed too
+	renamed := map[types.Object]bool{target: true}
+	for ident, obj := range typesInfo.Defs {
+		if field, ok := obj.(*types.Var); ok && field.Embedded() && typesInfo.Uses[ident] == target {
+			renamed[field] = true
+		}
+	}
+
+	occurrences := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if renamed[typesInfo.Defs[ident]] || renamed[typesInfo.Uses[ident]] {
+			ident.Name = newName
+			occurrences++
+		}
+		return true
+	})
+
+	var buf bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("failed to print renamed code: %w", err)
+	}
+
+	return &RenameSymbolOutput{
+		Success:     true,
+		Code:        info.restore(buf.String()),
+		Kind:        objectKind(target),
+		Line:        fset.Position(target.Pos()).Line,
+		Occurrences: occurrences,
+	}, nil
+}
+
+// renameTarget finds the object declared as name. Embedded fields and
+// imports are not candidates; when several declarations remain, the one at
+// package level is chosen if there is exactly one.
+func renameTarget(typesInfo *types.Info, pkg *types.Package, name string, fset *token.FileSet) (types.Object, error) {
+	var candidates []types.Object
+	for ident, obj := range typesInfo.Defs {
+		if obj == nil || ident.Name != name {
+			continue
+		}
+		if v, ok := obj.(*types.Var); ok && v.Embedded() {
+			continue
+		}
+		candidates = append(candidates, obj)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no declaration of %q found", name)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Pos() < candidates[j].Pos() })
+
+	if len(candidates) > 1 {
+		var packageLevel []types.Object
+		var lines []string
+		for _, obj := range candidates {
+			if obj.Parent() == pkg.Scope() {
+				packageLevel = append(packageLevel, obj)
+			}
+			lines = append(lines, fmt.Sprint(fset.Position(obj.Pos()).Line))
+		}
+		if len(packageLevel) != 1 {
+			return nil, fmt.Errorf("%q is declared %d times (lines %s); only a name declared once, or once at package level, can be renamed",
+				name, len(candidates), strings.Join(lines, ", "))
+		}
+		candidates = packageLevel
+	}
+
+	if _, ok := candidates[0].(*types.PkgName); ok {
+		return nil, fmt.Errorf("%q is an import name; renaming imports is not supported", name)
+	}
+	return candidates[0], nil
+}
+
+// checkRenameConflicts reports an error if renaming target to newName would
+// change what any identifier refers to or declare newName twice in one scope
+func checkRenameConflicts(typesInfo *types.Info, pkg *types.Package, target types.Object, newName string, fset *token.FileSet) error {
+	conflict := func(pos token.Pos, what string) error {
+		return fmt.Errorf("renaming %s to %q conflicts with %s at line %d",
+			target.Name(), newName, what, fset.Position(pos).Line)
+	}
+
+	// Fields and methods are selected rather than resolved lexically, so
+	// only other fields and methods of the same type can collide
+	if target.Parent() == nil {
+		if owner := memberOwner(target, pkg); owner != nil {
+			if obj, _, _ := types.LookupFieldOrMethod(owner, true, pkg, newName); obj != nil {
+				return conflict(obj.Pos(), "the field or method "+newName)
+			}
+		}
+		return nil
+	}
+
+	if obj := target.Parent().Lookup(newName); obj != nil {
+		return conflict(obj.Pos(), "the existing declaration of "+newName)
+	}
+
+	for ident, obj := range typesInfo.Uses {
+		if obj == nil {
+			continue
+		}
+
+		// A reference to target must not resolve to another newName
+		// declared between it and target's scope
+		if obj == target {
+			scope := pkg.Scope().Innermost(ident.Pos())
+			if scope == nil {
+				continue
+			}
+			if s, other := scope.LookupParent(newName, ident.Pos()); other != nil && s != target.Parent() && scopeWithin(s, target.Parent()) {
+				return conflict(other.Pos(), "the declaration of "+newName)
+			}
+			continue
+		}
+
+		// A reference to an outer newName inside target's scope would
+		// resolve to target instead
+		if ident.Name != newName || obj.Parent() == nil || !scopeWithin(target.Parent(), obj.Parent()) || target.Parent() == obj.Parent() {
+			continue
+		}
+		if target.Parent() == pkg.Scope() || target.Parent().Contains(ident.Pos()) && ident.Pos() > target.Pos() {
+			return conflict(ident.Pos(), "a reference to "+newName)
+		}
+	}
+
+	// The file scope holds imports, which package-level names may not reuse
+	if target.Parent() == pkg.Scope() {
+		for _, fileScope := range typesInfo.Scopes {
+			if obj := fileScope.Lookup(newName); obj != nil && fileScope.Parent() == pkg.Scope() {
+				return conflict(obj.Pos(), "the import "+newName)
+			}
+		}
+	}
+	return nil
+}
+
+// scopeWithin reports whether scope is outer or nested inside it
+func scopeWithin(scope, outer *types.Scope) bool {
+	for s := scope; s != nil; s = s.Parent() {
+		if s == outer {
+			return true
+		}
+	}
+	return false
+}
+
+// memberOwner returns the named type declaring the field or method obj
+func memberOwner(obj types.Object, pkg *types.Package) types.Type {
+	if fn, ok := obj.(*types.Func); ok {
+		if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return sig.Recv().Type()
+		}
+		return nil
+	}
+	for _, name := range pkg.Scope().Names() {
+		typeName, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if st, ok := typeName.Type().Underlying().(*types.Struct); ok {
+			for i := range st.NumFields() {
+				if st.Field(i) == obj {
+					return typeName.Type()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// objectKind names the kind of a declared object
+func objectKind(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Func:
+		if sig, ok := obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.Var:
+		if obj.IsField() {
+			return "field"
+		}
+		return "var"
+	case *types.Const:
+		return "const"
+	case *types.TypeName:
+		return "type"
+	case *types.Label:
+		return "label"
+	}
+	return "symbol"
+}
@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultRiskTop is the number of functions RiskScore lists when no number is given
+const DefaultRiskTop = 5
+
+// Metric values at which a risk factor saturates at 1. Functions at or
+// beyond all of them score 100.
+const (
+	riskCyclomaticCap = 20
+	riskCognitiveCap  = 30
+	riskNestingCap    = 5
+	riskLengthCap     = 100 // Source lines of code
+	riskParamsCap     = 7
+)
+
+// riskFactorNames lists the risk factors in the order ties are broken in
+var riskFactorNames = []string{"cyclomatic", "cognitive", "nesting", "length", "params"}
+
+// defaultRiskWeights weigh the risk factors when no weights are given
+var defaultRiskWeights = map[string]float64{
+	"cyclomatic": 0.25,
+	"cognitive":  0.30,
+	"nesting":    0.15,
+	"length":     0.20,
+	"params":     0.10,
+}
+
+// RiskWeights are the relative weights of the risk factors. Unset weights
+// keep their defaults; weights are normalized by their sum.
+type RiskWeights struct {
+	Cyclomatic *float64 `json:"cyclomatic,omitempty" jsonschema:"Weight of cyclomatic complexity (default: 0.25)"`
+	Cognitive  *float64 `json:"cognitive,omitempty" jsonschema:"Weight of cognitive complexity (default: 0.3)"`
+	Nesting    *float64 `json:"nesting,omitempty" jsonschema:"Weight of the maximum nesting depth (default: 0.15)"`
+	Length     *float64 `json:"length,omitempty" jsonschema:"Weight of the source lines of code (default: 0.2)"`
+	Params     *float64 `json:"params,omitempty" jsonschema:"Weight of the parameter count (default: 0.1)"`
+}
+
+// RiskScoreInput represents the input for risk scoring
+type RiskScoreInput struct {
+	Code     string      `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string      `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Weights  RiskWeights `json:"weights,omitempty" jsonschema:"Optional weights of the risk factors"`
+	Top      int         `json:"top,omitempty" jsonschema:"Number of highest-risk functions to return (default: 5)"`
+}
+
+// RiskScoreOutput represents the highest-risk functions of a file
+type RiskScoreOutput struct {
+	Success       bool               `json:"success"`
+	Functions     []FunctionRisk     `json:"functions"`      // Highest risk first, at most Top of them
+	FunctionCount int                `json:"function_count"` // Functions scored
+	Weights       map[string]float64 `json:"weights"`        // The weights applied, normalized to sum to 1
+	Error         string             `json:"error,omitempty"`
+	Diagnostics   []Diagnostic       `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// FunctionRisk represents the risk score of one function and its inputs
+type FunctionRisk struct {
+	Name                 string  `json:"name"`
+	Receiver             string  `json:"receiver,omitempty"` // Receiver type name, for methods
+	Line                 int     `json:"line"`
+	Score                float64 `json:"score"`       // 0-100, higher is riskier
+	MainFactor           string  `json:"main_factor"` // Factor contributing most to the score
+	CyclomaticComplexity int     `json:"cyclomatic_complexity"`
+	CognitiveComplexity  int     `json:"cognitive_complexity"`
+	MaxNestingDepth      int     `json:"max_nesting_depth"`
+	SourceLinesOfCode    int     `json:"source_lines_of_code"`
+	ParameterCount       int     `json:"parameter_count"`
+}
+
+// RiskScore ranks the functions of code by a composite risk score. Each
+// factor (cyclomatic and cognitive complexity, nesting depth, source lines
+// and parameter count) is scaled to 0-1 by dividing it by the value at which
+// it saturates, and the score is the weighted mean of the factors times 100.
+func RiskScore(input RiskScoreInput) (*RiskScoreOutput, error) {
+	weights, err := riskWeights(input.Weights)
+	if err == nil && input.Top < 0 {
+		err = fmt.Errorf("top must not be negative")
+	}
+	if err != nil {
+		return &RiskScoreOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	top := input.Top
+	if top == 0 {
+		top = DefaultRiskTop
+	}
+
+	metrics, err := CalculateMetrics(CalculateMetricsInput{Code: input.Code, FilePath: input.FilePath})
+	if err != nil {
+		return nil, err
+	}
+	if !metrics.Success {
+		return &RiskScoreOutput{
+			Success:     false,
+			Error:       metrics.Error,
+			Diagnostics: metrics.Diagnostics,
+		}, nil
+	}
+
+	risks := make([]FunctionRisk, 0, len(metrics.FunctionMetrics))
+	for _, fm := range metrics.FunctionMetrics {
+		factors := map[string]float64{
+			"cyclomatic": riskFactor(fm.CyclomaticComplexity, riskCyclomaticCap),
+			"cognitive":  riskFactor(fm.CognitiveComplexity, riskCognitiveCap),
+			"nesting":    riskFactor(fm.MaxNestingDepth, riskNestingCap),
+			"length":     riskFactor(fm.SourceLinesOfCode, riskLengthCap),
+			"params":     riskFactor(fm.ParameterCount, riskParamsCap),
+		}
+		score, mainFactor, mainContribution := 0.0, "", 0.0
+		for _, name := range riskFactorNames {
+			contribution := weights[name] * factors[name]
+			score += contribution
+			if contribution > mainContribution {
+				mainFactor, mainContribution = name, contribution
+			}
+		}
+		if mainFactor == "" {
+			mainFactor = "none"
+		}
+
+		risks = append(risks, FunctionRisk{
+			Name:                 fm.Name,
+			Receiver:             fm.Receiver,
+			Line:                 fm.Line,
+			Score:                score * 100,
+			MainFactor:           mainFactor,
+			CyclomaticComplexity: fm.CyclomaticComplexity,
+			CognitiveComplexity:  fm.CognitiveComplexity,
+			MaxNestingDepth:      fm.MaxNestingDepth,
+			SourceLinesOfCode:    fm.SourceLinesOfCode,
+			ParameterCount:       fm.ParameterCount,
+		})
+	}
+
+	sort.SliceStable(risks, func(i, j int) bool {
+		return risks[i].Score > risks[j].Score
+	})
+	return &RiskScoreOutput{
+		Success:       true,
+		Functions:     risks[:min(top, len(risks))],
+		FunctionCount: len(risks),
+		Weights:       weights,
+	}, nil
+}
+
+// riskWeights applies the given weights over the defaults and normalizes
+// them to sum to 1
+func riskWeights(given RiskWeights) (map[string]float64, error) {
+	weights := map[string]float64{}
+	for name, weight := range defaultRiskWeights {
+		weights[name] = weight
+	}
+	for name, weight := range map[string]*float64{
+		"cyclomatic": given.Cyclomatic,
+		"cognitive":  given.Cognitive,
+		"nesting":    given.Nesting,
+		"length":     given.Length,
+		"params":     given.Params,
+	} {
+		if weight == nil {
+			continue
+		}
+		if *weight < 0 {
+			return nil, fmt.Errorf("weight %s must not be negative", name)
+		}
+		weights[name] = *weight
+	}
+
+	sum := 0.0
+	for _, weight := range weights {
+		sum += weight
+	}
+	if sum == 0 {
+		return nil, fmt.Errorf("at least one weight must be positive")
+	}
+	for name := range weights {
+		weights[name] /= sum
+	}
+	return weights, nil
+}
+
+// riskFactor scales value to 0-1, saturating at limit
+func riskFactor(value, limit int) float64 {
+	return min(float64(value)/float64(limit), 1)
+}
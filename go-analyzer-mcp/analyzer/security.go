@@ -0,0 +1,435 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ScanSecurityInput represents the input for a security scan
+type ScanSecurityInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to scan"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// ScanSecurityOutput represents the result of a security scan
+type ScanSecurityOutput struct {
+	Success     bool              `json:"success"`
+	Findings    []SecurityFinding `json:"findings"`
+	Count       int               `json:"count"`
+	Error       string            `json:"error,omitempty"`
+	Diagnostics []Diagnostic      `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// SecurityFinding represents an insecure pattern found by ScanSecurity
+type SecurityFinding struct {
+	RuleID   string `json:"rule_id"`  // The matching gosec rule, e.g. "G101"
+	Severity string `json:"severity"` // "high" or "medium"
+	Message  string `json:"message"`
+	Function string `json:"function,omitempty"` // Enclosing function declaration
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// securityRule is one check of ScanSecurity. Check is called for every node
+// of the file and reports each insecure pattern it finds; the finding gets the
+// rule's ID and severity. Checks are added by appending to securityRules.
+type securityRule struct {
+	ID       string // The matching gosec rule, e.g. "G101"
+	Severity string // "high" or "medium"
+	Check    func(scan *securityScan, n ast.Node, report func(pos token.Pos, message string))
+}
+
+// securityRules are the checks run by ScanSecurity
+var securityRules = []securityRule{
+	{ID: "G101", Severity: "high", Check: checkHardcodedCredentials},
+	{ID: "G114", Severity: "medium", Check: checkTimeoutlessServe},
+	{ID: "G204", Severity: "medium", Check: checkSubprocessArgs},
+	{ID: "G401", Severity: "medium", Check: checkWeakHashUse},
+	{ID: "G404", Severity: "high", Check: checkWeakRandom},
+	{ID: "G501", Severity: "medium", Check: weakHashImportCheck("crypto/md5")},
+	{ID: "G505", Severity: "medium", Check: weakHashImportCheck("crypto/sha1")},
+}
+
+// securityScan is the state shared by the rules during one scan
+type securityScan struct {
+	file  *ast.File
+	stack []ast.Node // Ancestors of the node being checked, ending with the node
+}
+
+// importName returns the name the scanned file imports path under, or ""
+func (s *securityScan) importName(path string) string {
+	return importName(s.file, path)
+}
+
+// packageCall returns the name of the function called by n when it is a call
+// to a function of the package imported from path, or "" otherwise
+func (s *securityScan) packageCall(n ast.Node, path string) string {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || !isPackageIdent(sel.X, s.importName(path)) {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+// weakHashPackages are the hash packages too weak for security-related use
+var weakHashPackages = []string{"crypto/md5", "crypto/sha1"}
+
+// credentialWords are name words that mark a variable as holding a credential
+var credentialWords = map[string]bool{
+	"password":    true,
+	"passwd":      true,
+	"pwd":         true,
+	"secret":      true,
+	"token":       true,
+	"apikey":      true,
+	"credential":  true,
+	"credentials": true,
+	"bearer":      true,
+}
+
+// keyQualifiers are name words that make a following "key" a credential, as
+// in apiKey or privateKey
+var keyQualifiers = map[string]bool{
+	"api":        true,
+	"access":     true,
+	"private":    true,
+	"secret":     true,
+	"signing":    true,
+	"encryption": true,
+}
+
+// sensitiveWords are name words that make a random value security-sensitive
+var sensitiveWords = map[string]bool{
+	"password":   true,
+	"passwd":     true,
+	"secret":     true,
+	"token":      true,
+	"key":        true,
+	"apikey":     true,
+	"nonce":      true,
+	"salt":       true,
+	"session":    true,
+	"otp":        true,
+	"auth":       true,
+	"credential": true,
+	"csrf":       true,
+	"iv":         true,
+}
+
+// timeoutlessServeFuncs are the net/http functions that serve with a server
+// that has no timeouts
+var timeoutlessServeFuncs = map[string]bool{
+	"ListenAndServe":    true,
+	"ListenAndServeTLS": true,
+	"Serve":             true,
+	"ServeTLS":          true,
+}
+
+// ScanSecurity flags a few common insecure patterns that can be found from
+// the syntax tree alone, using gosec's rule IDs:
+//
+//   - G101: string literals assigned to variables, constants and fields named
+//     like credentials, such as password, secret, token or apiKey
+//   - G114: http.ListenAndServe and the other net/http functions that serve
+//     without timeouts
+//   - G204: exec.Command arguments that are not constant, such as variables
+//     or strings built by concatenation or fmt.Sprintf
+//   - G401: hashing with crypto/md5 or crypto/sha1
+//   - G404: math/rand used for a value named like a token, key, nonce or
+//     similar, or inside a function named that way
+//   - G501, G505: imports of crypto/md5 and crypto/sha1
+//
+// It is no substitute for gosec, but needs nothing beyond the parser.
+func ScanSecurity(code string) (*ScanSecurityOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &ScanSecurityOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	findings := []SecurityFinding{}
+	scan := &securityScan{file: file}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			scan.stack = scan.stack[:len(scan.stack)-1]
+			return true
+		}
+		scan.stack = append(scan.stack, n)
+
+		for _, rule := range securityRules {
+			rule.Check(scan, n, func(pos token.Pos, message string) {
+				position := fset.Position(pos)
+				findings = append(findings, SecurityFinding{
+					RuleID:   rule.ID,
+					Severity: rule.Severity,
+					Message:  message,
+					Function: enclosingFuncName(file, pos),
+					Line:     position.Line,
+					Column:   position.Column,
+				})
+			})
+		}
+		return true
+	})
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return &ScanSecurityOutput{
+		Success:  true,
+		Findings: findings,
+		Count:    len(findings),
+	}, nil
+}
+
+// checkHardcodedCredentials implements G101 for assignments, declarations and
+// composite literal fields
+func checkHardcodedCredentials(scan *securityScan, n ast.Node, report func(token.Pos, string)) {
+	switch node := n.(type) {
+	case *ast.AssignStmt:
+		if len(node.Lhs) == len(node.Rhs) {
+			for i, lhs := range node.Lhs {
+				checkHardcodedCredential(assignedName(lhs), node.Rhs[i], report)
+			}
+		}
+
+	case *ast.ValueSpec:
+		for i, name := range node.Names {
+			if i < len(node.Values) {
+				checkHardcodedCredential(name.Name, node.Values[i], report)
+			}
+		}
+
+	case *ast.KeyValueExpr:
+		if key, ok := node.Key.(*ast.Ident); ok {
+			checkHardcodedCredential(key.Name, node.Value, report)
+		}
+	}
+}
+
+// checkTimeoutlessServe implements G114
+func checkTimeoutlessServe(scan *securityScan, n ast.Node, report func(token.Pos, string)) {
+	if name := scan.packageCall(n, "net/http"); timeoutlessServeFuncs[name] {
+		report(n.Pos(), fmt.Sprintf("http.%s uses a server without timeouts; use an http.Server with ReadHeaderTimeout and other timeouts set", name))
+	}
+}
+
+// checkSubprocessArgs implements G204, reporting the first argument of
+// exec.Command or exec.CommandContext that is not constant
+func checkSubprocessArgs(scan *securityScan, n ast.Node, report func(token.Pos, string)) {
+	name := scan.packageCall(n, "os/exec")
+	if name != "Command" && name != "CommandContext" {
+		return
+	}
+	args := n.(*ast.CallExpr).Args
+	if name == "CommandContext" && len(args) > 0 {
+		args = args[1:]
+	}
+	for _, arg := range args {
+		if isBuiltString(arg, scan.importName("fmt")) {
+			report(arg.Pos(), fmt.Sprintf("exec.%s argument is built from strings; pass each argument separately and validate untrusted input", name))
+			return
+		}
+		if !isConstantString(arg) {
+			report(arg.Pos(), fmt.Sprintf("exec.%s runs a command with a variable argument; validate it if it can come from untrusted input", name))
+			return
+		}
+	}
+}
+
+// checkWeakHashUse implements G401 for calls into crypto/md5 and crypto/sha1
+func checkWeakHashUse(scan *securityScan, n ast.Node, report func(token.Pos, string)) {
+	for _, path := range weakHashPackages {
+		if name := scan.packageCall(n, path); name != "" {
+			report(n.Pos(), fmt.Sprintf("%s.%s uses a weak hash; use crypto/sha256 or stronger for anything security-related", defaultImportName(path), name))
+		}
+	}
+}
+
+// checkWeakRandom implements G404 for math/rand calls in a sensitive context
+func checkWeakRandom(scan *securityScan, n ast.Node, report func(token.Pos, string)) {
+	for _, path := range []string{"math/rand", "math/rand/v2"} {
+		if scan.packageCall(n, path) == "" {
+			continue
+		}
+		if name := sensitiveContextName(scan.stack); name != "" {
+			report(n.Pos(), fmt.Sprintf("math/rand is not cryptographically secure; use crypto/rand for %s", name))
+		}
+	}
+}
+
+// weakHashImportCheck returns a check reporting imports of the weak hash
+// package path, for G501 and G505
+func weakHashImportCheck(path string) func(*securityScan, ast.Node, func(token.Pos, string)) {
+	return func(scan *securityScan, n ast.Node, report func(token.Pos, string)) {
+		imp, ok := n.(*ast.ImportSpec)
+		if !ok {
+			return
+		}
+		if importPath, err := strconv.Unquote(imp.Path.Value); err == nil && importPath == path {
+			report(imp.Pos(), fmt.Sprintf("%s is a weak hash; use crypto/sha256 or stronger for anything security-related", path))
+		}
+	}
+}
+
+// checkHardcodedCredential reports value when it is a non-empty string literal
+// and name looks like a credential
+func checkHardcodedCredential(name string, value ast.Expr, report func(token.Pos, string)) {
+	lit, ok := ast.Unparen(value).(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING || !isCredentialName(name) {
+		return
+	}
+	if text, err := strconv.Unquote(lit.Value); err != nil || text == "" {
+		return
+	}
+	report(lit.Pos(), fmt.Sprintf("%s looks like a hardcoded credential; load it from the environment or a secret store", name))
+}
+
+// assignedName returns the name assigned to by an assignment target: the
+// variable, or the field of a selector
+func assignedName(expr ast.Expr) string {
+	switch target := expr.(type) {
+	case *ast.Ident:
+		return target.Name
+	case *ast.SelectorExpr:
+		return target.Sel.Name
+	}
+	return ""
+}
+
+// isCredentialName reports whether the last word of name names a credential,
+// so dbPassword and apiKey match but tokenURL and passwordPrompt do not
+func isCredentialName(name string) bool {
+	words := nameWords(name)
+	if len(words) == 0 {
+		return false
+	}
+	last := words[len(words)-1]
+	if credentialWords[last] {
+		return true
+	}
+	return last == "key" && len(words) > 1 && keyQualifiers[words[len(words)-2]]
+}
+
+// sensitiveContextName returns the name that makes the innermost node of stack
+// security-sensitive: the variable or field its value is assigned to, or the
+// function declaration it is in, when named like a token, key or other
+// secret. It returns "" when there is none.
+func sensitiveContextName(stack []ast.Node) string {
+	var names []string
+	assigned := false
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch node := stack[i].(type) {
+		case *ast.AssignStmt:
+			if !assigned {
+				for _, lhs := range node.Lhs {
+					names = append(names, assignedName(lhs))
+				}
+			}
+			assigned = true
+		case *ast.ValueSpec:
+			if !assigned {
+				for _, name := range node.Names {
+					names = append(names, name.Name)
+				}
+			}
+			assigned = true
+		case *ast.KeyValueExpr:
+			if key, ok := node.Key.(*ast.Ident); ok && !assigned {
+				names = append(names, key.Name)
+			}
+			assigned = true
+		case *ast.BlockStmt:
+			// Assignments further out do not receive the value
+			assigned = true
+		case *ast.FuncDecl:
+			names = append(names, node.Name.Name)
+		}
+	}
+	for _, name := range names {
+		for _, word := range nameWords(name) {
+			if sensitiveWords[word] {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// isBuiltString reports whether expr builds a string at run time, by
+// concatenation involving more than literals or by fmt.Sprintf
+func isBuiltString(expr ast.Expr, fmtName string) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && !isLiteralString(e)
+	case *ast.CallExpr:
+		sel, ok := ast.Unparen(e.Fun).(*ast.SelectorExpr)
+		return ok && isPackageIdent(sel.X, fmtName) && sel.Sel.Name == "Sprintf"
+	}
+	return false
+}
+
+// isConstantString reports whether expr is a string literal, a concatenation
+// of them, or a constant declared in the file
+func isConstantString(expr ast.Expr) bool {
+	if ident, ok := ast.Unparen(expr).(*ast.Ident); ok {
+		return ident.Obj != nil && ident.Obj.Kind == ast.Con
+	}
+	return isLiteralString(expr)
+}
+
+// isLiteralString reports whether expr is a string literal or a concatenation
+// of string literals
+func isLiteralString(expr ast.Expr) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.STRING
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && isLiteralString(e.X) && isLiteralString(e.Y)
+	}
+	return false
+}
+
+// nameWords splits a MixedCaps or snake_case name into lower-case words,
+// treating a run of capitals as one word: "APIKey" gives "api", "key"
+func nameWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			words = append(words, strings.ToLower(string(runes[start:end])))
+		}
+		start = end
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			flush(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r) &&
+			(!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])):
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return words
+}
@@ -0,0 +1,155 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// ruleIDs returns the rule IDs of the findings in code, in order
+func ruleIDs(t *testing.T, code string) []string {
+	t.Helper()
+	result, err := ScanSecurity(code)
+	if err != nil {
+		t.Fatalf("ScanSecurity: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("ScanSecurity failed: %s", result.Error)
+	}
+	ids := []string{}
+	for _, finding := range result.Findings {
+		ids = append(ids, finding.RuleID)
+	}
+	return ids
+}
+
+func TestScanSecurityExecCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		call string
+		want []string
+	}{
+		{name: "constant arguments", call: `exec.Command("ls", "-l")`, want: []string{}},
+		{name: "named constant", call: `exec.Command(tool, "-l")`, want: []string{}},
+		{name: "variable program", call: `exec.Command(name)`, want: []string{"G204"}},
+		{name: "variable argument", call: `exec.Command("ls", name)`, want: []string{"G204"}},
+		{name: "concatenation", call: `exec.Command("sh", "-c", "ls "+name)`, want: []string{"G204"}},
+		{name: "Sprintf", call: `exec.Command("sh", "-c", fmt.Sprintf("ls %s", name))`, want: []string{"G204"}},
+		{name: "CommandContext", call: `exec.CommandContext(ctx, name)`, want: []string{"G204"}},
+		{name: "constant CommandContext", call: `exec.CommandContext(ctx, "ls")`, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := fmt.Sprintf(`package p
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+const tool = "ls"
+
+var _ = fmt.Sprint
+
+func run(ctx context.Context, name string) {
+	_ = %s
+}
+`, tt.call)
+			if got := ruleIDs(t, code); !slices.Equal(got, tt.want) {
+				t.Errorf("rule IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanSecurityWeakHash(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "md5 sum",
+			code: "package p\n\nimport \"crypto/md5\"\n\nfunc h(b []byte) { md5.Sum(b) }\n",
+			want: []string{"G501", "G401"},
+		},
+		{
+			name: "sha1 new",
+			code: "package p\n\nimport \"crypto/sha1\"\n\nfunc h() { sha1.New() }\n",
+			want: []string{"G505", "G401"},
+		},
+		{
+			name: "renamed import",
+			code: "package p\n\nimport weak \"crypto/md5\"\n\nfunc h(b []byte) { weak.Sum(b) }\n",
+			want: []string{"G501", "G401"},
+		},
+		{
+			name: "sha256",
+			code: "package p\n\nimport \"crypto/sha256\"\n\nfunc h(b []byte) { sha256.Sum256(b) }\n",
+			want: []string{},
+		},
+		{
+			name: "local md5 value",
+			code: "package p\n\ntype hasher struct{}\n\nfunc (hasher) Sum() {}\n\nfunc h(md5 hasher) { md5.Sum() }\n",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleIDs(t, tt.code); !slices.Equal(got, tt.want) {
+				t.Errorf("rule IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanSecurityOtherRules(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{name: "credential", code: "package p\n\nvar dbPassword = \"hunter2\"\n", want: []string{"G101"}},
+		{name: "empty credential", code: "package p\n\nvar dbPassword = \"\"\n", want: []string{}},
+		{name: "not a credential", code: "package p\n\nvar tokenURL = \"https://example.com\"\n", want: []string{}},
+		{
+			name: "server without timeouts",
+			code: "package p\n\nimport \"net/http\"\n\nfunc serve() { http.ListenAndServe(\":80\", nil) }\n",
+			want: []string{"G114"},
+		},
+		{
+			name: "math/rand token",
+			code: "package p\n\nimport \"math/rand\"\n\nfunc f() { sessionToken := rand.Int63(); _ = sessionToken }\n",
+			want: []string{"G404"},
+		},
+		{
+			name: "math/rand elsewhere",
+			code: "package p\n\nimport \"math/rand\"\n\nfunc shuffle() { jitter := rand.Intn(10); _ = jitter }\n",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleIDs(t, tt.code); !slices.Equal(got, tt.want) {
+				t.Errorf("rule IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityRulesRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, rule := range securityRules {
+		if seen[rule.ID] {
+			t.Errorf("rule %s registered twice", rule.ID)
+		}
+		seen[rule.ID] = true
+		if rule.Severity != "high" && rule.Severity != "medium" {
+			t.Errorf("rule %s has severity %q", rule.ID, rule.Severity)
+		}
+		if rule.Check == nil {
+			t.Errorf("rule %s has no check", rule.ID)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// FindShadowedVariablesInput represents the input for shadowed variable detection
+type FindShadowedVariablesInput struct {
+	Code      string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath  string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	IgnoreErr bool   `json:"ignoreErr,omitempty" jsonschema:"Do not report variables named err, which are routinely redeclared with :="`
+}
+
+// ShadowOutput represents the result of shadowed variable detection
+type ShadowOutput struct {
+	Success     bool               `json:"success"`
+	Shadows     []ShadowedVariable `json:"shadows"`
+	Count       int                `json:"count"`
+	Error       string             `json:"error,omitempty"`
+	Diagnostics []Diagnostic       `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// ShadowedVariable represents a local declaration hiding an outer one of the
+// same name
+type ShadowedVariable struct {
+	Name         string `json:"name"`
+	Function     string `json:"function,omitempty"` // Enclosing function declaration
+	Message      string `json:"message"`
+	Line         int    `json:"line"` // Position of the inner declaration
+	Column       int    `json:"column"`
+	ShadowedKind string `json:"shadowed_kind"` // "var", "const", "func", "type" or "import"
+	ShadowedLine int    `json:"shadowed_line"` // Line of the outer declaration
+	PackageLevel bool   `json:"package_level"` // Whether the outer declaration is at package or file level
+}
+
+// FindShadowedVariables flags local variables, including parameters and
+// results, that shadow a variable of an enclosing scope or a package-level
+// or imported name. Scopes are resolved with go/types. Copies such as
+// x := x, which shadow on purpose, are not reported, and neither are
+// variables named err when ignoreErr is set.
+func FindShadowedVariables(code string, ignoreErr bool) (*ShadowOutput, error) {
+	return findShadows(code, ignoreErr, false)
+}
+
+// findShadows implements FindShadowedVariables. With declaredOnly, only
+// variables declared by := or var are reported, leaving out parameters and
+// results.
+func findShadows(code string, ignoreErr, declaredOnly bool) (*ShadowOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &ShadowOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	typesInfo := &types.Info{
+		Defs: map[*ast.Ident]types.Object{},
+		Uses: map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Importer: stubImporter{}, Error: func(error) {}}
+	pkg, _ := conf.Check(file.Name.Name, fset, []*ast.File{file}, typesInfo)
+
+	// Declared identifiers initialized from a plain identifier, to recognize
+	// deliberate copies, and every identifier declared by := or var
+	copies := map[*ast.Ident]*ast.Ident{}
+	declared := map[*ast.Ident]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		var lhs []*ast.Ident
+		var rhs []ast.Expr
+		switch decl := n.(type) {
+		case *ast.AssignStmt:
+			if decl.Tok != token.DEFINE {
+				return true
+			}
+			for _, expr := range decl.Lhs {
+				ident, _ := expr.(*ast.Ident)
+				lhs = append(lhs, ident)
+				declared[ident] = true
+			}
+			rhs = decl.Rhs
+		case *ast.RangeStmt:
+			if decl.Tok == token.DEFINE {
+				for _, expr := range []ast.Expr{decl.Key, decl.Value} {
+					if ident, ok := expr.(*ast.Ident); ok {
+						declared[ident] = true
+					}
+				}
+			}
+			return true
+		case *ast.ValueSpec:
+			lhs, rhs = decl.Names, decl.Values
+			for _, ident := range lhs {
+				declared[ident] = true
+			}
+		default:
+			return true
+		}
+		if len(lhs) != len(rhs) {
+			return true
+		}
+		for i, ident := range lhs {
+			if value, ok := rhs[i].(*ast.Ident); ok && ident != nil {
+				copies[ident] = value
+			}
+		}
+		return true
+	})
+
+	result := &ShadowOutput{
+		Success: true,
+		Shadows: []ShadowedVariable{},
+	}
+	for ident, obj := range typesInfo.Defs {
+		v, ok := obj.(*types.Var)
+		if !ok || v.IsField() || v.Name() == "_" || v.Parent() == nil || v.Parent() == pkg.Scope() {
+			continue
+		}
+		if ignoreErr && v.Name() == "err" || declaredOnly && !declared[ident] {
+			continue
+		}
+
+		_, outer := v.Parent().Parent().LookupParent(v.Name(), v.Pos())
+		if outer == nil || outer.Parent() == types.Universe {
+			continue
+		}
+		if value, ok := copies[ident]; ok && typesInfo.Uses[value] == outer {
+			continue
+		}
+		switch outer.(type) {
+		case *types.Var, *types.Const, *types.Func, *types.TypeName, *types.PkgName:
+		default:
+			continue
+		}
+
+		kind := objectKind(outer)
+		if _, ok := outer.(*types.PkgName); ok {
+			kind = "import"
+		}
+		packageLevel := outer.Parent() == pkg.Scope() || outer.Parent().Parent() == pkg.Scope()
+		pos := fset.Position(ident.Pos())
+		outerLine := fset.Position(outer.Pos()).Line
+		result.Shadows = append(result.Shadows, ShadowedVariable{
+			Name:         v.Name(),
+			Function:     enclosingFuncName(file, ident.Pos()),
+			Message:      fmt.Sprintf("declaration of %s shadows the %s declared at line %d", v.Name(), kind, outerLine),
+			Line:         pos.Line,
+			Column:       pos.Column,
+			ShadowedKind: kind,
+			ShadowedLine: outerLine,
+			PackageLevel: packageLevel,
+		})
+	}
+
+	sort.Slice(result.Shadows, func(i, j int) bool {
+		a, b := result.Shadows[i], result.Shadows[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	result.Count = len(result.Shadows)
+	return result, nil
+}
+
+// FindShadowedInput represents the input for find_shadowed
+type FindShadowedInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// FindShadowedOutput represents the result of FindShadowed
+type FindShadowedOutput struct {
+	Success     bool         `json:"success"`
+	Shadows     []Shadow     `json:"shadows"`
+	Count       int          `json:"count"`
+	Error       string       `json:"error,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// Shadow represents a := or var declaration hiding a name of an enclosing
+// scope
+type Shadow struct {
+	Name           string `json:"name"`
+	Line           int    `json:"line"`           // Line of the inner declaration
+	ShadowedAtLine int    `json:"shadowedAtLine"` // Line of the declaration it hides
+}
+
+// FindShadowed is the compact form of FindShadowedVariables: it reports only
+// variables declared with := or var, not parameters and results, and gives
+// just the name and the two lines of each. Declarations of the same name in
+// sibling scopes do not shadow each other and are not reported.
+func FindShadowed(code string) (*FindShadowedOutput, error) {
+	shadows, err := findShadows(code, false, true)
+	if err != nil {
+		return nil, err
+	}
+	if !shadows.Success {
+		return &FindShadowedOutput{
+			Success:     false,
+			Error:       shadows.Error,
+			Diagnostics: shadows.Diagnostics,
+		}, nil
+	}
+
+	result := &FindShadowedOutput{
+		Success: true,
+		Shadows: make([]Shadow, 0, len(shadows.Shadows)),
+		Count:   shadows.Count,
+	}
+	for _, s := range shadows.Shadows {
+		result.Shadows = append(result.Shadows, Shadow{
+			Name:           s.Name,
+			Line:           s.Line,
+			ShadowedAtLine: s.ShadowedLine,
+		})
+	}
+	return result, nil
+}
+
+// enclosingFuncName returns the name of the function declaration containing
+// pos, or "" when there is none
+func enclosingFuncName(file *ast.File, pos token.Pos) string {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= pos && pos < fn.End() {
+			return fn.Name.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindShadowed(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []Shadow
+	}{
+		{
+			name: "package-level variable",
+			code: "package p\n\nvar x = 1\n\nfunc f() {\n\tx := 2\n\t_ = x\n}\n",
+			want: []Shadow{{Name: "x", Line: 6, ShadowedAtLine: 3}},
+		},
+		{
+			name: "outer block",
+			code: "package p\n\nfunc f() {\n\tx := 1\n\tif x > 0 {\n\t\tvar x int\n\t\t_ = x\n\t}\n}\n",
+			want: []Shadow{{Name: "x", Line: 6, ShadowedAtLine: 4}},
+		},
+		{
+			name: "parameter",
+			code: "package p\n\nfunc f(n int) {\n\tfor n := range 3 {\n\t\t_ = n\n\t}\n}\n",
+			want: []Shadow{{Name: "n", Line: 4, ShadowedAtLine: 3}},
+		},
+		{
+			name: "sibling scopes",
+			code: "package p\n\nfunc f() {\n\tif true {\n\t\tx := 1\n\t\t_ = x\n\t}\n\tif true {\n\t\tx := 2\n\t\t_ = x\n\t}\n}\n",
+			want: []Shadow{},
+		},
+		{
+			name: "parameter shadowing package name",
+			code: "package p\n\nvar x = 1\n\nfunc f(x int) {}\n",
+			want: []Shadow{},
+		},
+		{
+			name: "deliberate copy",
+			code: "package p\n\nfunc f(x int) {\n\tfunc() {\n\t\tx := x\n\t\t_ = x\n\t}()\n}\n",
+			want: []Shadow{},
+		},
+		{
+			name: "err",
+			code: "package p\n\nfunc g() error { return nil }\n\nfunc f() {\n\terr := g()\n\tif err := g(); err != nil {\n\t}\n\t_ = err\n}\n",
+			want: []Shadow{{Name: "err", Line: 7, ShadowedAtLine: 6}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FindShadowed(tt.code)
+			if err != nil {
+				t.Fatalf("FindShadowed: %v", err)
+			}
+			if !result.Success {
+				t.Fatalf("FindShadowed failed: %s", result.Error)
+			}
+			if !slices.Equal(result.Shadows, tt.want) || result.Count != len(tt.want) {
+				t.Errorf("Shadows = %+v (count %d), want %+v", result.Shadows, result.Count, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindShadowedSyntaxError(t *testing.T) {
+	result, err := FindShadowed("package p\nfunc {")
+	if err != nil {
+		t.Fatalf("FindShadowed: %v", err)
+	}
+	if result.Success || len(result.Diagnostics) == 0 {
+		t.Errorf("FindShadowed = %+v, want a failure with diagnostics", result)
+	}
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SourceRoot is the directory that filePath, path and directory inputs are
+// resolved against and must stay within. Servers set it at startup; when it is empty the working
+// directory is used.
+var SourceRoot string
+
+// NoPathInputs rejects the filePath, path and directory inputs of every tool.
+// Servers reachable over the network set it, so that their clients cannot
+// read files on the server's disk.
+var NoPathInputs bool
+
+// ErrPathInputsDisabled is returned for a filePath, path or directory input
+// while NoPathInputs is set
+var ErrPathInputsDisabled = errors.New("not supported by this server")
+
+// LoadSource returns code, or the contents of the file at filePath when code
+// is empty, so tools can take either. Relative paths are resolved against
+// SourceRoot. Paths leading outside SourceRoot, including through symbolic
+// links, are rejected. With neither set, it returns "" and no error.
+func LoadSource(code, filePath string) (string, error) {
+	if code != "" || filePath == "" {
+		return code, nil
+	}
+
+	path, err := resolveSourcePath("filePath", filePath)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read filePath %s: %w", filePath, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("filePath %s is a directory, not a Go file", filePath)
+	}
+	if MaxInputSize > 0 && info.Size() > int64(MaxInputSize) {
+		return "", fmt.Errorf("%w: filePath %s is %d bytes, exceeding the limit of %d bytes", ErrInputTooLarge, filePath, info.Size(), MaxInputSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read filePath %s: %w", filePath, err)
+	}
+	return string(data), nil
+}
+
+// loadInputFile is LoadSource for inputs that also name their file: when the
+// code is read from filePath and fileName is unset, the file's base name is
+// returned as the file name
+func loadInputFile(code, fileName, filePath string) (string, string, error) {
+	if code != "" || filePath == "" {
+		return code, fileName, nil
+	}
+	code, err := LoadSource(code, filePath)
+	if err != nil {
+		return "", "", err
+	}
+	if fileName == "" {
+		fileName = filepath.Base(filePath)
+	}
+	return code, fileName, nil
+}
+
+// resolveSourcePath returns the absolute path of filePath with symbolic links
+// evaluated, after checking that it lies within SourceRoot. Errors name the
+// path as the tool input called input.
+func resolveSourcePath(input, filePath string) (string, error) {
+	if NoPathInputs {
+		return "", fmt.Errorf("%s is %w; send the code instead", input, ErrPathInputsDisabled)
+	}
+	root := SourceRoot
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		root = wd
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("source root %s: %w", root, err)
+	}
+
+	// Check the path as written first, so nothing is revealed about files
+	// outside the root, then again once symbolic links are followed
+	path := filePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if !withinDir(root, path) {
+		return "", fmt.Errorf("%s %s is outside the source root %s", input, filePath, root)
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%s %s does not exist", input, filePath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s %s: %w", input, filePath, err)
+	}
+	if !withinDir(realRoot, path) {
+		return "", fmt.Errorf("%s %s is outside the source root %s", input, filePath, root)
+	}
+	return path, nil
+}
+
+// withinDir reports whether the absolute path lies within the directory dir
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
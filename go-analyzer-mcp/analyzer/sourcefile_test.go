@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withSourceRoot points SourceRoot at a temporary directory holding files,
+// keyed by slash-separated relative path, for the duration of the test
+func withSourceRoot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := SourceRoot
+	SourceRoot = root
+	t.Cleanup(func() { SourceRoot = old })
+	return root
+}
+
+func TestLoadSource(t *testing.T) {
+	root := withSourceRoot(t, map[string]string{
+		"a.go":     "package a\n",
+		"sub/b.go": "package b\n",
+	})
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.go"), []byte("package secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.go"), filepath.Join(root, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		filePath string
+		want     string
+		wantErr  string
+	}{
+		{name: "code wins", code: "package c\n", filePath: "a.go", want: "package c\n"},
+		{name: "neither", want: ""},
+		{name: "relative", filePath: "a.go", want: "package a\n"},
+		{name: "subdirectory", filePath: "sub/b.go", want: "package b\n"},
+		{name: "absolute inside", filePath: filepath.Join(root, "a.go"), want: "package a\n"},
+		{name: "parent", filePath: "../a.go", wantErr: "outside the source root"},
+		{name: "absolute outside", filePath: filepath.Join(outside, "secret.go"), wantErr: "outside the source root"},
+		{name: "symlink outside", filePath: "link.go", wantErr: "outside the source root"},
+		{name: "missing", filePath: "missing.go", wantErr: "does not exist"},
+		{name: "directory", filePath: "sub", wantErr: "is a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadSource(tt.code, tt.filePath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadSource error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadSource: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LoadSource = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSourcePathNamesInput(t *testing.T) {
+	withSourceRoot(t, nil)
+	_, err := resolveSourcePath("directory", "../elsewhere")
+	if err == nil || !strings.HasPrefix(err.Error(), "directory ../elsewhere is outside") {
+		t.Errorf("resolveSourcePath error = %v, want one naming the directory input", err)
+	}
+}
+
+func TestNoPathInputs(t *testing.T) {
+	withSourceRoot(t, map[string]string{"a.go": "package a\n"})
+	NoPathInputs = true
+	t.Cleanup(func() { NoPathInputs = false })
+
+	if _, err := LoadSource("", "a.go"); !errors.Is(err, ErrPathInputsDisabled) || !strings.Contains(err.Error(), "filePath is not supported") {
+		t.Errorf("LoadSource error = %v, want filePath rejected", err)
+	}
+	if code, err := LoadSource("package c\n", "a.go"); err != nil || code != "package c\n" {
+		t.Errorf("LoadSource = %q, %v, want the code unchanged", code, err)
+	}
+	if _, err := AnalyzeCode(AnalyzeCodeInput{Path: "."}); !errors.Is(err, ErrPathInputsDisabled) || !strings.Contains(err.Error(), "path is not supported") {
+		t.Errorf("AnalyzeCode error = %v, want path rejected", err)
+	}
+	if result, _ := ImportFanout(ImportFanoutInput{Directory: "."}); result.Success || !strings.Contains(result.Error, "directory is not supported") {
+		t.Errorf("ImportFanout error = %q, want directory rejected", result.Error)
+	}
+	batch := AnalyzeBatchInput{Files: []AnalyzeCodeInput{{FileName: "a.go", FilePath: "a.go"}}}
+	if result, _ := AnalyzeBatch(context.Background(), batch); result.Success || !strings.Contains(result.Error, "filePath is not supported") {
+		t.Errorf("AnalyzeBatch error = %q, want filePath rejected", result.Error)
+	}
+}
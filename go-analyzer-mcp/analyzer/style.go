@@ -0,0 +1,254 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Style rule IDs reported in StyleFinding.RuleID
+const (
+	StyleNakedReturn       = "naked-return"
+	StyleTooManyStatements = "too-many-statements"
+	StyleTooManyParams     = "too-many-params"
+	StyleReceiverName      = "receiver-name"
+	StyleMissingDoc        = "missing-doc"
+)
+
+// Defaults of the StyleCheck limits, used when a limit is not positive
+const (
+	DefaultNakedReturnLines = 5
+	DefaultMaxStatements    = 40
+	DefaultMaxParams        = 5
+)
+
+// StyleCheckInput represents the input for a style check
+type StyleCheckInput struct {
+	Code                string `json:"code,omitempty" jsonschema:"Go source code to check"`
+	FilePath            string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	NakedReturnLines    int    `json:"nakedReturnLines,omitempty" jsonschema:"Flag naked returns in functions longer than this many lines (default: 5)"`
+	MaxStatements       int    `json:"maxStatements,omitempty" jsonschema:"Flag functions with more statements than this (default: 40)"`
+	MaxParams           int    `json:"maxParams,omitempty" jsonschema:"Flag functions with more parameters than this, counting a, b int as 2 (default: 5)"`
+	NoNakedReturn       bool   `json:"noNakedReturn,omitempty" jsonschema:"Skip the naked-return rule"`
+	NoTooManyStatements bool   `json:"noTooManyStatements,omitempty" jsonschema:"Skip the too-many-statements rule"`
+	NoTooManyParams     bool   `json:"noTooManyParams,omitempty" jsonschema:"Skip the too-many-params rule"`
+	NoReceiverName      bool   `json:"noReceiverName,omitempty" jsonschema:"Skip the receiver-name rule"`
+	NoMissingDoc        bool   `json:"noMissingDoc,omitempty" jsonschema:"Skip the missing-doc rule"`
+}
+
+// StyleCheckOutput represents the result of a style check
+type StyleCheckOutput struct {
+	Success     bool           `json:"success"`
+	Findings    []StyleFinding `json:"findings"` // In line order
+	Count       int            `json:"count"`
+	Rules       []string       `json:"rules"` // IDs of the rules that ran
+	Error       string         `json:"error,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// StyleFinding represents a departure from a style rule
+type StyleFinding struct {
+	RuleID   string `json:"rule_id"` // e.g. "naked-return"
+	Message  string `json:"message"`
+	Function string `json:"function,omitempty"` // Enclosing function declaration
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// StyleCheck collects opinionated style findings that go vet leaves alone,
+// each rule unless its No flag is set:
+//   - naked-return: return statements without results in functions with
+//     named results longer than input.NakedReturnLines
+//   - too-many-statements: functions with more than input.MaxStatements
+//     statements, counting those nested in blocks and function literals
+//   - too-many-params: functions with more than input.MaxParams parameters
+//   - receiver-name: methods whose receiver is named differently from the
+//     one most methods of the type use
+//   - missing-doc: exported symbols without a doc comment, as in DocCoverage
+func StyleCheck(input StyleCheckInput) (*StyleCheckOutput, error) {
+	code, err := LoadSource(input.Code, input.FilePath)
+	if err == nil {
+		err = checkSource(code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &StyleCheckOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	nakedReturnLines := positiveOr(input.NakedReturnLines, DefaultNakedReturnLines)
+	maxStatements := positiveOr(input.MaxStatements, DefaultMaxStatements)
+	maxParams := positiveOr(input.MaxParams, DefaultMaxParams)
+
+	result := &StyleCheckOutput{
+		Success:  true,
+		Findings: []StyleFinding{},
+		Rules:    []string{},
+	}
+	report := func(rule string, pos token.Pos, format string, args ...any) {
+		position := fset.Position(pos)
+		result.Findings = append(result.Findings, StyleFinding{
+			RuleID:   rule,
+			Message:  fmt.Sprintf(format, args...),
+			Function: enclosingFuncName(file, pos),
+			Line:     position.Line,
+			Column:   position.Column,
+		})
+	}
+	enabled := func(rule string, skip bool) bool {
+		if !skip {
+			result.Rules = append(result.Rules, rule)
+		}
+		return !skip
+	}
+
+	nakedReturn := enabled(StyleNakedReturn, input.NoNakedReturn)
+	tooManyStatements := enabled(StyleTooManyStatements, input.NoTooManyStatements)
+	tooManyParams := enabled(StyleTooManyParams, input.NoTooManyParams)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if params := len(fieldsOf(fn.Type.Params)); tooManyParams && params > maxParams {
+			report(StyleTooManyParams, fn.Name.Pos(), "%s has %d parameters, more than %d; group them in a struct", fn.Name.Name, params, maxParams)
+		}
+		if fn.Body == nil {
+			continue
+		}
+		if statements := countStatements(fn.Body); tooManyStatements && statements > maxStatements {
+			report(StyleTooManyStatements, fn.Name.Pos(), "%s has %d statements, more than %d; split it into smaller functions", fn.Name.Name, statements, maxStatements)
+		}
+		lines := fset.Position(fn.End()).Line - fset.Position(fn.Pos()).Line + 1
+		if nakedReturn && hasNamedResults(fn.Type) && lines > nakedReturnLines {
+			for _, ret := range nakedReturns(fn.Body) {
+				report(StyleNakedReturn, ret.Pos(), "naked return in %s, which is %d lines long; list the results", fn.Name.Name, lines)
+			}
+		}
+	}
+
+	if enabled(StyleReceiverName, input.NoReceiverName) {
+		checkReceiverNames(file, report)
+	}
+	if enabled(StyleMissingDoc, input.NoMissingDoc) {
+		forEachExported(file, func(name *ast.Ident, kind string, doc *ast.CommentGroup) {
+			if strings.TrimSpace(doc.Text()) == "" {
+				report(StyleMissingDoc, name.Pos(), "exported %s %s has no doc comment", kind, name.Name)
+			}
+		})
+	}
+
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	result.Count = len(result.Findings)
+	return result, nil
+}
+
+// positiveOr returns n, or def when n is not positive
+func positiveOr(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+	return n
+}
+
+// countStatements counts the statements of body, including those nested in
+// blocks, clauses and function literals. Blocks, labels and empty statements
+// do not count themselves.
+func countStatements(body *ast.BlockStmt) int {
+	count := 0
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.BlockStmt, *ast.LabeledStmt, *ast.EmptyStmt, *ast.CaseClause, *ast.CommClause:
+		case ast.Stmt:
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// hasNamedResults reports whether a function type names its results
+func hasNamedResults(ft *ast.FuncType) bool {
+	return ft.Results != nil && len(ft.Results.List) > 0 && len(ft.Results.List[0].Names) > 0
+}
+
+// nakedReturns returns the return statements without results in body,
+// leaving out those of function literals, which return for themselves
+func nakedReturns(body *ast.BlockStmt) []*ast.ReturnStmt {
+	var returns []*ast.ReturnStmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(node.Results) == 0 {
+				returns = append(returns, node)
+			}
+		}
+		return true
+	})
+	return returns
+}
+
+// checkReceiverNames reports the methods of each type whose receiver is named
+// differently from the name most of its methods use, or the first one used
+// on a tie. Blank and unnamed receivers are left out.
+func checkReceiverNames(file *ast.File, report func(rule string, pos token.Pos, format string, args ...any)) {
+	type receiver struct {
+		name   *ast.Ident
+		method string
+	}
+	var typeNames []string
+	byType := map[string][]receiver{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || len(fn.Recv.List[0].Names) == 0 {
+			continue
+		}
+		name := fn.Recv.List[0].Names[0]
+		typeName := receiverTypeName(fn.Recv.List[0].Type)
+		if name.Name == "_" || typeName == "" {
+			continue
+		}
+		if byType[typeName] == nil {
+			typeNames = append(typeNames, typeName)
+		}
+		byType[typeName] = append(byType[typeName], receiver{name: name, method: fn.Name.Name})
+	}
+
+	for _, typeName := range typeNames {
+		receivers := byType[typeName]
+		uses := map[string]int{}
+		common := ""
+		for _, r := range receivers {
+			uses[r.name.Name]++
+			if uses[r.name.Name] > uses[common] {
+				common = r.name.Name
+			}
+		}
+		if len(uses) < 2 {
+			continue
+		}
+		for _, r := range receivers {
+			if r.name.Name != common {
+				report(StyleReceiverName, r.name.Pos(), "receiver of %s.%s is named %s, but other methods of %s name it %s",
+					typeName, r.method, r.name.Name, typeName, common)
+			}
+		}
+	}
+}
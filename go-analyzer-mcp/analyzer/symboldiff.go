@@ -0,0 +1,120 @@
+package analyzer
+
+import "slices"
+
+// DiffSymbolsInput represents the input for comparing the symbols of two versions of a file
+type DiffSymbolsInput struct {
+	OldCode string `json:"oldCode" jsonschema:"Base version of the Go source file"`
+	NewCode string `json:"newCode" jsonschema:"Head version of the Go source file"`
+}
+
+// DiffSymbolsOutput represents the symbols added, removed and changed between two versions
+type DiffSymbolsOutput struct {
+	Success     bool           `json:"success"`
+	Added       []Symbol       `json:"added"`   // Only in the new version
+	Removed     []Symbol       `json:"removed"` // Only in the old version
+	Changed     []SymbolChange `json:"changed"` // In both, with a different kind, receiver or signature
+	Error       string         `json:"error,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"` // Syntax errors when parsing fails, with File set to "old" or "new"
+}
+
+// SymbolChange pairs the old and new versions of a changed symbol
+type SymbolChange struct {
+	Old Symbol `json:"old"`
+	New Symbol `json:"new"`
+}
+
+// DiffSymbols compares the symbols of two versions of a file. Symbols are
+// matched by name, and methods additionally by receiver type, so methods of
+// the same name on different types are compared separately. A matched symbol
+// has changed when its kind, receiver, rendered signature, declared type or
+// type parameters differ. Changes to struct fields or interface methods are
+// not detected, since types carry no signature.
+func DiffSymbols(oldCode, newCode string) (*DiffSymbolsOutput, error) {
+	oldCode, _ = normalizeSource(oldCode)
+	newCode, _ = normalizeSource(newCode)
+	oldSyms, errOut := diffSideSymbols(oldCode, "old")
+	if errOut != nil {
+		return errOut, nil
+	}
+	newSyms, errOut := diffSideSymbols(newCode, "new")
+	if errOut != nil {
+		return errOut, nil
+	}
+
+	// Symbols sharing a key, such as several init functions, pair up in order
+	oldByKey := map[string][]int{}
+	for i, sym := range oldSyms {
+		key := symbolDiffKey(sym)
+		oldByKey[key] = append(oldByKey[key], i)
+	}
+
+	result := &DiffSymbolsOutput{
+		Success: true,
+		Added:   []Symbol{},
+		Removed: []Symbol{},
+		Changed: []SymbolChange{},
+	}
+	paired := make([]bool, len(oldSyms))
+	for _, sym := range newSyms {
+		key := symbolDiffKey(sym)
+		candidates := oldByKey[key]
+		if len(candidates) == 0 {
+			result.Added = append(result.Added, sym)
+			continue
+		}
+		oldByKey[key] = candidates[1:]
+		paired[candidates[0]] = true
+		if old := oldSyms[candidates[0]]; symbolChanged(old, sym) {
+			result.Changed = append(result.Changed, SymbolChange{Old: old, New: sym})
+		}
+	}
+
+	for i, sym := range oldSyms {
+		if !paired[i] {
+			result.Removed = append(result.Removed, sym)
+		}
+	}
+
+	return result, nil
+}
+
+// diffSideSymbols extracts the symbols of one side of a diff, or returns the
+// failed output to report, with diagnostics attributed to side
+func diffSideSymbols(code, side string) ([]Symbol, *DiffSymbolsOutput) {
+	symbols, err := GetSymbols(GetSymbolsInput{Code: code})
+	if err != nil {
+		return nil, &DiffSymbolsOutput{Success: false, Error: side + " code: " + err.Error()}
+	}
+	if !symbols.Success {
+		// The output may be cached, so its diagnostics are copied
+		diagnostics := slices.Clone(symbols.Diagnostics)
+		for i := range diagnostics {
+			diagnostics[i].File = side
+		}
+		return nil, &DiffSymbolsOutput{
+			Success:     false,
+			Error:       side + " code: " + symbols.Error,
+			Diagnostics: diagnostics,
+		}
+	}
+	return symbols.Symbols, nil
+}
+
+// symbolDiffKey identifies a symbol across versions: its name, qualified by
+// the receiver type for methods
+func symbolDiffKey(sym Symbol) string {
+	if sym.Kind == "method" {
+		return sym.TypeName + "." + sym.Name
+	}
+	return sym.Name
+}
+
+// symbolChanged reports whether two matched symbols differ in anything but position
+func symbolChanged(old, new Symbol) bool {
+	return old.Kind != new.Kind ||
+		old.Receiver != new.Receiver ||
+		old.Signature != new.Signature ||
+		old.TypeName != new.TypeName ||
+		old.TypeParams != new.TypeParams
+}
@@ -4,36 +4,167 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"slices"
+	"strings"
 )
 
 // GetSymbolsInput represents the input for symbol extraction
 type GetSymbolsInput struct {
-	Code   string `json:"code" jsonschema:"Go source code to analyze"`
-	Filter string `json:"filter,omitempty" jsonschema:"Optional filter: 'function', 'type', 'const', 'var', or 'all'"`
+	Code       string            `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath   string            `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Files      map[string]string `json:"files,omitempty" jsonschema:"Alternative to code: several files of one package keyed by file name, analyzed together"`
+	Filter     string            `json:"filter,omitempty" jsonschema:"Optional comma-separated kinds: 'function', 'method', 'type', 'struct', 'interface', 'const', 'var', or 'all'"`
+	BestEffort bool              `json:"bestEffort,omitempty" jsonschema:"When the code has syntax errors, extract the symbols of the parts that parse and report the errors instead of failing"`
+	NoCache    bool              `json:"noCache,omitempty" jsonschema:"Extract the symbols again instead of returning the cached result of an identical earlier request"`
+	Mode       string            `json:"mode,omitempty" jsonschema:"How to parse the code: 'file' (default), 'fragment' for declarations or statements without a package clause, or 'expr' for an expression; lines are reported as in the code"`
+	StartLine  int               `json:"startLine,omitempty" jsonschema:"Only list symbols whose declarations reach this line or beyond, e.g. the top of an editor viewport (default: 0, from the start of the code)"`
+	EndLine    int               `json:"endLine,omitempty" jsonschema:"Only list symbols whose declarations start at or before this line (default: 0, to the end of the code)"`
 }
 
 // GetSymbolsOutput represents the result of symbol extraction
 type GetSymbolsOutput struct {
-	Success bool     `json:"success"`
-	Symbols []Symbol `json:"symbols"`
-	Count   int      `json:"count"`
-	Error   string   `json:"error,omitempty"`
+	Success         bool         `json:"success"`
+	Symbols         []Symbol     `json:"symbols"`
+	Count           int          `json:"count"`
+	ExportedCount   int          `json:"exported_count"`     // Symbols whose own name is exported
+	UnexportedCount int          `json:"unexported_count"`   // Symbols whose own name is unexported
+	Partial         bool         `json:"partial,omitempty"`  // In best-effort mode, the code had syntax errors, listed in Diagnostics
+	Fragment        string       `json:"fragment,omitempty"` // "declarations", "statements" or "expression" when the code was parsed as a fragment
+	Error           string       `json:"error,omitempty"`
+	Diagnostics     []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails or, in best-effort mode, that were skipped
 }
 
 // Symbol represents a symbol in Go code
 type Symbol struct {
-	Name       string `json:"name"`
-	Kind       string `json:"kind"` // "function", "type", "const", "var", "method", "struct", "interface"
-	Line       int    `json:"line"`
-	Column     int    `json:"column"`
-	Signature  string `json:"signature,omitempty"`
-	Receiver   string `json:"receiver,omitempty"` // For methods
-	TypeName   string `json:"type_name,omitempty"` // For methods, fields
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"` // "function", "type", "const", "var", "method", "struct", "interface"
+	Line       int      `json:"line"`
+	Column     int      `json:"column"`
+	Signature  string   `json:"signature,omitempty"`
+	Receiver   string   `json:"receiver,omitempty"`    // For methods
+	TypeName   string   `json:"type_name,omitempty"`   // For methods, fields
+	File       string   `json:"file,omitempty"`        // Originating file, for multi-file requests
+	Methods    []string `json:"methods,omitempty"`     // For types, methods declared in any of the files
+	TypeParams string   `json:"type_params,omitempty"` // For generic functions and types, e.g. "[K comparable, V any]"
+	Doc        string   `json:"doc,omitempty"`         // Doc comment text, without comment markers
 }
 
-// GetSymbols extracts all symbols from Go code
-func GetSymbols(code, filter string) (*GetSymbolsOutput, error) {
-	file, fset, err := ParseAST(code)
+// symbolFilterKinds maps each accepted filter token to the symbol kinds it
+// selects. "function" and "type" are umbrellas over their more specific kinds.
+var symbolFilterKinds = map[string][]string{
+	"function":  {"function", "method"},
+	"method":    {"method"},
+	"type":      {"type", "struct", "interface"},
+	"struct":    {"struct"},
+	"interface": {"interface"},
+	"const":     {"const"},
+	"var":       {"var"},
+}
+
+// validSymbolFilters lists the accepted filter tokens in display order
+var validSymbolFilters = []string{"all", "function", "method", "type", "struct", "interface", "const", "var"}
+
+// SymbolFilters returns the kinds accepted in GetSymbolsInput.Filter
+func SymbolFilters() []string {
+	return slices.Clone(validSymbolFilters)
+}
+
+// parseSymbolFilter parses a comma-separated filter into the set of symbol
+// kinds to keep. A nil set means every kind is kept.
+func parseSymbolFilter(filter string) (map[string]bool, error) {
+	kinds := map[string]bool{}
+	for _, token := range strings.Split(filter, ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		switch token {
+		case "":
+			continue
+		case "all":
+			return nil, nil
+		}
+
+		selected, ok := symbolFilterKinds[token]
+		if !ok {
+			return nil, fmt.Errorf("unknown symbol filter %q (valid kinds: %s)", token, strings.Join(validSymbolFilters, ", "))
+		}
+		for _, kind := range selected {
+			kinds[kind] = true
+		}
+	}
+
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	return kinds, nil
+}
+
+// GetSymbols extracts all symbols from Go code, or from several files of one
+// package when input.Files is set. Methods are attached to their receiver type
+// even when declared in a different file. The filter is a comma-separated list
+// of kinds to keep; an empty filter or "all" keeps everything. input.Mode
+// selects how code is parsed: as a file, as a fragment completed by
+// WrapFragment, or as an expression. input.StartLine and input.EndLine
+// restrict the symbols to declarations overlapping those lines, including
+// ones that start before them. Results are cached by input unless
+// input.NoCache is set.
+func GetSymbols(input GetSymbolsInput) (*GetSymbolsOutput, error) {
+	var err error
+	input.Code, err = LoadSource(input.Code, input.FilePath)
+	if err == nil {
+		err = checkSource(input.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	input.FilePath = ""
+	refresh := input.NoCache
+	input.NoCache = false
+	return cachedResult("get_symbols", input, refresh, getSymbols)
+}
+
+// getSymbols implements GetSymbols without the cache
+func getSymbols(input GetSymbolsInput) (*GetSymbolsOutput, error) {
+	symbols := []Symbol{}
+	result, err := StreamSymbols(input, func(sym Symbol) error {
+		symbols = append(symbols, sym)
+		return nil
+	})
+	if err != nil || !result.Success {
+		return result, err
+	}
+	result.Symbols = symbols
+	return result, nil
+}
+
+// StreamSymbols extracts symbols like GetSymbols, but passes each one to emit
+// as soon as it is found instead of collecting them, so memory use does not
+// grow with the number of symbols. The output carries the counts and any
+// error, but no symbols. Extraction stops at the first error from emit, which
+// is returned. Results are not cached.
+func StreamSymbols(input GetSymbolsInput, emit func(Symbol) error) (*GetSymbolsOutput, error) {
+	code, err := LoadSource(input.Code, input.FilePath)
+	if err == nil {
+		err = checkSource(code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	input.Code, _ = normalizeSource(code)
+	kinds, err := parseSymbolFilter(input.Filter)
+	if err != nil {
+		return &GetSymbolsOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	scope, err := newLineScope(input.StartLine, input.EndLine, input.Files)
+	if err != nil {
+		return &GetSymbolsOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	code, fragment, err := wrapSource(input.Code, input.Files, input.Mode)
 	if err != nil {
 		return &GetSymbolsOutput{
 			Success: false,
@@ -41,57 +172,135 @@ func GetSymbols(code, filter string) (*GetSymbolsOutput, error) {
 		}, nil
 	}
 
-	symbols := []Symbol{}
+	sources, fset, err := parseSources(code, input.Files, input.BestEffort)
+	if sources == nil {
+		message, diagnostics := parseFailure(code, fragment, err)
+		return &GetSymbolsOutput{
+			Success:     false,
+			Error:       message,
+			Diagnostics: diagnostics,
+		}, nil
+	}
 
-	// Walk the AST
-	ast.Inspect(file, func(n ast.Node) bool {
-		switch decl := n.(type) {
-		case *ast.FuncDecl:
-			if filter == "" || filter == "all" || filter == "function" {
-				sym := extractFunctionSymbol(decl, fset)
-				symbols = append(symbols, sym)
+	result, emitErr := emitSymbols(sources, fset, len(input.Files) > 0, fragment, kinds, scope, emit)
+	if emitErr != nil {
+		return nil, emitErr
+	}
+	if err != nil {
+		result.Partial = true
+		result.Diagnostics = parseErrorsToDiagnostics(err)
+	}
+	return result, nil
+}
+
+// emitSymbols passes the symbols of the parsed sources of kinds, or of every
+// kind when kinds is nil, whose declarations overlap scope to emit, and
+// returns the counts. Symbols record their file when multiFile is set. Only
+// declared symbols of a statements or expression fragment are emitted, not
+// its wrapper function. Extraction stops at the first error from emit, which
+// is returned.
+func emitSymbols(sources []sourceFile, fset *token.FileSet, multiFile bool, fragment string, kinds map[string]bool, scope lineScope, emit func(Symbol) error) (*GetSymbolsOutput, error) {
+	// Collect the methods of each receiver type across files first, so types
+	// can be emitted with their methods as soon as they are found
+	methods := map[string][]string{}
+	for _, src := range sources {
+		for _, decl := range src.AST.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && len(fn.Recv.List) > 0 {
+				if typeName := receiverTypeName(fn.Recv.List[0].Type); typeName != "" {
+					methods[typeName] = append(methods[typeName], fn.Name.Name)
+				}
 			}
+		}
+	}
 
-		case *ast.GenDecl:
-			// Handle type, const, var declarations
-			for _, spec := range decl.Specs {
-				switch s := spec.(type) {
-				case *ast.TypeSpec:
-					if filter == "" || filter == "all" || filter == "type" {
-						sym := extractTypeSymbol(s, fset)
-						symbols = append(symbols, sym)
-					}
+	result := &GetSymbolsOutput{Success: true, Fragment: fragment}
+	for _, src := range sources {
+		fileName := ""
+		if multiFile {
+			fileName = src.Name
+		}
+		var emitErr error
+		add := func(decl ast.Node, syms ...Symbol) {
+			if !scope.covers(fset, decl) {
+				return
+			}
+			for _, sym := range syms {
+				switch sym.Kind {
+				case "type", "struct", "interface":
+					sym.Methods = methods[sym.Name]
+				}
+				if emitErr != nil || kinds != nil && !kinds[sym.Kind] {
+					continue
+				}
+				sym.File = fileName
+				if emitErr = emit(sym); emitErr != nil {
+					continue
+				}
+				result.Count++
 
-				case *ast.ValueSpec:
-					kind := "var"
-					if decl.Tok == token.CONST {
-						kind = "const"
-					}
-					if filter == "" || filter == "all" || filter == kind {
-						syms := extractValueSymbols(s, kind, fset)
-						symbols = append(symbols, syms...)
+				// Methods count by their own name, whatever their receiver type
+				if ast.IsExported(sym.Name) {
+					result.ExportedCount++
+				} else {
+					result.UnexportedCount++
+				}
+			}
+		}
+
+		// Walk the AST
+		ast.Inspect(src.AST, func(n ast.Node) bool {
+			if emitErr != nil {
+				return false
+			}
+			switch decl := n.(type) {
+			case *ast.FuncDecl:
+				// The only function of a statements or expression fragment
+				// is its wrapper, whose local declarations are still listed
+				if fragment == "" || fragment == FragmentDeclarations {
+					add(decl, extractFunctionSymbol(decl, fset))
+				}
+
+			case *ast.GenDecl:
+				// Handle type, const, var declarations
+				for _, spec := range decl.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						// As in go doc, the declaration's comment documents a
+						// type only when it is declared on its own
+						groupDoc := decl.Doc
+						if decl.Lparen.IsValid() {
+							groupDoc = nil
+						}
+						add(s, extractTypeSymbol(s, groupDoc, fset))
+
+					case *ast.ValueSpec:
+						kind := "var"
+						if decl.Tok == token.CONST {
+							kind = "const"
+						}
+						add(s, extractValueSymbols(s, kind, decl.Doc, fset)...)
 					}
 				}
 			}
+			return true
+		})
+		if emitErr != nil {
+			return nil, emitErr
 		}
-		return true
-	})
+	}
 
-	return &GetSymbolsOutput{
-		Success: true,
-		Symbols: symbols,
-		Count:   len(symbols),
-	}, nil
+	return result, nil
 }
 
 func extractFunctionSymbol(decl *ast.FuncDecl, fset *token.FileSet) Symbol {
 	pos := fset.Position(decl.Pos())
-	
+
 	sym := Symbol{
 		Name:   decl.Name.Name,
 		Kind:   "function",
 		Line:   pos.Line,
 		Column: pos.Column,
+		Doc:    docText(decl.Doc),
 	}
 
 	// Check if it's a method
@@ -99,49 +308,43 @@ func extractFunctionSymbol(decl *ast.FuncDecl, fset *token.FileSet) Symbol {
 		sym.Kind = "method"
 		// Extract receiver type
 		if field := decl.Recv.List[0]; field.Type != nil {
-			sym.Receiver = fmt.Sprintf("%s", field.Type)
+			sym.Receiver = types.ExprString(field.Type)
+			sym.TypeName = receiverTypeName(field.Type)
 		}
 	}
 
 	// Build signature
-	sig := decl.Name.Name + "("
-	if decl.Type.Params != nil {
-		for i, param := range decl.Type.Params.List {
-			if i > 0 {
-				sig += ", "
-			}
-			for _, name := range param.Names {
-				sig += name.Name + " "
-			}
-			sig += fmt.Sprintf("%s", param.Type)
-		}
+	sig := decl.Name.Name
+	if decl.Type.TypeParams != nil {
+		sym.TypeParams = "[" + fieldListString(decl.Type.TypeParams) + "]"
+		sig += sym.TypeParams
 	}
-	sig += ")"
+	sig += "(" + fieldListString(decl.Type.Params) + ")"
 
 	// Add return types
-	if decl.Type.Results != nil && len(decl.Type.Results.List) > 0 {
-		sig += " "
-		if len(decl.Type.Results.List) > 1 {
-			sig += "("
-		}
-		for i, result := range decl.Type.Results.List {
-			if i > 0 {
-				sig += ", "
+	var results []string
+	if decl.Type.Results != nil {
+		for _, result := range decl.Type.Results.List {
+			for range max(len(result.Names), 1) {
+				results = append(results, types.ExprString(result.Type))
 			}
-			sig += fmt.Sprintf("%s", result.Type)
-		}
-		if len(decl.Type.Results.List) > 1 {
-			sig += ")"
 		}
 	}
+	switch len(results) {
+	case 0:
+	case 1:
+		sig += " " + results[0]
+	default:
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
 
 	sym.Signature = sig
 	return sym
 }
 
-func extractTypeSymbol(spec *ast.TypeSpec, fset *token.FileSet) Symbol {
+func extractTypeSymbol(spec *ast.TypeSpec, groupDoc *ast.CommentGroup, fset *token.FileSet) Symbol {
 	pos := fset.Position(spec.Pos())
-	
+
 	kind := "type"
 	switch spec.Type.(type) {
 	case *ast.StructType:
@@ -150,17 +353,25 @@ func extractTypeSymbol(spec *ast.TypeSpec, fset *token.FileSet) Symbol {
 		kind = "interface"
 	}
 
-	return Symbol{
+	sym := Symbol{
 		Name:   spec.Name.Name,
 		Kind:   kind,
 		Line:   pos.Line,
 		Column: pos.Column,
+		Doc:    docText(spec.Doc, groupDoc),
 	}
+	if spec.TypeParams != nil {
+		sym.TypeParams = "[" + fieldListString(spec.TypeParams) + "]"
+	}
+	return sym
 }
 
-func extractValueSymbols(spec *ast.ValueSpec, kind string, fset *token.FileSet) []Symbol {
+// extractValueSymbols returns a symbol per name in spec. Specs without a doc
+// comment of their own take the doc comment of their const or var block.
+func extractValueSymbols(spec *ast.ValueSpec, kind string, groupDoc *ast.CommentGroup, fset *token.FileSet) []Symbol {
 	symbols := []Symbol{}
-	
+	doc := docText(spec.Doc, groupDoc)
+
 	for _, name := range spec.Names {
 		pos := fset.Position(name.Pos())
 		sym := Symbol{
@@ -168,14 +379,48 @@ func extractValueSymbols(spec *ast.ValueSpec, kind string, fset *token.FileSet)
 			Kind:   kind,
 			Line:   pos.Line,
 			Column: pos.Column,
+			Doc:    doc,
 		}
-		
+
 		if spec.Type != nil {
-			sym.TypeName = fmt.Sprintf("%s", spec.Type)
+			sym.TypeName = types.ExprString(spec.Type)
 		}
-		
+
 		symbols = append(symbols, sym)
 	}
-	
+
 	return symbols
 }
+
+// docText returns the text of the first non-nil comment group, with comment
+// markers and the trailing newline removed
+func docText(groups ...*ast.CommentGroup) string {
+	for _, group := range groups {
+		if group != nil {
+			return strings.TrimSuffix(group.Text(), "\n")
+		}
+	}
+	return ""
+}
+
+// fieldListString renders a parameter or type parameter list without its
+// enclosing brackets, e.g. "K comparable, V any" or "a, b int, opts ...Option"
+func fieldListString(list *ast.FieldList) string {
+	if list == nil {
+		return ""
+	}
+
+	fields := make([]string, 0, len(list.List))
+	for _, field := range list.List {
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		text := types.ExprString(field.Type)
+		if len(names) > 0 {
+			text = strings.Join(names, ", ") + " " + text
+		}
+		fields = append(fields, text)
+	}
+	return strings.Join(fields, ", ")
+}
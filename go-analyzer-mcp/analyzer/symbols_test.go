@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetSymbolsTypeParams(t *testing.T) {
+	const code = `package p
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+func (p Pair[K, V]) Swap() Pair[K, V] { return p }
+
+type Number interface {
+	~int | ~float64
+}
+
+func Sum[T Number](xs ...T) T {
+	var s T
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+func Map[S ~[]E, E, R any](s S, f func(E) R) []R { return nil }
+
+func Plain(x int) int { return x }
+
+type List[T any] []T
+`
+	result, err := GetSymbols(GetSymbolsInput{Code: code, NoCache: true})
+	if err != nil {
+		t.Fatalf("GetSymbols: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("GetSymbols failed: %s", result.Error)
+	}
+	symbols := map[string]Symbol{}
+	for _, sym := range result.Symbols {
+		symbols[sym.Name] = sym
+	}
+
+	tests := []struct {
+		name       string
+		kind       string
+		typeParams string
+		signature  string
+		receiver   string
+	}{
+		{name: "Pair", kind: "struct", typeParams: "[K comparable, V any]"},
+		{name: "Swap", kind: "method", signature: "Swap() Pair[K, V]", receiver: "Pair[K, V]"},
+		{name: "Number", kind: "interface"},
+		{name: "Sum", kind: "function", typeParams: "[T Number]", signature: "Sum[T Number](xs ...T) T"},
+		{name: "Map", kind: "function", typeParams: "[S ~[]E, E, R any]", signature: "Map[S ~[]E, E, R any](s S, f func(E) R) []R"},
+		{name: "Plain", kind: "function", signature: "Plain(x int) int"},
+		{name: "List", kind: "type", typeParams: "[T any]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sym, ok := symbols[tt.name]
+			if !ok {
+				t.Fatalf("no symbol %s", tt.name)
+			}
+			if sym.Kind != tt.kind {
+				t.Errorf("Kind = %q, want %q", sym.Kind, tt.kind)
+			}
+			if sym.TypeParams != tt.typeParams {
+				t.Errorf("TypeParams = %q, want %q", sym.TypeParams, tt.typeParams)
+			}
+			if sym.Signature != tt.signature {
+				t.Errorf("Signature = %q, want %q", sym.Signature, tt.signature)
+			}
+			if sym.Receiver != tt.receiver {
+				t.Errorf("Receiver = %q, want %q", sym.Receiver, tt.receiver)
+			}
+		})
+	}
+}
+
+// brokenStatementCode has one broken statement, on line 7, in the body of f,
+// between valid declarations
+const brokenStatementCode = `package p
+
+func a() {}
+
+func f() {
+	x :=
+	return
+}
+
+func g() int { return 1 }
+`
+
+func TestGetSymbolsBestEffortBrokenStatement(t *testing.T) {
+	result, err := GetSymbols(GetSymbolsInput{Code: brokenStatementCode, BestEffort: true, NoCache: true})
+	if err != nil {
+		t.Fatalf("GetSymbols: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("GetSymbols failed: %s", result.Error)
+	}
+	if !result.Partial {
+		t.Error("result not marked partial")
+	}
+	var names []string
+	for _, sym := range result.Symbols {
+		names = append(names, sym.Name)
+	}
+	if !slices.Equal(names, []string{"a", "f", "g"}) {
+		t.Errorf("symbols = %v, want [a f g]", names)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Line != 7 {
+		t.Errorf("Diagnostics = %+v, want one on line 7", result.Diagnostics)
+	}
+}
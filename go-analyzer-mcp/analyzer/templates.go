@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// CheckTemplatesInput represents the input for template validation
+type CheckTemplatesInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code containing text/template or html/template definitions"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// TemplateOutput represents the result of template validation
+type TemplateOutput struct {
+	Success     bool            `json:"success"`
+	Templates   []TemplateCheck `json:"templates"`
+	Count       int             `json:"count"`
+	ErrorCount  int             `json:"error_count"`
+	Error       string          `json:"error,omitempty"`
+	Diagnostics []Diagnostic    `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// TemplateCheck represents the result of parsing one template literal
+type TemplateCheck struct {
+	Name    string `json:"name,omitempty"` // Name passed to template.New, when it is a literal
+	Line    int    `json:"line"`           // Position of the template literal, or of the error within it
+	Column  int    `json:"column"`
+	Valid   bool   `json:"valid"`
+	Message string `json:"message,omitempty"`
+}
+
+// templateErrorPattern matches errors from text/template/parse, e.g.
+// "template: tmpl:3: unexpected EOF"
+var templateErrorPattern = regexp.MustCompile(`^template: tmpl:(\d+):\s*(.*)$`)
+
+// CheckTemplates finds string literals passed to Parse on templates created with
+// text/template or html/template New, parses them with the template engine and
+// reports syntax errors at their position in the Go source. Template function
+// names are not checked, since custom functions are registered at runtime.
+func CheckTemplates(code string) (*TemplateOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &TemplateOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	pkgNames := map[string]bool{}
+	for _, path := range []string{"text/template", "html/template"} {
+		if name := importName(file, path); name != "" {
+			pkgNames[name] = true
+		}
+	}
+
+	result := &TemplateOutput{
+		Success:   true,
+		Templates: []TemplateCheck{},
+	}
+	if len(pkgNames) == 0 {
+		return result, nil
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Parse" {
+			return true
+		}
+
+		name, left, right, ok := templateChain(sel.X, pkgNames)
+		if !ok {
+			return true
+		}
+		text, lit, ok := stringConstant(call.Args[0])
+		if !ok {
+			return true
+		}
+
+		check := checkTemplateText(text, left, right)
+		check.Name = name
+		pos := fset.Position(lit.Pos())
+		check.Line = pos.Line
+		check.Column = pos.Column
+		if !check.Valid {
+			result.ErrorCount++
+			// Raw strings keep their line breaks, so the error line within the
+			// template maps directly onto the source
+			if m := templateErrorPattern.FindStringSubmatch(check.Message); m != nil {
+				check.Message = m[2]
+				if strings.HasPrefix(lit.Value, "`") {
+					if line, err := strconv.Atoi(m[1]); err == nil && line > 1 {
+						check.Line += line - 1
+						check.Column = 1
+					}
+				}
+			}
+		}
+		result.Templates = append(result.Templates, check)
+		return true
+	})
+
+	result.Count = len(result.Templates)
+	return result, nil
+}
+
+// templateChain walks a method chain such as template.New("x").Funcs(f).Delims("[[", "]]")
+// back to its template.New call, returning the template name and delimiters
+func templateChain(expr ast.Expr, pkgNames map[string]bool) (name, left, right string, ok bool) {
+	for {
+		call, isCall := expr.(*ast.CallExpr)
+		if !isCall {
+			return "", "", "", false
+		}
+		sel, isSel := call.Fun.(*ast.SelectorExpr)
+		if !isSel {
+			return "", "", "", false
+		}
+
+		if ident, isIdent := sel.X.(*ast.Ident); isIdent && pkgNames[ident.Name] {
+			if sel.Sel.Name != "New" {
+				return "", "", "", false
+			}
+			if len(call.Args) == 1 {
+				name, _, _ = stringConstant(call.Args[0])
+			}
+			return name, left, right, true
+		}
+
+		// The innermost Delims call is applied first, but any later one wins
+		if sel.Sel.Name == "Delims" && len(call.Args) == 2 && left == "" && right == "" {
+			left, _, _ = stringConstant(call.Args[0])
+			right, _, _ = stringConstant(call.Args[1])
+		}
+		expr = sel.X
+	}
+}
+
+// stringConstant evaluates a string literal or a concatenation of string
+// literals, returning its value and the first literal
+func stringConstant(expr ast.Expr) (string, *ast.BasicLit, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", nil, false
+		}
+		value, err := strconv.Unquote(e.Value)
+		if err != nil {
+			return "", nil, false
+		}
+		return value, e, true
+
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", nil, false
+		}
+		x, lit, ok := stringConstant(e.X)
+		if !ok {
+			return "", nil, false
+		}
+		y, _, ok := stringConstant(e.Y)
+		if !ok {
+			return "", nil, false
+		}
+		return x + y, lit, true
+
+	case *ast.ParenExpr:
+		return stringConstant(e.X)
+	}
+	return "", nil, false
+}
+
+// checkTemplateText parses a template body without checking function names
+func checkTemplateText(text, left, right string) TemplateCheck {
+	tree := parse.New("tmpl")
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+	if _, err := tree.Parse(text, left, right, map[string]*parse.Tree{}); err != nil {
+		return TemplateCheck{Valid: false, Message: err.Error()}
+	}
+	return TemplateCheck{Valid: true}
+}
@@ -0,0 +1,300 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// GenerateTestSkeletonInput represents the input for test skeleton generation
+type GenerateTestSkeletonInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code containing the functions to test"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	FileName string `json:"fileName,omitempty" jsonschema:"Optional name of the source file, used to name the test file (default: derived from the package name)"`
+}
+
+// TestSkeletonOutput represents a generated test file
+type TestSkeletonOutput struct {
+	Success     bool         `json:"success"`
+	FileName    string       `json:"file_name"` // Suggested name of the test file
+	TestCode    string       `json:"test_code"`
+	Tests       []string     `json:"tests"`             // Names of the generated test functions
+	Skipped     []string     `json:"skipped,omitempty"` // Exported generic functions and methods, which need type arguments
+	Error       string       `json:"error,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// skeletonTarget describes the function or method a test is generated for
+type skeletonTarget struct {
+	testName string
+	call     string // Name shown in failure messages, e.g. "T.Get()"
+	receiver string // Receiver base type, for methods
+	params   []skeletonField
+	results  []skeletonField // Non-error results, named want, want1, ...
+	hasErr   bool            // Last result is an error
+}
+
+// skeletonField is a named table field and its type
+type skeletonField struct {
+	name     string
+	typ      string
+	variadic bool
+}
+
+// GenerateTestSkeleton generates a table-driven test file for the exported
+// functions of code, and for the exported methods of its exported types. Each
+// test has an args struct built from the parameters, a receiver field holding
+// the value the method is called on, and want fields for the results; a
+// trailing error result becomes a wantErr flag. The tests are in the package
+// under test, so unexported types in signatures need no qualification.
+func GenerateTestSkeleton(input GenerateTestSkeletonInput) (*TestSkeletonOutput, error) {
+	code, fileName, err := loadInputFile(input.Code, input.FileName, input.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	input.Code, _ = normalizeSource(code)
+	input.FileName = fileName
+	file, _, err := ParseAST(input.Code)
+	if err != nil {
+		return &TestSkeletonOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	result := &TestSkeletonOutput{
+		Success:  true,
+		FileName: testFileName(input.FileName, file.Name.Name),
+		Tests:    []string{},
+	}
+
+	var targets []skeletonTarget
+	usedPackages := map[string]bool{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !fn.Name.IsExported() {
+			continue
+		}
+
+		target := skeletonTarget{
+			testName: "Test" + fn.Name.Name,
+			call:     fn.Name.Name + "()",
+		}
+		generic := fn.Type.TypeParams != nil
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			recvType := fn.Recv.List[0].Type
+			if star, ok := recvType.(*ast.StarExpr); ok {
+				recvType = star.X
+			}
+			target.receiver = receiverTypeName(recvType)
+			if !ast.IsExported(target.receiver) {
+				continue
+			}
+			switch recvType.(type) {
+			case *ast.IndexExpr, *ast.IndexListExpr:
+				generic = true
+			}
+			target.testName = "Test" + target.receiver + "_" + fn.Name.Name
+			target.call = target.receiver + "." + target.call
+		}
+		if generic {
+			result.Skipped = append(result.Skipped, strings.TrimSuffix(target.call, "()"))
+			continue
+		}
+
+		for i, param := range fieldsOf(fn.Type.Params) {
+			if param.name == "" || param.name == "_" {
+				param.name = "arg" + strconv.Itoa(i)
+			}
+			field := skeletonField{name: param.name, typ: types.ExprString(param.expr)}
+			if ellipsis, ok := param.expr.(*ast.Ellipsis); ok {
+				field.typ = "[]" + types.ExprString(ellipsis.Elt)
+				field.variadic = true
+			}
+			target.params = append(target.params, field)
+			collectPackages(param.expr, usedPackages)
+		}
+
+		results := fieldsOf(fn.Type.Results)
+		if n := len(results); n > 0 && types.ExprString(results[n-1].expr) == "error" {
+			target.hasErr = true
+			results = results[:n-1]
+		}
+		for i, res := range results {
+			name := "want"
+			if i > 0 {
+				name += strconv.Itoa(i)
+			}
+			target.results = append(target.results, skeletonField{name: name, typ: types.ExprString(res.expr)})
+			collectPackages(res.expr, usedPackages)
+		}
+
+		targets = append(targets, target)
+		result.Tests = append(result.Tests, target.testName)
+	}
+
+	if len(targets) == 0 {
+		result.TestCode = "package " + file.Name.Name + "\n"
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\nimport (\n", file.Name.Name)
+	for _, spec := range skeletonImports(file, usedPackages, targets) {
+		fmt.Fprintf(&buf, "\t%s\n", spec)
+	}
+	buf.WriteString(")\n")
+	for _, target := range targets {
+		writeSkeletonTest(&buf, target)
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated test: %w", err)
+	}
+	result.TestCode = string(formatted)
+	return result, nil
+}
+
+// testFileName derives the test file name from the source file name, or from
+// the package name when there is none
+func testFileName(fileName, pkg string) string {
+	if base, ok := strings.CutSuffix(fileName, ".go"); ok && base != "" {
+		return strings.TrimSuffix(base, "_test") + "_test.go"
+	}
+	return pkg + "_test.go"
+}
+
+// paramField is one entry of a flattened parameter or result list
+type paramField struct {
+	name string
+	expr ast.Expr
+}
+
+// fieldsOf flattens a field list so that each name, or each unnamed field,
+// has its own entry
+func fieldsOf(list *ast.FieldList) []paramField {
+	if list == nil {
+		return nil
+	}
+	var fields []paramField
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			fields = append(fields, paramField{expr: field.Type})
+		}
+		for _, name := range field.Names {
+			fields = append(fields, paramField{name: name.Name, expr: field.Type})
+		}
+	}
+	return fields
+}
+
+// collectPackages records the package names qualifying identifiers in expr
+func collectPackages(expr ast.Expr, used map[string]bool) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+			return false
+		}
+		return true
+	})
+}
+
+// skeletonImports returns the import specs the test file needs: testing,
+// reflect when results are compared, and the imports of the source file
+// named in the signatures
+func skeletonImports(file *ast.File, usedPackages map[string]bool, targets []skeletonTarget) []string {
+	specs := map[string]bool{`"testing"`: true}
+	for _, target := range targets {
+		if len(target.results) > 0 {
+			specs[`"reflect"`] = true
+		}
+	}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !usedPackages[importName(file, path)] {
+			continue
+		}
+		spec := imp.Path.Value
+		if imp.Name != nil {
+			spec = imp.Name.Name + " " + spec
+		}
+		specs[spec] = true
+	}
+	return sortedKeys(specs)
+}
+
+// writeSkeletonTest writes the table-driven test for target
+func writeSkeletonTest(buf *bytes.Buffer, target skeletonTarget) {
+	fmt.Fprintf(buf, "\nfunc %s(t *testing.T) {\n", target.testName)
+	if len(target.params) > 0 {
+		buf.WriteString("type args struct {\n")
+		for _, param := range target.params {
+			fmt.Fprintf(buf, "%s %s\n", param.name, param.typ)
+		}
+		buf.WriteString("}\n")
+	}
+
+	buf.WriteString("tests := []struct {\nname string\n")
+	if target.receiver != "" {
+		fmt.Fprintf(buf, "receiver %s\n", target.receiver)
+	}
+	if len(target.params) > 0 {
+		buf.WriteString("args args\n")
+	}
+	for _, res := range target.results {
+		fmt.Fprintf(buf, "%s %s\n", res.name, res.typ)
+	}
+	if target.hasErr {
+		buf.WriteString("wantErr bool\n")
+	}
+	buf.WriteString("}{\n// TODO: Add test cases.\n}\n")
+
+	args := make([]string, len(target.params))
+	for i, param := range target.params {
+		args[i] = "tt.args." + param.name
+		if param.variadic {
+			args[i] += "..."
+		}
+	}
+	callee := strings.TrimSuffix(target.call, "()")
+	if target.receiver != "" {
+		callee = "tt.receiver." + strings.TrimPrefix(callee, target.receiver+".")
+	}
+	call := callee + "(" + strings.Join(args, ", ") + ")"
+
+	var got []string
+	for i := range target.results {
+		name := "got"
+		if i > 0 {
+			name += strconv.Itoa(i)
+		}
+		got = append(got, name)
+	}
+	if target.hasErr {
+		got = append(got, "err")
+	}
+
+	buf.WriteString("for _, tt := range tests {\nt.Run(tt.name, func(t *testing.T) {\n")
+	if len(got) > 0 {
+		fmt.Fprintf(buf, "%s := %s\n", strings.Join(got, ", "), call)
+	} else {
+		buf.WriteString(call + "\n")
+	}
+	if target.hasErr {
+		fmt.Fprintf(buf, "if (err != nil) != tt.wantErr {\nt.Errorf(%q, err, tt.wantErr)\nreturn\n}\n",
+			target.call+" error = %v, wantErr %v")
+	}
+	for i, res := range target.results {
+		fmt.Fprintf(buf, "if !reflect.DeepEqual(%s, tt.%s) {\nt.Errorf(%q, %s, tt.%s)\n}\n",
+			got[i], res.name, target.call+" "+got[i]+" = %v, want %v", got[i], res.name)
+	}
+	buf.WriteString("})\n}\n}\n")
+}
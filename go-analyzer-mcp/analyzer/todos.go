@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// defaultTodoMarkers are the markers searched for when none are given
+var defaultTodoMarkers = []string{"TODO", "FIXME", "HACK", "XXX"}
+
+// DefaultTodoMarkers returns a copy of the default markers
+func DefaultTodoMarkers() []string {
+	return slices.Clone(defaultTodoMarkers)
+}
+
+// FindTodosInput represents the input for TODO comment extraction
+type FindTodosInput struct {
+	Code     string   `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string   `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+	Markers  []string `json:"markers,omitempty" jsonschema:"Optional markers to search for, matched case-insensitively (default: TODO, FIXME, HACK, XXX)"`
+}
+
+// TodosOutput represents the result of TODO comment extraction
+type TodosOutput struct {
+	Success     bool          `json:"success"`
+	Todos       []TodoComment `json:"todos"`
+	Count       int           `json:"count"`
+	Error       string        `json:"error,omitempty"`
+	Diagnostics []Diagnostic  `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// TodoComment represents a marker found in a comment
+type TodoComment struct {
+	Marker string `json:"marker"`          // The marker as configured, e.g. "TODO"
+	Text   string `json:"text"`            // Text following the marker up to the end of the line
+	Owner  string `json:"owner,omitempty"` // Name in parentheses after the marker, as in TODO(alice)
+	File   string `json:"file,omitempty"`  // Originating file, for multi-file requests
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// FindTodos finds TODO-style markers in line and block comments. Markers match
+// case-insensitively as whole words; at most one marker is reported per line.
+func FindTodos(code string, markers []string) (*TodosOutput, error) {
+	code, _ = normalizeSource(code)
+	if len(markers) == 0 {
+		markers = defaultTodoMarkers
+	}
+	pattern, canonical, err := todoPattern(markers)
+	if err != nil {
+		return &TodosOutput{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &TodosOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	todos := findTodoComments(file, fset, "", pattern, canonical)
+	return &TodosOutput{
+		Success: true,
+		Todos:   todos,
+		Count:   len(todos),
+	}, nil
+}
+
+// findTodoComments lists the markers matched by pattern in the comments of
+// file, recording fileName in each. Text following a parenthesized owner, as
+// in TODO(alice): fix, is the text after it.
+func findTodoComments(file *ast.File, fset *token.FileSet, fileName string, pattern *regexp.Regexp, canonical map[string]string) []TodoComment {
+	todos := []TodoComment{}
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			pos := fset.Position(comment.Pos())
+			for i, line := range strings.Split(comment.Text, "\n") {
+				loc := pattern.FindStringSubmatchIndex(line)
+				if loc == nil {
+					continue
+				}
+
+				// Block comments keep their closing marker on the last line
+				rest := strings.TrimSuffix(line[loc[3]:], "*/")
+				owner := ""
+				if inner, ok := strings.CutPrefix(rest, "("); ok {
+					if name, after, found := strings.Cut(inner, ")"); found {
+						owner, rest = strings.TrimSpace(name), after
+					}
+				}
+				column := loc[2] + 1
+				if i == 0 {
+					column += pos.Column - 1
+				}
+				todos = append(todos, TodoComment{
+					Marker: canonical[strings.ToLower(line[loc[2]:loc[3]])],
+					Text:   strings.TrimSpace(strings.TrimLeft(rest, ":- \t")),
+					Owner:  owner,
+					File:   fileName,
+					Line:   pos.Line + i,
+					Column: column,
+				})
+			}
+		}
+	}
+	return todos
+}
+
+// todoPattern compiles a case-insensitive whole-word pattern for markers and
+// maps each lower-cased marker back to its configured spelling
+func todoPattern(markers []string) (*regexp.Regexp, map[string]string, error) {
+	canonical := map[string]string{}
+	quoted := make([]string, 0, len(markers))
+	for _, marker := range markers {
+		marker = strings.TrimSpace(marker)
+		if marker == "" {
+			return nil, nil, fmt.Errorf("markers must not be empty")
+		}
+		canonical[strings.ToLower(marker)] = marker
+		quoted = append(quoted, regexp.QuoteMeta(marker))
+	}
+
+	pattern, err := regexp.Compile(`(?i)(?:^|[^\pL\pN_])(` + strings.Join(quoted, "|") + `)(?:[^\pL\pN_]|$)`)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pattern, canonical, nil
+}
@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// actionVerbs are the leading words accepted as action-oriented. The list is
+// deliberately generous, since a missed verb produces a noisy suggestion.
+var actionVerbs = map[string]bool{
+	"abort": true, "accept": true, "acquire": true, "add": true, "allocate": true,
+	"analyze": true, "append": true, "apply": true, "assert": true, "attach": true,
+	"begin": true, "bind": true, "build": true, "calculate": true, "call": true,
+	"cancel": true, "check": true, "clean": true, "cleanup": true, "clear": true,
+	"clone": true, "close": true, "collect": true, "commit": true, "compare": true,
+	"compile": true, "complete": true, "compress": true, "compute": true, "configure": true,
+	"connect": true, "convert": true, "copy": true, "count": true, "create": true,
+	"decode": true, "decompress": true, "decrypt": true, "delete": true, "deserialize": true,
+	"detach": true, "dial": true, "disable": true, "disconnect": true, "dispatch": true,
+	"do": true, "download": true, "drain": true, "drop": true, "emit": true,
+	"enable": true, "encode": true, "encrypt": true, "end": true, "enqueue": true,
+	"ensure": true, "evaluate": true, "exec": true, "execute": true, "export": true,
+	"extract": true, "fetch": true, "fill": true, "filter": true, "find": true,
+	"finish": true, "fix": true, "flush": true, "format": true, "free": true,
+	"generate": true, "get": true, "handle": true, "hash": true, "import": true,
+	"increment": true, "init": true, "initialize": true, "insert": true, "inspect": true,
+	"install": true, "invoke": true, "join": true, "kill": true, "launch": true,
+	"limit": true, "list": true, "listen": true, "load": true, "lock": true,
+	"log": true, "lookup": true, "make": true, "mark": true, "marshal": true,
+	"match": true, "measure": true, "merge": true, "migrate": true, "mount": true,
+	"move": true, "must": true, "new": true, "normalize": true, "notify": true,
+	"open": true, "parse": true, "patch": true, "ping": true, "poll": true,
+	"pop": true, "post": true, "prepare": true, "print": true, "process": true,
+	"publish": true, "pull": true, "push": true, "put": true, "read": true,
+	"receive": true, "record": true, "refresh": true, "register": true, "release": true,
+	"reload": true, "remove": true, "render": true, "replace": true, "report": true,
+	"reset": true, "resolve": true, "restart": true, "restore": true, "resume": true,
+	"retry": true, "return": true, "rollback": true, "run": true, "save": true,
+	"scan": true, "schedule": true, "search": true, "send": true, "serialize": true,
+	"serve": true, "set": true, "setup": true, "shutdown": true, "sign": true,
+	"skip": true, "sort": true, "spawn": true, "split": true, "start": true,
+	"stop": true, "store": true, "submit": true, "subscribe": true, "sync": true,
+	"track": true, "transform": true, "trim": true, "try": true, "unlock": true,
+	"unmarshal": true, "unmount": true, "unregister": true, "unsubscribe": true, "unwrap": true,
+	"update": true, "upload": true, "upsert": true, "use": true, "validate": true,
+	"verify": true, "visit": true, "wait": true, "walk": true, "watch": true,
+	"wrap": true, "write": true,
+}
+
+// CheckVerbNamingInput represents the input for the function verb naming check
+type CheckVerbNamingInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// VerbNamingOutput represents the result of the function verb naming check
+type VerbNamingOutput struct {
+	Success     bool                `json:"success"`
+	Findings    []VerbNamingFinding `json:"findings"`
+	Count       int                 `json:"count"`
+	Error       string              `json:"error,omitempty"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// VerbNamingFinding represents an exported function with side effects whose
+// name does not start with a verb
+type VerbNamingFinding struct {
+	Function  string `json:"function"`
+	Receiver  string `json:"receiver,omitempty"` // Receiver type name, for methods
+	FirstWord string `json:"first_word"`
+	Severity  string `json:"severity"` // Always "suggestion"
+	Message   string `json:"message"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+}
+
+// CheckFunctionVerbNaming suggests action-oriented names for exported functions
+// and methods that have side effects but whose names do not start with a
+// verb. This is an opinionated style check: findings are suggestions only.
+// Methods named after common interface methods are skipped.
+func CheckFunctionVerbNaming(code string) (*VerbNamingOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &VerbNamingOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	findings := []VerbNamingFinding{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || !fn.Name.IsExported() {
+			continue
+		}
+		receiver := ""
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			receiver = receiverTypeName(fn.Recv.List[0].Type)
+			if wellKnownInterfaceMethods[fn.Name.Name] {
+				continue
+			}
+		}
+
+		word := firstNameWord(fn.Name.Name)
+		if actionVerbs[strings.ToLower(word)] || !hasSideEffects(fn.Body) {
+			continue
+		}
+
+		pos := fset.Position(fn.Name.Pos())
+		findings = append(findings, VerbNamingFinding{
+			Function:  fn.Name.Name,
+			Receiver:  receiver,
+			FirstWord: word,
+			Severity:  "suggestion",
+			Message:   "performs an action but its name does not start with a verb; consider an action-oriented name",
+			Line:      pos.Line,
+			Column:    pos.Column,
+		})
+	}
+
+	return &VerbNamingOutput{
+		Success:  true,
+		Findings: findings,
+		Count:    len(findings),
+	}, nil
+}
+
+// firstNameWord returns the first word of a MixedCaps name, treating a run of
+// capitals as an initialism: "ServeHTTP" gives "Serve", "URLPath" gives "URL"
+func firstNameWord(name string) string {
+	runes := []rune(name)
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) && !unicode.IsDigit(runes[i]) {
+			if i > 1 && unicode.IsUpper(runes[i-1]) {
+				return string(runes[:i-1])
+			}
+			for j := i + 1; j < len(runes); j++ {
+				if unicode.IsUpper(runes[j]) {
+					return string(runes[:j])
+				}
+			}
+			return name
+		}
+	}
+	return name
+}
+
+// hasSideEffects reports whether body does something beyond computing a
+// result: calls made for their effect, assignments through selectors, indexes
+// or pointers, channel sends, increments of non-local state, or go and defer
+// statements
+func hasSideEffects(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch stmt := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ExprStmt:
+			_, found = stmt.X.(*ast.CallExpr)
+		case *ast.SendStmt, *ast.GoStmt, *ast.DeferStmt:
+			found = true
+		case *ast.AssignStmt:
+			if stmt.Tok != token.DEFINE {
+				for _, lhs := range stmt.Lhs {
+					found = found || isNonLocalTarget(lhs)
+				}
+			}
+		case *ast.IncDecStmt:
+			found = isNonLocalTarget(stmt.X)
+		}
+		return !found
+	})
+	return found
+}
+
+// isNonLocalTarget reports whether an assignment target writes through a
+// field, element or pointer rather than to a plain variable
+func isNonLocalTarget(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.SelectorExpr, *ast.IndexExpr, *ast.StarExpr:
+		return true
+	}
+	return false
+}
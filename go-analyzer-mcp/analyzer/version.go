@@ -0,0 +1,4 @@
+package analyzer
+
+// Version is the version of the analyzer reported by the servers
+const Version = "1.0.0"
@@ -0,0 +1,214 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// FindWaitGroupMisuseInput represents the input for sync.WaitGroup misuse detection
+type FindWaitGroupMisuseInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// WaitGroupOutput represents the result of sync.WaitGroup misuse detection
+type WaitGroupOutput struct {
+	Success     bool             `json:"success"`
+	Findings    []WaitGroupIssue `json:"findings"`
+	Count       int              `json:"count"`
+	Error       string           `json:"error,omitempty"`
+	Diagnostics []Diagnostic     `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// WaitGroupIssue represents a single sync.WaitGroup misuse
+type WaitGroupIssue struct {
+	Kind         string `json:"kind"` // "add_in_goroutine" or "done_not_deferred"
+	Variable     string `json:"variable"`
+	Message      string `json:"message"`
+	SuggestedFix string `json:"suggested_fix,omitempty"` // Replacement for the call, when one can be given
+	Line         int    `json:"line"`
+	Column       int    `json:"column"`
+}
+
+// FindWaitGroupMisuse flags wg.Add calls made inside the goroutine they are
+// meant to account for (racing with wg.Wait), and wg.Done calls that are not
+// deferred (skipped on panic or early return). WaitGroups are recognized by
+// their declared type, so variables, parameters and struct fields of type
+// sync.WaitGroup or *sync.WaitGroup are all tracked by name.
+func FindWaitGroupMisuse(code string) (*WaitGroupOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &WaitGroupOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	findings := []WaitGroupIssue{}
+
+	syncName := importName(file, "sync")
+	if syncName != "" {
+		names := waitGroupNames(file, syncName)
+		w := &waitGroupWalker{names: names, fset: fset}
+		w.walk(file, false, false)
+		findings = w.findings
+	}
+
+	return &WaitGroupOutput{
+		Success:  true,
+		Findings: findings,
+		Count:    len(findings),
+	}, nil
+}
+
+// waitGroupNames collects the names of variables, parameters and fields
+// declared with a sync.WaitGroup type
+func waitGroupNames(file *ast.File, syncName string) map[string]bool {
+	names := map[string]bool{}
+	addNames := func(idents []*ast.Ident) {
+		for _, ident := range idents {
+			names[ident.Name] = true
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.Field:
+			if isWaitGroupType(node.Type, syncName) {
+				addNames(node.Names)
+			}
+		case *ast.ValueSpec:
+			if node.Type != nil && isWaitGroupType(node.Type, syncName) {
+				addNames(node.Names)
+				break
+			}
+			for i, value := range node.Values {
+				if i < len(node.Names) && isWaitGroupValue(value, syncName) {
+					names[node.Names[i].Name] = true
+				}
+			}
+		case *ast.AssignStmt:
+			for i, value := range node.Rhs {
+				if i >= len(node.Lhs) || !isWaitGroupValue(value, syncName) {
+					continue
+				}
+				if ident, ok := node.Lhs[i].(*ast.Ident); ok {
+					names[ident.Name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	return names
+}
+
+// isWaitGroupType reports whether expr is sync.WaitGroup or *sync.WaitGroup
+func isWaitGroupType(expr ast.Expr, syncName string) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "WaitGroup" && isPackageIdent(sel.X, syncName)
+}
+
+// isWaitGroupValue reports whether expr constructs a sync.WaitGroup, as in
+// sync.WaitGroup{}, &sync.WaitGroup{} or new(sync.WaitGroup)
+func isWaitGroupValue(expr ast.Expr, syncName string) bool {
+	switch e := expr.(type) {
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return isWaitGroupValue(e.X, syncName)
+		}
+	case *ast.CompositeLit:
+		return e.Type != nil && isWaitGroupType(e.Type, syncName)
+	case *ast.CallExpr:
+		if ident, ok := e.Fun.(*ast.Ident); ok && ident.Name == "new" && len(e.Args) == 1 {
+			return isWaitGroupType(e.Args[0], syncName)
+		}
+	}
+	return false
+}
+
+// waitGroupWalker walks the AST tracking whether the current node is inside a
+// goroutine body or a deferred call
+type waitGroupWalker struct {
+	names    map[string]bool
+	fset     *token.FileSet
+	findings []WaitGroupIssue
+}
+
+func (w *waitGroupWalker) walk(node ast.Node, inGoroutine, deferred bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == node {
+			return true
+		}
+		switch stmt := n.(type) {
+		case *ast.GoStmt:
+			// Arguments are evaluated by the launching goroutine; only the
+			// function literal's body runs in the new goroutine
+			for _, arg := range stmt.Call.Args {
+				w.walk(arg, inGoroutine, deferred)
+			}
+			if lit, ok := stmt.Call.Fun.(*ast.FuncLit); ok {
+				w.walk(lit.Body, true, false)
+			}
+			return false
+
+		case *ast.DeferStmt:
+			w.walk(stmt.Call, inGoroutine, true)
+			return false
+
+		case *ast.CallExpr:
+			sel, ok := stmt.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			variable := waitGroupReceiver(sel.X)
+			if variable == "" || !w.names[variable] {
+				return true
+			}
+			switch {
+			case sel.Sel.Name == "Add" && inGoroutine:
+				w.report(stmt, "add_in_goroutine", variable,
+					"Add is called inside the goroutine it accounts for and may race with Wait; call Add before the go statement", "")
+			case sel.Sel.Name == "Done" && !deferred:
+				fix := "defer " + types.ExprString(sel) + "()"
+				w.report(stmt, "done_not_deferred", variable,
+					"Done is not deferred and is skipped on panic or early return; use "+fix, fix)
+			}
+		}
+		return true
+	})
+}
+
+func (w *waitGroupWalker) report(call *ast.CallExpr, kind, variable, message, fix string) {
+	pos := w.fset.Position(call.Pos())
+	w.findings = append(w.findings, WaitGroupIssue{
+		Kind:         kind,
+		Variable:     variable,
+		Message:      message,
+		SuggestedFix: fix,
+		Line:         pos.Line,
+		Column:       pos.Column,
+	})
+}
+
+// waitGroupReceiver returns the name identifying the WaitGroup in a method
+// call receiver: the variable name for wg, or the field name for s.wg
+func waitGroupReceiver(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.ParenExpr:
+		return waitGroupReceiver(e.X)
+	case *ast.StarExpr:
+		return waitGroupReceiver(e.X)
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// workDirs holds the scratch directories go vet runs in. Its size caps the
+// number of analyses, and so vet subprocesses, running at once.
+var workDirs = newWorkDirPool(runtime.NumCPU())
+
+// workDirPool hands out a bounded set of scratch directories under one root,
+// emptying each directory when it is returned so it can be reused
+type workDirPool struct {
+	slots chan string // Free directories; "" is a slot not yet backed by one
+
+	mu   sync.Mutex
+	root string
+}
+
+func newWorkDirPool(size int) *workDirPool {
+	p := &workDirPool{slots: make(chan string, size)}
+	for range size {
+		p.slots <- ""
+	}
+	return p
+}
+
+// acquire blocks until a directory is free and returns it along with the
+// function that hands it back. The directory is empty.
+func (p *workDirPool) acquire() (string, func(), error) {
+	dir := <-p.slots
+
+	// The directory may be gone if the pool was cleaned up since it was freed
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			dir = ""
+		}
+	}
+	if dir == "" {
+		var err error
+		if dir, err = p.newDir(); err != nil {
+			p.slots <- ""
+			return "", nil, err
+		}
+	}
+
+	return dir, func() { p.release(dir) }, nil
+}
+
+// newDir creates a directory under the pool root, creating the root first if needed
+func (p *workDirPool) newDir() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.root == "" {
+		root, err := os.MkdirTemp("", "go-analyzer-*")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		p.root = root
+	}
+	dir, err := os.MkdirTemp(p.root, "work-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	return dir, nil
+}
+
+// release empties dir and returns it to the pool. A directory that cannot be
+// emptied is discarded and its slot recreated on next use.
+func (p *workDirPool) release(dir string) {
+	entries, err := os.ReadDir(dir)
+	for _, entry := range entries {
+		if err == nil {
+			err = os.RemoveAll(filepath.Join(dir, entry.Name()))
+		}
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		dir = ""
+	}
+	p.slots <- dir
+}
+
+// cleanup removes the pool root and every directory under it
+func (p *workDirPool) cleanup() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.root == "" {
+		return nil
+	}
+	err := os.RemoveAll(p.root)
+	p.root = ""
+	return err
+}
+
+// CleanupWorkDirs removes the scratch directories used for analysis. Servers
+// call it on shutdown; analyses still running will fail.
+func CleanupWorkDirs() error {
+	return workDirs.cleanup()
+}
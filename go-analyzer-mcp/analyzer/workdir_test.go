@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestWorkDirPoolConcurrent is meant to be run with -race
+func TestWorkDirPoolConcurrent(t *testing.T) {
+	const size = 3
+	pool := newWorkDirPool(size)
+	t.Cleanup(func() { pool.cleanup() })
+
+	var (
+		active, peak atomic.Int32
+		mu           sync.Mutex
+		held         = map[string]bool{}
+		wg           sync.WaitGroup
+	)
+	for g := range 12 {
+		wg.Go(func() {
+			for range 10 {
+				dir, release, err := pool.acquire()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := active.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+
+				mu.Lock()
+				if held[dir] {
+					t.Errorf("directory %s handed out twice", dir)
+				}
+				held[dir] = true
+				mu.Unlock()
+
+				if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+					t.Errorf("acquired directory %s holds %d entries, %v", dir, len(entries), err)
+				}
+				if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+					t.Error(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "sub", "f.go"), []byte{byte(g)}, 0o644); err != nil {
+					t.Error(err)
+				}
+
+				mu.Lock()
+				delete(held, dir)
+				mu.Unlock()
+				active.Add(-1)
+				release()
+			}
+		})
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > size {
+		t.Errorf("%d directories in use at once, want at most %d", p, size)
+	}
+}
+
+func TestWorkDirPoolAfterCleanup(t *testing.T) {
+	pool := newWorkDirPool(1)
+	t.Cleanup(func() { pool.cleanup() })
+
+	dir, release, err := pool.acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if err := pool.cleanup(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The freed directory went with the root, so a new one is made
+	dir2, release, err := pool.acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if dir2 == dir {
+		t.Errorf("acquired removed directory %s again", dir)
+	}
+	if _, err := os.Stat(dir2); err != nil {
+		t.Errorf("acquired directory is missing: %v", err)
+	}
+}
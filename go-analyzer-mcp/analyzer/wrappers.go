@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// FindTrivialWrappersInput represents the input for trivial wrapper detection
+type FindTrivialWrappersInput struct {
+	Code     string `json:"code,omitempty" jsonschema:"Go source code to analyze"`
+	FilePath string `json:"filePath,omitempty" jsonschema:"Alternative to code: path of a Go file to read, relative to the source root; code is used when both are set"`
+}
+
+// WrapperOutput represents the result of trivial wrapper detection
+type WrapperOutput struct {
+	Success     bool             `json:"success"`
+	Wrappers    []TrivialWrapper `json:"wrappers"`
+	Count       int              `json:"count"`
+	Error       string           `json:"error,omitempty"`
+	Diagnostics []Diagnostic     `json:"diagnostics,omitempty"` // Syntax errors when parsing fails
+}
+
+// TrivialWrapper represents a function whose body only forwards its arguments
+// unchanged to another function
+type TrivialWrapper struct {
+	Name     string `json:"name"`
+	Receiver string `json:"receiver,omitempty"` // For methods
+	Callee   string `json:"callee"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// wellKnownInterfaceMethods are method names from common standard library
+// interfaces; exported methods with these names are assumed to exist to
+// satisfy an interface and are never reported
+var wellKnownInterfaceMethods = map[string]bool{
+	"String": true, "GoString": true, "Error": true, "Unwrap": true, "Format": true,
+	"Read": true, "Write": true, "Close": true, "Seek": true, "ReadFrom": true, "WriteTo": true,
+	"Len": true, "Less": true, "Swap": true, "Push": true, "Pop": true,
+	"ServeHTTP": true, "RoundTrip": true,
+	"MarshalJSON": true, "UnmarshalJSON": true, "MarshalText": true, "UnmarshalText": true,
+}
+
+// FindTrivialWrappers finds functions whose body is a single call (or return of
+// a call) that forwards every parameter unchanged and in order, making them
+// candidates for inlining. Exported methods that appear to implement an
+// interface are excluded.
+func FindTrivialWrappers(code string) (*WrapperOutput, error) {
+	code, _ = normalizeSource(code)
+	file, fset, err := ParseAST(code)
+	if err != nil {
+		return &WrapperOutput{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: parseErrorsToDiagnostics(err),
+		}, nil
+	}
+
+	// Method names declared by interfaces in this file
+	interfaceMethods := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if iface, ok := n.(*ast.InterfaceType); ok {
+			for _, field := range iface.Methods.List {
+				for _, name := range field.Names {
+					interfaceMethods[name.Name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	wrappers := []TrivialWrapper{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		isMethod := fn.Recv != nil && len(fn.Recv.List) > 0
+		if isMethod && fn.Name.IsExported() &&
+			(interfaceMethods[fn.Name.Name] || wellKnownInterfaceMethods[fn.Name.Name]) {
+			continue
+		}
+
+		call := forwardedCall(fn)
+		if call == nil {
+			continue
+		}
+
+		pos := fset.Position(fn.Pos())
+		wrapper := TrivialWrapper{
+			Name:   fn.Name.Name,
+			Callee: types.ExprString(call.Fun),
+			Line:   pos.Line,
+			Column: pos.Column,
+		}
+		if isMethod {
+			wrapper.Receiver = types.ExprString(fn.Recv.List[0].Type)
+		}
+		wrappers = append(wrappers, wrapper)
+	}
+
+	return &WrapperOutput{
+		Success:  true,
+		Wrappers: wrappers,
+		Count:    len(wrappers),
+	}, nil
+}
+
+// forwardedCall returns the call expression if fn's body consists solely of a
+// call (or a return of a call) passing fn's parameters through unchanged
+func forwardedCall(fn *ast.FuncDecl) *ast.CallExpr {
+	if len(fn.Body.List) != 1 {
+		return nil
+	}
+
+	var expr ast.Expr
+	switch stmt := fn.Body.List[0].(type) {
+	case *ast.ExprStmt:
+		expr = stmt.X
+	case *ast.ReturnStmt:
+		if len(stmt.Results) != 1 {
+			return nil
+		}
+		expr = stmt.Results[0]
+	default:
+		return nil
+	}
+
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	// Immediately-invoked literals and conversions like T(x) are not wrappers
+	switch call.Fun.(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr:
+	default:
+		return nil
+	}
+	if ident, ok := call.Fun.(*ast.Ident); ok {
+		if ident.Name == fn.Name.Name {
+			return nil
+		}
+		if _, isType := types.Universe.Lookup(ident.Name).(*types.TypeName); isType {
+			return nil
+		}
+	}
+
+	// Collect parameter names in order; blank or unnamed parameters cannot be
+	// forwarded, so a function that has them is not a pure wrapper
+	var params []string
+	variadic := false
+	for _, field := range fn.Type.Params.List {
+		if len(field.Names) == 0 {
+			return nil
+		}
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				return nil
+			}
+			params = append(params, name.Name)
+		}
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			variadic = true
+		}
+	}
+
+	if len(call.Args) != len(params) || variadic != call.Ellipsis.IsValid() {
+		return nil
+	}
+	for i, arg := range call.Args {
+		ident, ok := arg.(*ast.Ident)
+		if !ok || ident.Name != params[i] {
+			return nil
+		}
+	}
+
+	return call
+}
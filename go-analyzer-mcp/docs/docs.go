@@ -43,29 +43,45 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/analyzer.AnalyzeCodeOutput"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.AnalyzeCodeOutput"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     }
                 }
             }
         },
-        "/api/go/format": {
+        "/api/go/analyze/batch": {
             "post": {
-                "description": "Format Go code using gofmt",
+                "description": "Analyze several files with go vet in one call. Files are analyzed concurrently; files that cannot be analyzed are reported under errors without failing the batch. No further files are started once the client disconnects.",
                 "consumes": [
                     "application/json"
                 ],
@@ -75,15 +91,15 @@ const docTemplate = `{
                 "tags": [
                     "Go Analyzer"
                 ],
-                "summary": "Format Go code",
+                "summary": "Analyze Go files in a batch",
                 "parameters": [
                     {
-                        "description": "Code to format",
+                        "description": "Files to analyze",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/analyzer.FormatCodeInput"
+                            "$ref": "#/definitions/analyzer.AnalyzeBatchInput"
                         }
                     }
                 ],
@@ -91,29 +107,45 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/analyzer.FormatCodeOutput"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.AnalyzeBatchOutput"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     }
                 }
             }
         },
-        "/api/go/metrics": {
+        "/api/go/ast.json": {
             "post": {
-                "description": "Calculate code metrics including cyclomatic complexity",
+                "description": "Parse Go code and return its syntax tree as nested nodes with type, positions and children. Large trees are truncated.",
                 "consumes": [
                     "application/json"
                 ],
@@ -123,15 +155,15 @@ const docTemplate = `{
                 "tags": [
                     "Go Analyzer"
                 ],
-                "summary": "Calculate metrics",
+                "summary": "Get AST as JSON",
                 "parameters": [
                     {
-                        "description": "Code to analyze",
+                        "description": "Code to parse",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/analyzer.CalculateMetricsInput"
+                            "$ref": "#/definitions/analyzer.ASTJSONInput"
                         }
                     }
                 ],
@@ -139,29 +171,45 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/analyzer.CalculateMetricsOutput"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.ASTJSONOutput"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     }
                 }
             }
         },
-        "/api/go/symbols": {
+        "/api/go/explain": {
             "post": {
-                "description": "Extract symbols (functions, types, variables) from Go code",
+                "description": "Run the AST-based checks and return their findings as diagnostics tagged with the check name, including a suggested_fix with replacement code where one can be given",
                 "consumes": [
                     "application/json"
                 ],
@@ -171,7 +219,7 @@ const docTemplate = `{
                 "tags": [
                     "Go Analyzer"
                 ],
-                "summary": "Extract symbols",
+                "summary": "Explain findings with suggested fixes",
                 "parameters": [
                     {
                         "description": "Code to analyze",
@@ -179,7 +227,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/analyzer.GetSymbolsInput"
+                            "$ref": "#/definitions/analyzer.ExplainInput"
                         }
                     }
                 ],
@@ -187,225 +235,1499 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/analyzer.GetSymbolsOutput"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.ExplainOutput"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     }
                 }
             }
         },
-        "/description": {
-            "get": {
-                "description": "Returns the complete OpenAPI 3.0 specification",
+        "/api/go/format": {
+            "post": {
+                "description": "Format Go code using gofmt",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documentation"
+                    "Go Analyzer"
+                ],
+                "summary": "Format Go code",
+                "parameters": [
+                    {
+                        "description": "Code to format",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/analyzer.FormatCodeInput"
+                        }
+                    }
                 ],
-                "summary": "Get OpenAPI specification",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.FormatCodeOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "analyzer.AnalyzeCodeInput": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "fileName": {
-                    "type": "string"
-                }
-            }
         },
-        "analyzer.AnalyzeCodeOutput": {
-            "type": "object",
-            "properties": {
-                "diagnostics": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/analyzer.Diagnostic"
+        "/api/go/format-check": {
+            "post": {
+                "description": "Report whether Go code is gofmt-clean, with a unified diff when it is not",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Go Analyzer"
+                ],
+                "summary": "Check Go formatting",
+                "parameters": [
+                    {
+                        "description": "Code to check",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/analyzer.CheckFormatInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.CheckFormatOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
                     }
-                },
-                "error_count": {
-                    "type": "integer"
-                },
-                "success": {
-                    "type": "boolean"
-                },
-                "warning_count": {
-                    "type": "integer"
                 }
             }
         },
-        "analyzer.CalculateMetricsInput": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
+        "/api/go/lint": {
+            "post": {
+                "description": "Lint Go code with golangci-lint when it is on the server's PATH, optionally restricted to the given linters. Falls back to go vet otherwise; backend names the tool that ran.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Go Analyzer"
+                ],
+                "summary": "Lint Go code",
+                "parameters": [
+                    {
+                        "description": "Code to lint",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/analyzer.LintCodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.LintCodeOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    }
                 }
             }
         },
-        "analyzer.CalculateMetricsOutput": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "function_metrics": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/analyzer.FunctionMetrics"
+        "/api/go/metrics": {
+            "post": {
+                "description": "Calculate code metrics including cyclomatic complexity",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Go Analyzer"
+                ],
+                "summary": "Calculate metrics",
+                "parameters": [
+                    {
+                        "description": "Code to analyze",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/analyzer.CalculateMetricsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.CalculateMetricsOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
                     }
-                },
-                "metrics": {
-                    "$ref": "#/definitions/analyzer.CodeMetrics"
-                },
-                "success": {
-                    "type": "boolean"
                 }
             }
         },
-        "analyzer.CodeMetrics": {
-            "type": "object",
-            "properties": {
-                "average_complexity": {
-                    "type": "number"
-                },
-                "blank_lines": {
-                    "type": "integer"
-                },
+        "/api/go/module/analyze": {
+            "post": {
+                "description": "Extract a zip archive of a Go module (with go.mod at its root or in a single top-level folder) and run go vet ./... over it, returning diagnostics grouped by file",
+                "consumes": [
+                    "application/zip"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Go Analyzer"
+                ],
+                "summary": "Analyze a zipped module",
+                "parameters": [
+                    {
+                        "description": "Zip archive of the module",
+                        "name": "module",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated build tags",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target GOOS",
+                        "name": "goos",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target GOARCH",
+                        "name": "goarch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.ModuleAnalysisOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/go/schema/{tool}": {
+            "get": {
+                "description": "Returns the JSON Schemas of the input and output of an MCP tool, such as get_symbols, for clients that validate requests. The input schema also describes the request body of the matching HTTP endpoint.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documentation"
+                ],
+                "summary": "Get the JSON Schemas of a tool",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tool name, such as get_symbols",
+                        "name": "tool",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/go/symbols": {
+            "post": {
+                "description": "Extract symbols (functions, types, variables) from Go code. With Accept: application/x-ndjson, symbols are streamed as newline-delimited JSON, one Symbol per line, as they are found.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Go Analyzer"
+                ],
+                "summary": "Extract symbols",
+                "parameters": [
+                    {
+                        "description": "Code to analyze",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/analyzer.GetSymbolsInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/x-ndjson to stream one symbol per line",
+                        "name": "Accept",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.apiResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/analyzer.GetSymbolsOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/description": {
+            "get": {
+                "description": "Returns the complete OpenAPI 3.0 specification",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documentation"
+                ],
+                "summary": "Get OpenAPI specification",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Returns 200 while the server is able to handle requests",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Returns 200 when the go toolchain can be run, or 503 when it is missing, since most analyses shell out to go. The check is cached for 5 seconds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Returns the analyzer version, the Go toolchain version, and which of the go, goimports, gofumpt and golangci-lint tools are available. Tool availability is cached for up to a minute.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Version information",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.apiResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "analyzer.ASTJSONInput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "filePath": {
+                    "type": "string"
+                }
+            }
+        },
+        "analyzer.ASTJSONOutput": {
+            "type": "object",
+            "properties": {
+                "diagnostics": {
+                    "description": "Syntax errors when parsing fails",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "max_nodes": {
+                    "type": "integer"
+                },
+                "node_count": {
+                    "type": "integer"
+                },
+                "root": {
+                    "$ref": "#/definitions/analyzer.ASTNode"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "truncated": {
+                    "description": "Set when the tree was cut off at MaxNodes",
+                    "type": "boolean"
+                }
+            }
+        },
+        "analyzer.ASTNode": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.ASTNode"
+                    }
+                },
+                "end": {
+                    "$ref": "#/definitions/analyzer.ASTPos"
+                },
+                "field": {
+                    "description": "Field of the parent holding this node, e.g. \"Body\"",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "For identifiers",
+                    "type": "string"
+                },
+                "start": {
+                    "$ref": "#/definitions/analyzer.ASTPos"
+                },
+                "token": {
+                    "description": "Operator, keyword or literal kind",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "go/ast type name, e.g. \"FuncDecl\"",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Literal value or comment text",
+                    "type": "string"
+                }
+            }
+        },
+        "analyzer.ASTPos": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.AnalyzeBatchInput": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.AnalyzeCodeInput"
+                    }
+                }
+            }
+        },
+        "analyzer.AnalyzeBatchOutput": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "error_count": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "description": "Files that could not be analyzed, keyed by file name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "results": {
+                    "description": "Keyed by file name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/analyzer.AnalyzeCodeOutput"
+                    }
+                },
+                "success": {
+                    "description": "Whether every file was analyzed without diagnostics",
+                    "type": "boolean"
+                },
+                "warning_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.AnalyzeCodeInput": {
+            "type": "object",
+            "properties": {
+                "buildTags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "filePath": {
+                    "type": "string"
+                },
+                "goarch": {
+                    "type": "string"
+                },
+                "goos": {
+                    "type": "string"
+                },
+                "moduleContext": {
+                    "$ref": "#/definitions/analyzer.ModuleContext"
+                },
+                "noCache": {
+                    "type": "boolean"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "verbose": {
+                    "type": "boolean"
+                },
+                "vetChecks": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "vetFlags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "analyzer.AnalyzeCodeOutput": {
+            "type": "object",
+            "properties": {
+                "diagnostics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "error_count": {
+                    "type": "integer"
+                },
+                "exit_code": {
+                    "description": "Exit status of go vet, only with Verbose",
+                    "type": "integer"
+                },
+                "raw_output": {
+                    "description": "What go vet wrote, only with Verbose",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analyzer.RawVetOutput"
+                        }
+                    ]
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "warning_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.CalculateMetricsInput": {
+            "type": "object",
+            "properties": {
+                "bestEffort": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "complexityThreshold": {
+                    "type": "integer"
+                },
+                "endLine": {
+                    "type": "integer"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "filePath": {
+                    "type": "string"
+                },
+                "files": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "histogramBounds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "minComplexity": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "noCache": {
+                    "type": "boolean"
+                },
+                "startLine": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.CalculateMetricsOutput": {
+            "type": "object",
+            "properties": {
+                "complexity_histogram": {
+                    "description": "Number of functions by cyclomatic complexity range, e.g. \"6-10\" or \"21+\"",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "complexity_threshold": {
+                    "description": "0 when flagging is disabled",
+                    "type": "integer"
+                },
+                "diagnostics": {
+                    "description": "Syntax errors when parsing fails or, in best-effort mode, that were skipped",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fragment": {
+                    "description": "\"declarations\", \"statements\" or \"expression\" when the code was parsed as a fragment",
+                    "type": "string"
+                },
+                "function_metrics": {
+                    "description": "In source order, or most complex first when filtered by MinComplexity",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.FunctionMetrics"
+                    }
+                },
+                "high_complexity_functions": {
+                    "description": "Functions at or above ComplexityThreshold",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.FunctionMetrics"
+                    }
+                },
+                "histogram_bounds": {
+                    "description": "Upper bounds of the histogram buckets but the last",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "lines": {
+                    "description": "Lines measured when restricted by StartLine and EndLine",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analyzer.LineRange"
+                        }
+                    ]
+                },
+                "metrics": {
+                    "$ref": "#/definitions/analyzer.CodeMetrics"
+                },
+                "min_complexity": {
+                    "description": "Complexity FunctionMetrics was filtered by, if any",
+                    "type": "integer"
+                },
+                "partial": {
+                    "description": "In best-effort mode, the code had syntax errors, listed in Diagnostics",
+                    "type": "boolean"
+                },
+                "production": {
+                    "description": "Non-test files only, when test files are present",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analyzer.CodeMetrics"
+                        }
+                    ]
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "test": {
+                    "description": "_test.go files only",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analyzer.CodeMetrics"
+                        }
+                    ]
+                },
+                "todos": {
+                    "description": "TODO, FIXME, XXX and HACK comments, in file and line order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.TodoComment"
+                    }
+                }
+            }
+        },
+        "analyzer.CheckFormatInput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "filePath": {
+                    "type": "string"
+                },
+                "omitDiff": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "analyzer.CheckFormatOutput": {
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "type": "string"
+                },
+                "divergent_lines": {
+                    "description": "Input lines where the first divergences from gofmt start",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "formatted": {
+                    "type": "boolean"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "analyzer.CodeMetrics": {
+            "type": "object",
+            "properties": {
+                "average_complexity": {
+                    "type": "number"
+                },
+                "benchmark_count": {
+                    "type": "integer"
+                },
+                "blank_lines": {
+                    "type": "integer"
+                },
                 "comment_lines": {
+                    "description": "Lines containing only comments",
+                    "type": "integer"
+                },
+                "function_count": {
+                    "type": "integer"
+                },
+                "fuzz_target_count": {
+                    "type": "integer"
+                },
+                "halstead": {
+                    "description": "Over the whole token stream of all files",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analyzer.HalsteadMetrics"
+                        }
+                    ]
+                },
+                "lines_of_code": {
+                    "description": "Physical lines, including blanks and comments",
+                    "type": "integer"
+                },
+                "longest_function_lines": {
+                    "type": "integer"
+                },
+                "longest_function_name": {
+                    "type": "string"
+                },
+                "maintainability_index": {
+                    "description": "0-100, from the overall Halstead volume, total complexity and SLOC",
+                    "type": "number"
+                },
+                "max_complexity": {
+                    "type": "integer"
+                },
+                "source_lines_of_code": {
+                    "description": "Lines containing code, even with a trailing comment",
+                    "type": "integer"
+                },
+                "test_function_count": {
+                    "description": "Test, benchmark and fuzz functions are only counted in _test.go files",
+                    "type": "integer"
+                },
+                "todo_count": {
+                    "description": "Comment lines with a TODO, FIXME, XXX or HACK marker",
+                    "type": "integer"
+                },
+                "total_complexity": {
+                    "type": "integer"
+                },
+                "type_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.Diagnostic": {
+            "type": "object",
+            "properties": {
+                "check": {
+                    "description": "Check that reported the diagnostic, when known: a vet analyzer such as \"printf\", or \"typecheck\" for compile errors",
+                    "type": "string"
+                },
+                "column": {
+                    "type": "integer"
+                },
+                "file": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "description": "Stable across edits that only move the diagnostic",
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "severity": {
+                    "description": "\"error\" or \"warning\"",
+                    "type": "string"
+                },
+                "suggested_fix": {
+                    "description": "Replacement code for the flagged construct, when one can be given",
+                    "type": "string"
+                }
+            }
+        },
+        "analyzer.ExplainInput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "analyzer.ExplainOutput": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "diagnostics": {
+                    "description": "Syntax errors when parsing fails",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "findings": {
+                    "description": "Sorted by position; Check names the check",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "fix_count": {
+                    "description": "Findings with a SuggestedFix",
                     "type": "integer"
                 },
-                "function_count": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "analyzer.FileDiagnostics": {
+            "type": "object",
+            "properties": {
+                "diagnostics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "file": {
+                    "description": "Relative to the module root",
+                    "type": "string"
+                }
+            }
+        },
+        "analyzer.FormatCodeInput": {
+            "type": "object",
+            "properties": {
+                "bestEffort": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "filePath": {
+                    "type": "string"
+                },
+                "formatter": {
+                    "type": "string"
+                },
+                "returnDiff": {
+                    "type": "boolean"
+                },
+                "simplify": {
+                    "type": "boolean"
+                },
+                "tabWidth": {
                     "type": "integer"
+                }
+            }
+        },
+        "analyzer.FormatCodeOutput": {
+            "type": "object",
+            "properties": {
+                "bom": {
+                    "description": "The input started with a UTF-8 byte order mark, kept in the output",
+                    "type": "boolean"
                 },
-                "lines_of_code": {
-                    "type": "integer"
+                "canonical_code": {
+                    "description": "Tab-indented gofmt output, set when TabWidth expands tabs",
+                    "type": "string"
                 },
-                "max_complexity": {
-                    "type": "integer"
+                "changed": {
+                    "description": "Whether formatting changed the input",
+                    "type": "boolean"
                 },
-                "total_complexity": {
-                    "type": "integer"
+                "crlf": {
+                    "description": "The input had CRLF line endings, kept in the output",
+                    "type": "boolean"
                 },
-                "type_count": {
+                "diagnostics": {
+                    "description": "Syntax errors when formatting fails",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "diff": {
+                    "description": "Unified diff from the input, when ReturnDiff is set and the code changed",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "formatted_code": {
+                    "type": "string"
+                },
+                "formatter": {
+                    "description": "The formatter that ran: gofmt, goimports or gofumpt",
+                    "type": "string"
+                },
+                "note": {
+                    "description": "Explains which region was left unformatted, or why gofmt ran instead of the requested formatter",
+                    "type": "string"
+                },
+                "partial": {
+                    "description": "Only the code before UnformattedFrom was formatted, in best-effort mode",
+                    "type": "boolean"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "syntax_error": {
+                    "description": "First syntax error, when the code does not parse",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/analyzer.SyntaxError"
+                        }
+                    ]
+                },
+                "unformatted_from": {
+                    "description": "First input line of the region left unchanged",
                     "type": "integer"
+                },
+                "used_goimports": {
+                    "description": "Whether goimports organized the imports",
+                    "type": "boolean"
                 }
             }
         },
-        "analyzer.Diagnostic": {
+        "analyzer.FunctionMetrics": {
             "type": "object",
             "properties": {
-                "column": {
+                "cognitive_complexity": {
+                    "description": "SonarSource cognitive complexity, which penalizes nesting",
+                    "type": "integer"
+                },
+                "cyclomatic_complexity": {
+                    "type": "integer"
+                },
+                "end_line": {
+                    "description": "Line of the closing brace",
                     "type": "integer"
                 },
                 "file": {
+                    "description": "Originating file, for multi-file requests",
                     "type": "string"
                 },
+                "halstead": {
+                    "$ref": "#/definitions/analyzer.HalsteadMetrics"
+                },
+                "is_exported": {
+                    "type": "boolean"
+                },
+                "is_method": {
+                    "type": "boolean"
+                },
                 "line": {
                     "type": "integer"
                 },
-                "message": {
+                "lines_of_code": {
+                    "type": "integer"
+                },
+                "maintainability_index": {
+                    "type": "number"
+                },
+                "max_nesting_depth": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
-                "severity": {
-                    "description": "\"error\" or \"warning\"",
+                "parameter_count": {
+                    "description": "Not counting the receiver; a, b int counts as 2",
+                    "type": "integer"
+                },
+                "receiver": {
+                    "description": "Receiver type name, for methods",
                     "type": "string"
+                },
+                "return_count": {
+                    "description": "Results, counting named results like parameters",
+                    "type": "integer"
+                },
+                "source_lines_of_code": {
+                    "type": "integer"
                 }
             }
         },
-        "analyzer.FormatCodeInput": {
+        "analyzer.GetSymbolsInput": {
             "type": "object",
             "properties": {
+                "bestEffort": {
+                    "type": "boolean"
+                },
                 "code": {
                     "type": "string"
+                },
+                "endLine": {
+                    "type": "integer"
+                },
+                "filePath": {
+                    "type": "string"
+                },
+                "files": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "noCache": {
+                    "type": "boolean"
+                },
+                "startLine": {
+                    "type": "integer"
                 }
             }
         },
-        "analyzer.FormatCodeOutput": {
+        "analyzer.GetSymbolsOutput": {
             "type": "object",
             "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "diagnostics": {
+                    "description": "Syntax errors when parsing fails or, in best-effort mode, that were skipped",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
                 "error": {
                     "type": "string"
                 },
-                "formatted_code": {
+                "exported_count": {
+                    "description": "Symbols whose own name is exported",
+                    "type": "integer"
+                },
+                "fragment": {
+                    "description": "\"declarations\", \"statements\" or \"expression\" when the code was parsed as a fragment",
                     "type": "string"
                 },
+                "partial": {
+                    "description": "In best-effort mode, the code had syntax errors, listed in Diagnostics",
+                    "type": "boolean"
+                },
                 "success": {
                     "type": "boolean"
+                },
+                "symbols": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Symbol"
+                    }
+                },
+                "unexported_count": {
+                    "description": "Symbols whose own name is unexported",
+                    "type": "integer"
                 }
             }
         },
-        "analyzer.FunctionMetrics": {
+        "analyzer.HalsteadMetrics": {
             "type": "object",
             "properties": {
-                "cyclomatic_complexity": {
+                "distinct_operands": {
                     "type": "integer"
                 },
-                "line": {
+                "distinct_operators": {
                     "type": "integer"
                 },
-                "lines_of_code": {
+                "total_operands": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "total_operators": {
+                    "type": "integer"
+                },
+                "volume": {
+                    "description": "(TotalOperators+TotalOperands) * log2(DistinctOperators+DistinctOperands)",
+                    "type": "number"
                 }
             }
         },
-        "analyzer.GetSymbolsInput": {
+        "analyzer.LineRange": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.LintCodeInput": {
             "type": "object",
             "properties": {
                 "code": {
                     "type": "string"
                 },
-                "filter": {
+                "filePath": {
                     "type": "string"
+                },
+                "linters": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "analyzer.GetSymbolsOutput": {
+        "analyzer.LintCodeOutput": {
             "type": "object",
             "properties": {
-                "count": {
+                "backend": {
+                    "description": "\"golangci-lint\", or \"go vet\" when golangci-lint is not on PATH",
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
+                    }
+                },
+                "error_count": {
                     "type": "integer"
                 },
+                "success": {
+                    "type": "boolean"
+                },
+                "warning_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.ModuleAnalysisOutput": {
+            "type": "object",
+            "properties": {
                 "error": {
                     "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
+                "error_count": {
+                    "type": "integer"
                 },
-                "symbols": {
+                "files": {
+                    "description": "Diagnostics grouped by file, in file name order",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/analyzer.Symbol"
+                        "$ref": "#/definitions/analyzer.FileDiagnostics"
+                    }
+                },
+                "general": {
+                    "description": "Diagnostics without a file position",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.Diagnostic"
                     }
+                },
+                "module_path": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "warning_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.ModuleContext": {
+            "type": "object",
+            "properties": {
+                "goMod": {
+                    "type": "string"
+                },
+                "goSum": {
+                    "type": "string"
+                },
+                "tidy": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "analyzer.RawVetOutput": {
+            "type": "object",
+            "properties": {
+                "stderr": {
+                    "type": "string"
+                },
+                "stdout": {
+                    "type": "string"
                 }
             }
         },
@@ -415,6 +1737,14 @@ const docTemplate = `{
                 "column": {
                     "type": "integer"
                 },
+                "doc": {
+                    "description": "Doc comment text, without comment markers",
+                    "type": "string"
+                },
+                "file": {
+                    "description": "Originating file, for multi-file requests",
+                    "type": "string"
+                },
                 "kind": {
                     "description": "\"function\", \"type\", \"const\", \"var\", \"method\", \"struct\", \"interface\"",
                     "type": "string"
@@ -422,6 +1752,13 @@ const docTemplate = `{
                 "line": {
                     "type": "integer"
                 },
+                "methods": {
+                    "description": "For types, methods declared in any of the files",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
@@ -435,6 +1772,67 @@ const docTemplate = `{
                 "type_name": {
                     "description": "For methods, fields",
                     "type": "string"
+                },
+                "type_params": {
+                    "description": "For generic functions and types, e.g. \"[K comparable, V any]\"",
+                    "type": "string"
+                }
+            }
+        },
+        "analyzer.SyntaxError": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "analyzer.TodoComment": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "file": {
+                    "description": "Originating file, for multi-file requests",
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "marker": {
+                    "description": "The marker as configured, e.g. \"TODO\"",
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Name in parentheses after the marker, as in TODO(alice)",
+                    "type": "string"
+                },
+                "text": {
+                    "description": "Text following the marker up to the end of the line",
+                    "type": "string"
+                }
+            }
+        },
+        "main.apiResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "Set on errors, to match them with the server log",
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
                 }
             }
         }
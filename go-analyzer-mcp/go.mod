@@ -2,7 +2,10 @@ module github.com/jorda/go-analyzer-mcp
 
 go 1.25.2
 
-require github.com/modelcontextprotocol/go-sdk v1.4.0
+require (
+	github.com/modelcontextprotocol/go-sdk v1.4.0
+	golang.org/x/tools v0.41.0
+)
 
 require (
 	github.com/KyleBanks/depth v1.2.1 // indirect
@@ -18,12 +21,11 @@ require (
 	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/oauth2 v0.34.0 // indirect
 	golang.org/x/sys v0.40.0 // indirect
-	golang.org/x/tools v0.41.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
 
 require (
-	github.com/google/jsonschema-go v0.4.2 // indirect
+	github.com/google/jsonschema-go v0.4.2
 	github.com/swaggo/http-swagger v1.3.4
 	github.com/swaggo/swag v1.16.3
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
@@ -1,12 +1,26 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"mime"
 	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/jorda/go-analyzer-mcp/analyzer"
 	_ "github.com/jorda/go-analyzer-mcp/docs" // Import generated docs
+	"github.com/jorda/go-analyzer-mcp/tools"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -18,21 +32,83 @@ const serverPort = "7300"
 // @host localhost:7300
 // @BasePath /
 func main() {
+	// Level of the structured request log on stderr: debug, info (default), warn or error
+	level := slog.LevelInfo
+	if name := os.Getenv("LOG_LEVEL"); name != "" {
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			log.Fatalf("Invalid LOG_LEVEL %q: use debug, info, warn or error", name)
+		}
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	// Formatter paths, cache size, input size limit and source root
+	if err := analyzer.ConfigureFromEnv(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Clients of the API cannot have the server read its own disk: the
+	// filePath, path and directory inputs are rejected with status 400
+	analyzer.NoPathInputs = true
+
 	http.HandleFunc("/description", handleDescription)
+	http.HandleFunc("/api/go/schema/{tool}", handleToolSchema)
+	http.HandleFunc("/healthz", handleHealthz)
+	http.HandleFunc("/readyz", handleReadyz)
+	http.HandleFunc("/version", handleVersion)
 	http.HandleFunc("/api/go/analyze", handleAnalyzeCode)
+	http.HandleFunc("/api/go/analyze/batch", handleAnalyzeBatch)
 	http.HandleFunc("/api/go/format", handleFormatCode)
+	http.HandleFunc("/api/go/format-check", handleCheckFormat)
 	http.HandleFunc("/api/go/symbols", handleGetSymbols)
 	http.HandleFunc("/api/go/metrics", handleCalculateMetrics)
+	http.HandleFunc("/api/go/ast.json", handleASTJSON)
+	http.HandleFunc("/api/go/explain", handleExplain)
+	http.HandleFunc("/api/go/lint", handleLintCode)
+	http.HandleFunc("/api/go/module/analyze", handleAnalyzeModule)
 
 	// Swagger UI
 	http.Handle("/docs/", httpSwagger.WrapHandler)
 
+	// Stop cleanly on Ctrl+C or SIGTERM, removing scratch directories
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Allow browser clients from the origins in CORS_ALLOWED_ORIGINS, any
+	// origin when it is unset; set it empty to send no CORS headers
+	allowedOrigins, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS")
+	if !ok {
+		allowedOrigins = "*"
+	}
+
+	srv := &http.Server{
+		Addr:    ":" + serverPort,
+		Handler: withRequestLog(withCORS(http.DefaultServeMux, allowedOrigins)),
+	}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		// Give in-flight requests a few seconds to finish
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
 	log.Printf("Go Analyzer HTTP Server starting on port %s", serverPort)
 	log.Printf("OpenAPI documentation available at: http://localhost:%s/description", serverPort)
 	log.Printf("Swagger UI available at: http://localhost:%s/docs/", serverPort)
-	if err := http.ListenAndServe(":"+serverPort, nil); err != nil {
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		<-shutdownDone
+		err = nil
+	}
+	if cleanupErr := analyzer.CleanupWorkDirs(); cleanupErr != nil {
+		log.Printf("Failed to remove scratch directories: %v", cleanupErr)
+	}
+	if err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
+	log.Println("Go Analyzer HTTP Server stopped")
 }
 
 // handleDescription returns the auto-generated OpenAPI spec
@@ -52,6 +128,166 @@ func handleDescription(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "./docs/swagger.json")
 }
 
+// handleToolSchema returns the JSON Schemas of a tool's input and output,
+// inferred from the jsonschema tags of its structs
+// @Summary Get the JSON Schemas of a tool
+// @Description Returns the JSON Schemas of the input and output of an MCP tool, such as get_symbols, for clients that validate requests. The input schema also describes the request body of the matching HTTP endpoint.
+// @Tags Documentation
+// @Produce json
+// @Param tool path string true "Tool name, such as get_symbols"
+// @Success 200 {object} apiResponse
+// @Failure 404 {object} apiResponse
+// @Router /api/go/schema/{tool} [get]
+func handleToolSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.PathValue("tool")
+	schema, ok := tools.Schemas()[name]
+	if !ok {
+		respondError(w, fmt.Sprintf("Unknown tool %q", name), http.StatusNotFound)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"tool":   name,
+		"input":  schema.Input,
+		"output": schema.Output,
+	})
+}
+
+// handleHealthz reports that the server is running
+// @Summary Liveness check
+// @Description Returns 200 while the server is able to handle requests
+// @Tags Health
+// @Produce json
+// @Success 200 {object} apiResponse
+// @Router /healthz [get]
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{"status": "ok"})
+}
+
+// toolchainCheckTTL is how long the outcome of a toolchain check is reused
+const toolchainCheckTTL = 5 * time.Second
+
+// toolchainCheck caches the outcome of the last go version run
+var toolchainCheck struct {
+	mu      sync.Mutex
+	checked time.Time
+	version string
+	err     error
+}
+
+// goToolchainVersion runs go version to verify that the toolchain the
+// analyzers shell out to is installed, reusing the outcome for
+// toolchainCheckTTL so frequent probes do not each start a process
+func goToolchainVersion() (string, error) {
+	toolchainCheck.mu.Lock()
+	defer toolchainCheck.mu.Unlock()
+
+	if time.Since(toolchainCheck.checked) < toolchainCheckTTL {
+		return toolchainCheck.version, toolchainCheck.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), toolchainCheckTTL)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "go", "version").Output()
+	toolchainCheck.version = strings.TrimSpace(string(out))
+	toolchainCheck.err = err
+	toolchainCheck.checked = time.Now()
+	return toolchainCheck.version, toolchainCheck.err
+}
+
+// handleReadyz reports whether the server can serve analysis requests
+// @Summary Readiness check
+// @Description Returns 200 when the go toolchain can be run, or 503 when it is missing, since most analyses shell out to go. The check is cached for 5 seconds.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} apiResponse
+// @Failure 503 {object} apiResponse
+// @Router /readyz [get]
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	version, err := goToolchainVersion()
+	if err != nil {
+		respondError(w, "go toolchain not available: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"status":     "ready",
+		"go_version": version,
+	})
+}
+
+// optionalToolsTTL is how long the availability of optional tools is reused
+const optionalToolsTTL = time.Minute
+
+// optionalTools caches which optional tools were found on PATH
+var optionalTools struct {
+	mu           sync.Mutex
+	checked      time.Time
+	goimports    bool
+	gofumpt      bool
+	golangciLint bool
+}
+
+// optionalToolsAvailable reports whether goimports, gofumpt and golangci-lint,
+// which formatting and lint_code use when present, can be found, looking them
+// up at most once per optionalToolsTTL
+func optionalToolsAvailable() (goimports, gofumpt, golangciLint bool) {
+	optionalTools.mu.Lock()
+	defer optionalTools.mu.Unlock()
+
+	if time.Since(optionalTools.checked) >= optionalToolsTTL {
+		_, err := exec.LookPath(analyzer.GoimportsPath)
+		optionalTools.goimports = err == nil
+		_, err = exec.LookPath(analyzer.GofumptPath)
+		optionalTools.gofumpt = err == nil
+		_, err = exec.LookPath("golangci-lint")
+		optionalTools.golangciLint = err == nil
+		optionalTools.checked = time.Now()
+	}
+	return optionalTools.goimports, optionalTools.gofumpt, optionalTools.golangciLint
+}
+
+// handleVersion reports the analyzer version and the tools it can use
+// @Summary Version information
+// @Description Returns the analyzer version, the Go toolchain version, and which of the go, goimports, gofumpt and golangci-lint tools are available. Tool availability is cached for up to a minute.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} apiResponse
+// @Router /version [get]
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	goVersion, err := goToolchainVersion()
+	goimports, gofumpt, golangciLint := optionalToolsAvailable()
+	respondJSON(w, map[string]interface{}{
+		"version":    analyzer.Version,
+		"go_version": goVersion,
+		"tools": map[string]bool{
+			"go":            err == nil,
+			"goimports":     goimports,
+			"gofumpt":       gofumpt,
+			"golangci-lint": golangciLint,
+		},
+	})
+}
+
 // handleAnalyzeCode analyzes Go code for errors and warnings
 // @Summary Analyze Go code
 // @Description Analyze Go code for errors and warnings using go vet
@@ -59,9 +295,10 @@ func handleDescription(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param request body analyzer.AnalyzeCodeInput true "Code to analyze"
-// @Success 200 {object} analyzer.AnalyzeCodeOutput
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Success 200 {object} apiResponse{data=analyzer.AnalyzeCodeOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
 // @Router /api/go/analyze [post]
 func handleAnalyzeCode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -70,14 +307,49 @@ func handleAnalyzeCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input analyzer.AnalyzeCodeInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	result, err := analyzer.AnalyzeCode(input)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	respondJSON(w, result)
+}
+
+// handleAnalyzeBatch analyzes several Go files concurrently
+// @Summary Analyze Go files in a batch
+// @Description Analyze several files with go vet in one call. Files are analyzed concurrently; files that cannot be analyzed are reported under errors without failing the batch. No further files are started once the client disconnects.
+// @Tags Go Analyzer
+// @Accept json
+// @Produce json
+// @Param request body analyzer.AnalyzeBatchInput true "Files to analyze"
+// @Success 200 {object} apiResponse{data=analyzer.AnalyzeBatchOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
+// @Router /api/go/analyze/batch [post]
+func handleAnalyzeBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	result, err := analyzer.AnalyzeCode(input.Code, input.FileName)
+	var input analyzer.AnalyzeBatchInput
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	result, err := analyzer.AnalyzeBatch(r.Context(), input)
 	if err != nil {
-		respondError(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+	if result.Error != "" {
+		respondError(w, result.Error, http.StatusBadRequest)
 		return
 	}
 
@@ -91,9 +363,10 @@ func handleAnalyzeCode(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param request body analyzer.FormatCodeInput true "Code to format"
-// @Success 200 {object} analyzer.FormatCodeOutput
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Success 200 {object} apiResponse{data=analyzer.FormatCodeOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
 // @Router /api/go/format [post]
 func handleFormatCode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -102,14 +375,45 @@ func handleFormatCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input analyzer.FormatCodeInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &input) {
 		return
 	}
 
-	result, err := analyzer.FormatCode(input.Code)
+	result, err := analyzer.FormatCode(input)
 	if err != nil {
-		respondError(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	respondJSON(w, result)
+}
+
+// handleCheckFormat checks whether Go code is gofmt-clean
+// @Summary Check Go formatting
+// @Description Report whether Go code is gofmt-clean, with a unified diff when it is not
+// @Tags Go Analyzer
+// @Accept json
+// @Produce json
+// @Param request body analyzer.CheckFormatInput true "Code to check"
+// @Success 200 {object} apiResponse{data=analyzer.CheckFormatOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
+// @Router /api/go/format-check [post]
+func handleCheckFormat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input analyzer.CheckFormatInput
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	result, err := analyzer.CheckFormat(input)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
 		return
 	}
 
@@ -118,14 +422,17 @@ func handleFormatCode(w http.ResponseWriter, r *http.Request) {
 
 // handleGetSymbols extracts symbols from Go code
 // @Summary Extract symbols
-// @Description Extract symbols (functions, types, variables) from Go code
+// @Description Extract symbols (functions, types, variables) from Go code. With Accept: application/x-ndjson, symbols are streamed as newline-delimited JSON, one Symbol per line, as they are found.
 // @Tags Go Analyzer
 // @Accept json
 // @Produce json
+// @Produce application/x-ndjson
 // @Param request body analyzer.GetSymbolsInput true "Code to analyze"
-// @Success 200 {object} analyzer.GetSymbolsOutput
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Param Accept header string false "application/x-ndjson to stream one symbol per line"
+// @Success 200 {object} apiResponse{data=analyzer.GetSymbolsOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
 // @Router /api/go/symbols [post]
 func handleGetSymbols(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -134,20 +441,88 @@ func handleGetSymbols(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input analyzer.GetSymbolsInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	if acceptsMediaType(r, ndjsonMediaType) {
+		// A stream has no place for the syntax errors skipped in best-effort mode
+		if input.BestEffort {
+			respondError(w, "bestEffort is not supported when streaming; request JSON to receive the syntax errors", http.StatusBadRequest)
+			return
+		}
+		streamSymbols(w, input)
 		return
 	}
 
-	result, err := analyzer.GetSymbols(input.Code, input.Filter)
+	result, err := analyzer.GetSymbols(input)
 	if err != nil {
-		respondError(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err.Error(), errorStatus(err))
 		return
 	}
 
 	respondJSON(w, result)
 }
 
+// ndjsonMediaType is the media type of newline-delimited JSON
+const ndjsonMediaType = "application/x-ndjson"
+
+// symbolFlushInterval is the number of streamed symbols written between flushes
+const symbolFlushInterval = 256
+
+// acceptsMediaType reports whether the Accept header of r lists mediaType
+// without ruling it out with q=0
+func acceptsMediaType(r *http.Request, mediaType string) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			parsed, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && parsed == mediaType && params["q"] != "0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// streamSymbols writes each symbol as a line of JSON as soon as it is
+// extracted, flushing every symbolFlushInterval symbols, so large listings
+// never sit in memory. Invalid filters and syntax errors are found before
+// any symbol is written and are reported in the usual response envelope.
+func streamSymbols(w http.ResponseWriter, input analyzer.GetSymbolsInput) {
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	written := 0
+	result, err := analyzer.StreamSymbols(input, func(sym analyzer.Symbol) error {
+		if written == 0 {
+			w.Header().Set("Content-Type", ndjsonMediaType)
+		}
+		if err := encoder.Encode(sym); err != nil {
+			return err
+		}
+		written++
+		if written%symbolFlushInterval == 0 {
+			if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Writing failed, most likely because the client went away
+		slog.Warn("streaming symbols failed",
+			"request_id", w.Header().Get(requestIDHeader), "symbols", written, "error", err)
+		return
+	}
+	if !result.Success {
+		respondJSON(w, result)
+		return
+	}
+	if written == 0 {
+		w.Header().Set("Content-Type", ndjsonMediaType)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
 // handleCalculateMetrics calculates code metrics
 // @Summary Calculate metrics
 // @Description Calculate code metrics including cyclomatic complexity
@@ -155,9 +530,10 @@ func handleGetSymbols(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param request body analyzer.CalculateMetricsInput true "Code to analyze"
-// @Success 200 {object} analyzer.CalculateMetricsOutput
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Success 200 {object} apiResponse{data=analyzer.CalculateMetricsOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
 // @Router /api/go/metrics [post]
 func handleCalculateMetrics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -166,30 +542,380 @@ func handleCalculateMetrics(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input analyzer.CalculateMetricsInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	result, err := analyzer.CalculateMetrics(input)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	respondJSON(w, result)
+}
+
+// handleASTJSON returns the syntax tree of Go code as JSON
+// @Summary Get AST as JSON
+// @Description Parse Go code and return its syntax tree as nested nodes with type, positions and children. Large trees are truncated.
+// @Tags Go Analyzer
+// @Accept json
+// @Produce json
+// @Param request body analyzer.ASTJSONInput true "Code to parse"
+// @Success 200 {object} apiResponse{data=analyzer.ASTJSONOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
+// @Router /api/go/ast.json [post]
+func handleASTJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input analyzer.ASTJSONInput
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+	result, err := analyzer.ASTToJSON(code)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	respondJSON(w, result)
+}
+
+// handleExplain reports the findings of the AST-based checks with fix suggestions
+// @Summary Explain findings with suggested fixes
+// @Description Run the AST-based checks and return their findings as diagnostics tagged with the check name, including a suggested_fix with replacement code where one can be given
+// @Tags Go Analyzer
+// @Accept json
+// @Produce json
+// @Param request body analyzer.ExplainInput true "Code to analyze"
+// @Success 200 {object} apiResponse{data=analyzer.ExplainOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
+// @Router /api/go/explain [post]
+func handleExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input analyzer.ExplainInput
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	result, err := analyzer.Explain(input.Code)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	respondJSON(w, result)
+}
+
+// handleLintCode lints Go code with golangci-lint, falling back to go vet
+// @Summary Lint Go code
+// @Description Lint Go code with golangci-lint when it is on the server's PATH, optionally restricted to the given linters. Falls back to go vet otherwise; backend names the tool that ran.
+// @Tags Go Analyzer
+// @Accept json
+// @Produce json
+// @Param request body analyzer.LintCodeInput true "Code to lint"
+// @Success 200 {object} apiResponse{data=analyzer.LintCodeOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 500 {object} apiResponse
+// @Router /api/go/lint [post]
+func handleLintCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input analyzer.LintCodeInput
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+	result, err := analyzer.LintCode(code, input.Linters)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	respondJSON(w, result)
+}
+
+// handleAnalyzeModule runs go vet over an uploaded module
+// @Summary Analyze a zipped module
+// @Description Extract a zip archive of a Go module (with go.mod at its root or in a single top-level folder) and run go vet ./... over it, returning diagnostics grouped by file
+// @Tags Go Analyzer
+// @Accept application/zip
+// @Produce json
+// @Param module body string true "Zip archive of the module"
+// @Param tags query string false "Comma-separated build tags"
+// @Param goos query string false "Target GOOS"
+// @Param goarch query string false "Target GOARCH"
+// @Success 200 {object} apiResponse{data=analyzer.ModuleAnalysisOutput}
+// @Failure 400 {object} apiResponse
+// @Failure 413 {object} apiResponse
+// @Failure 415 {object} apiResponse
+// @Failure 500 {object} apiResponse
+// @Router /api/go/module/analyze [post]
+func handleAnalyzeModule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/zip" {
+		respondError(w, "Content-Type must be application/zip", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, analyzer.MaxModuleZipSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, "Archive too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		respondError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	result, err := analyzer.CalculateMetrics(input.Code)
+	query := r.URL.Query()
+	opts := analyzer.ModuleZipOptions{
+		GOOS:   query.Get("goos"),
+		GOARCH: query.Get("goarch"),
+	}
+	if tags := query.Get("tags"); tags != "" {
+		opts.BuildTags = strings.Split(tags, ",")
+	}
+
+	result, err := analyzer.AnalyzeModuleZip(data, opts)
 	if err != nil {
-		respondError(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, err.Error(), errorStatus(err))
 		return
 	}
 
 	respondJSON(w, result)
 }
 
+// apiResponse is the envelope of every API response. Success reports whether
+// the request was handled; the result of the analysis itself, which has its
+// own success field, is in Data.
+type apiResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	RequestID string      `json:"request_id,omitempty"` // Set on errors, to match them with the server log
+}
+
 func respondJSON(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(data)
+	json.NewEncoder(w).Encode(apiResponse{
+		Success: true,
+		Data:    data,
+	})
 }
 
 func respondError(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": false,
-		"error":   message,
+	json.NewEncoder(w).Encode(apiResponse{
+		Success:   false,
+		Error:     message,
+		RequestID: w.Header().Get(requestIDHeader),
+	})
+}
+
+// withCORS wraps next with CORS headers for the origins in allowed, a
+// comma-separated list or "*" for any origin, and answers their preflight
+// requests. Other origins get no CORS headers, so browsers keep the responses
+// from their pages; with allowed empty, that is every origin.
+func withCORS(next http.Handler, allowed string) http.Handler {
+	origins := map[string]bool{}
+	for _, origin := range strings.Split(allowed, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins[origin] = true
+		}
+	}
+	if len(origins) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		origin := r.Header.Get("Origin")
+		switch {
+		case origins["*"]:
+			header.Set("Access-Control-Allow-Origin", "*")
+		case origins[origin]:
+			header.Add("Vary", "Origin")
+			header.Set("Access-Control-Allow-Origin", origin)
+		default:
+			header.Add("Vary", "Origin")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Set("Access-Control-Expose-Headers", requestIDHeader)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			header.Set("Access-Control-Allow-Headers", "Content-Type, "+requestIDHeader)
+			header.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
+
+// requestIDHeader carries the ID of a request, given by the client or
+// generated, and is set on every response
+const requestIDHeader = "X-Request-ID"
+
+// withRequestLog wraps next to log each request with slog: its ID, method,
+// path, body size, status, response size and duration. A request ID sent by
+// the client is kept when it is a short token, so that requests can be traced
+// through a proxy; otherwise one is generated.
+func withRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = tools.NewRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		start := time.Now()
+		body := &countingBody{ReadCloser: r.Body}
+		r.Body = body
+		lw := &loggedResponse{ResponseWriter: w}
+		next.ServeHTTP(lw, r)
+
+		if lw.status == 0 {
+			lw.status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		switch {
+		case lw.status >= 500:
+			level = slog.LevelError
+		case lw.status >= 400:
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(r.Context(), level, "http request",
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int64("input_bytes", body.n),
+			slog.Int("status", lw.status),
+			slog.Int64("output_bytes", lw.n),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to log
+// and echo: 1 to 64 letters, digits, dots, dashes and underscores
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// countingBody counts the bytes read from a request body
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// loggedResponse records the status and size of a response. Unwrap lets
+// http.ResponseController reach the underlying writer to flush it.
+type loggedResponse struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+func (w *loggedResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggedResponse) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *loggedResponse) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decodeJSON decodes the request body into v, responding with 413 when the
+// body exceeds the input size limit and 400 when it is not valid JSON. The
+// body may be up to twice the limit, leaving room for JSON escaping and the
+// other fields. It reports whether v was decoded.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body := io.Reader(r.Body)
+	if analyzer.MaxInputSize > 0 {
+		body = http.MaxBytesReader(w, r.Body, 2*int64(analyzer.MaxInputSize))
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// errorStatus maps an error returned by the analyzer to an HTTP status:
+// 413 for oversized input, 400 for binary input, 500 otherwise
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, analyzer.ErrInputTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, analyzer.ErrBinaryInput), errors.Is(err, analyzer.ErrPathInputsDisabled):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,18 +2,40 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/jorda/go-analyzer-mcp/analyzer"
 	"github.com/jorda/go-analyzer-mcp/tools"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func main() {
+	// Level of the structured request log on stderr: debug, info (default), warn or error
+	level := slog.LevelInfo
+	if name := os.Getenv("LOG_LEVEL"); name != "" {
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			log.Fatalf("Invalid LOG_LEVEL %q: use debug, info, warn or error", name)
+		}
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	// Formatter paths, cache size, input size limit and source root
+	if err := analyzer.ConfigureFromEnv(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Create server with metadata
 	server := mcp.NewServer(
 		&mcp.Implementation{
 			Name:    "go-analyzer",
-			Version: "1.0.0",
+			Version: analyzer.Version,
 		},
 		nil, // No options yet
 	)
@@ -23,12 +45,74 @@ func main() {
 	tools.RegisterTools(server)
 	log.Println("Tools registered successfully")
 
-	// Run server on stdio transport
-	ctx := context.Background()
-	transport := &mcp.StdioTransport{}
-	
-	log.Println("Starting Go analyzer MCP server...")
-	if err := server.Run(ctx, transport); err != nil {
+	// Stop cleanly on Ctrl+C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Select the transport: stdio (default), streamable HTTP, or SSE
+	transport := os.Getenv("MCP_TRANSPORT")
+	if transport == "" {
+		transport = "stdio"
+	}
+	addr := os.Getenv("MCP_ADDR")
+	if addr == "" {
+		addr = "localhost:8080"
+	}
+
+	var err error
+	switch transport {
+	case "stdio":
+		log.Println("Starting Go analyzer MCP server on stdio transport...")
+		err = server.Run(ctx, &mcp.StdioTransport{})
+	case "http":
+		noPathInputs()
+		log.Printf("Starting Go analyzer MCP server on streamable HTTP transport at http://%s", addr)
+		err = serveHTTP(ctx, addr, mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	case "sse":
+		noPathInputs()
+		log.Printf("Starting Go analyzer MCP server on SSE transport at http://%s", addr)
+		err = serveHTTP(ctx, addr, mcp.NewSSEHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	default:
+		log.Fatalf("Unknown MCP_TRANSPORT %q: use stdio, http, or sse", transport)
+	}
+
+	if cleanupErr := analyzer.CleanupWorkDirs(); cleanupErr != nil {
+		log.Printf("Failed to remove scratch directories: %v", cleanupErr)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Fatalf("Server error: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// noPathInputs disables the tool inputs that read the server's disk, since
+// the network transports do not authenticate their clients. As over the REST
+// API, code must then be sent in the request.
+func noPathInputs() {
+	analyzer.NoPathInputs = true
+	log.Println("filePath, path and directory inputs are disabled on network transports")
+}
+
+// serveHTTP serves handler on addr until ctx is cancelled, then shuts down,
+// giving in-flight requests a few seconds to finish
+func serveHTTP(ctx context.Context, addr string, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	log.Println("Go analyzer MCP server stopped")
+	return nil
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// NewRequestID returns a random identifier for one request, logged with it
+// and returned in error responses, so a user report can be matched to the log
+func NewRequestID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// logToolCalls wraps a tool handler to log each call with slog: the tool,
+// the size of its arguments, how long it took and its outcome. Errors carry
+// the request ID of the call.
+func logToolCalls[In, Out any](name string, handler mcp.ToolHandlerFor[In, Out]) mcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		id := NewRequestID()
+		start := time.Now()
+		res, out, err := handler(ctx, req, input)
+
+		level, outcome := slog.LevelInfo, "ok"
+		switch {
+		case err != nil:
+			level, outcome = slog.LevelWarn, "error"
+		case res != nil && res.IsError:
+			level, outcome = slog.LevelWarn, "tool_error"
+		}
+		attrs := []slog.Attr{
+			slog.String("request_id", id),
+			slog.String("tool", name),
+			slog.Int("input_bytes", len(req.Params.Arguments)),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("outcome", outcome),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+			err = fmt.Errorf("%w\nRequest ID: %s", err, id)
+		}
+		slog.LogAttrs(ctx, level, "tool call", attrs...)
+		return res, out, err
+	}
+}
@@ -0,0 +1,287 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/jorda/go-analyzer-mcp/analyzer"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ToolSchema holds the JSON Schemas of the input and output of a tool
+type ToolSchema struct {
+	Input  *jsonschema.Schema `json:"input"`
+	Output *jsonschema.Schema `json:"output"`
+}
+
+var (
+	// toolSchemas records the schemas of each tool as addTool registers it
+	toolSchemas   = map[string]ToolSchema{}
+	toolSchemasMu sync.Mutex
+	schemasOnce   sync.Once
+)
+
+// recordSchemas records the schemas of a tool. The input schema is recorded
+// before the MCP-only format property is added, so that it also describes
+// the request bodies of the HTTP API. The output schema is inferred from Out
+// unless the tool sets one, as it must for recursive types.
+func recordSchemas[Out any](tool *mcp.Tool) {
+	output, _ := tool.OutputSchema.(*jsonschema.Schema)
+	if output == nil {
+		// Outputs are returned through pointers, described by their element type
+		rt := reflect.TypeFor[Out]()
+		if rt.Kind() == reflect.Pointer {
+			rt = rt.Elem()
+		}
+		var err error
+		output, err = jsonschema.ForType(rt, nil)
+		if err != nil {
+			panic(fmt.Sprintf("output schema of %s: %v", tool.Name, err))
+		}
+	}
+
+	toolSchemasMu.Lock()
+	defer toolSchemasMu.Unlock()
+	toolSchemas[tool.Name] = ToolSchema{
+		Input:  tool.InputSchema.(*jsonschema.Schema).CloneSchemas(),
+		Output: output,
+	}
+}
+
+// Schemas returns the input and output schemas of every tool by name, for
+// clients that validate requests themselves. The tools are registered on a
+// server of their own the first time, so that the schemas are available
+// without an MCP server.
+func Schemas() map[string]ToolSchema {
+	schemasOnce.Do(func() {
+		RegisterTools(mcp.NewServer(&mcp.Implementation{Name: "schemas"}, nil))
+	})
+	toolSchemasMu.Lock()
+	defer toolSchemasMu.Unlock()
+	return maps.Clone(toolSchemas)
+}
+
+// inputSchema infers the input schema of a tool from T's struct tags, then
+// lets customize add what tags cannot express: enums, defaults, bounds and
+// examples. Fields without omitempty are already marked required.
+func inputSchema[T any](customize func(props map[string]*jsonschema.Schema, schema *jsonschema.Schema)) *jsonschema.Schema {
+	schema, err := jsonschema.For[T](nil)
+	if err != nil {
+		panic(fmt.Sprintf("input schema: %v", err))
+	}
+	customize(schema.Properties, schema)
+	return schema
+}
+
+// defaultValue encodes v as a schema default
+func defaultValue(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("schema default: %v", err))
+	}
+	return data
+}
+
+// requireCodeOrFiles requires at least one of the alternative code and files
+// properties
+func requireCodeOrFiles(schema *jsonschema.Schema) {
+	schema.AnyOf = []*jsonschema.Schema{
+		{Required: []string{"code"}},
+		{Required: []string{"files"}},
+	}
+}
+
+// setModeEnum lists the parse modes of a mode property
+func setModeEnum(props map[string]*jsonschema.Schema) {
+	modes := analyzer.Modes()
+	props["mode"].Enum = make([]any, len(modes))
+	for i, mode := range modes {
+		props["mode"].Enum[i] = mode
+	}
+	props["mode"].Default = defaultValue(analyzer.ModeFile)
+}
+
+// setLineRangeBounds bounds the startLine and endLine properties, where 0
+// leaves the range open
+func setLineRangeBounds(props map[string]*jsonschema.Schema) {
+	for _, name := range []string{"startLine", "endLine"} {
+		props[name].Minimum = jsonschema.Ptr(0.0)
+		props[name].Default = defaultValue(0)
+	}
+}
+
+// allowFilePath accepts filePath as an alternative to code, for inputs that
+// can read their code from a file
+func allowFilePath(schema *jsonschema.Schema) {
+	if schema.Properties["filePath"] == nil {
+		return
+	}
+	if schema.AnyOf == nil {
+		schema.AnyOf = []*jsonschema.Schema{{Required: []string{"code"}}}
+	}
+	schema.AnyOf = append(schema.AnyOf, &jsonschema.Schema{Required: []string{"filePath"}})
+}
+
+// targetExamples adds examples to the build target properties
+func targetExamples(props map[string]*jsonschema.Schema) {
+	props["buildTags"].Items.Examples = []any{"integration", "purego"}
+	props["goos"].Examples = []any{"linux", "windows", "darwin"}
+	props["goarch"].Examples = []any{"amd64", "arm64", "386"}
+}
+
+func analyzeCodeInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.AnalyzeCodeInput](func(props map[string]*jsonschema.Schema, schema *jsonschema.Schema) {
+		props["fileName"].Default = defaultValue("temp.go")
+		props["path"].Examples = []any{"/home/me/project/internal/store", "./main.go"}
+		props["vetFlags"].Items.Examples = []any{"-printf=false", "-printf.funcs=Logf"}
+		checks := analyzer.VetAnalyzers()
+		props["vetChecks"].Items.Enum = make([]any, len(checks))
+		for i, check := range checks {
+			props["vetChecks"].Items.Enum[i] = check
+		}
+		targetExamples(props)
+		schema.AnyOf = []*jsonschema.Schema{
+			{Required: []string{"code"}},
+			{Required: []string{"path"}},
+		}
+	})
+}
+
+func applyFixesInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.ApplyFixesInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		names := analyzer.FixAnalyzers()
+		props["analyzers"].Items.Enum = make([]any, len(names))
+		for i, name := range names {
+			props["analyzers"].Items.Enum[i] = name
+		}
+		props["goVersion"].Examples = []any{"go1.21", "go1.24"}
+	})
+}
+
+func formatCodeInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.FormatCodeInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["tabWidth"].Minimum = jsonschema.Ptr(0.0)
+		props["tabWidth"].Default = defaultValue(0)
+		props["fileName"].Default = defaultValue("temp.go")
+		formatters := analyzer.Formatters()
+		props["formatter"].Enum = make([]any, len(formatters))
+		for i, formatter := range formatters {
+			props["formatter"].Enum[i] = formatter
+		}
+		props["formatter"].Default = defaultValue(analyzer.FormatterGofmt)
+	})
+}
+
+// getSymbolsInputSchema advertises the filter kinds as an enum for clients
+// that offer a choice of values. Since the filter may also list several
+// kinds, a comma-separated list of them is accepted as an alternative. The
+// pattern sticks to syntax shared by Go and ECMAScript regular expressions,
+// so kinds must be lower case.
+func getSymbolsInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.GetSymbolsInput](func(props map[string]*jsonschema.Schema, schema *jsonschema.Schema) {
+		kinds := analyzer.SymbolFilters()
+		enum := make([]any, len(kinds))
+		for i, kind := range kinds {
+			enum[i] = kind
+		}
+		kind := `\s*(` + strings.Join(kinds, "|") + `)?\s*`
+
+		props["filter"].AnyOf = []*jsonschema.Schema{
+			{Enum: enum},
+			{Pattern: `^` + kind + `(,` + kind + `)*$`},
+		}
+		props["filter"].Default = defaultValue("all")
+		props["filter"].Examples = []any{"function", "struct,interface"}
+		setModeEnum(props)
+		setLineRangeBounds(props)
+		requireCodeOrFiles(schema)
+	})
+}
+
+func calculateMetricsInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.CalculateMetricsInput](func(props map[string]*jsonschema.Schema, schema *jsonschema.Schema) {
+		props["complexityThreshold"].Minimum = jsonschema.Ptr(0.0)
+		props["complexityThreshold"].Default = defaultValue(analyzer.DefaultComplexityThreshold)
+		props["minComplexity"].Minimum = jsonschema.Ptr(0.0)
+		props["minComplexity"].Examples = []any{10, 15}
+		props["histogramBounds"].Items.Minimum = jsonschema.Ptr(1.0)
+		props["histogramBounds"].Default = defaultValue(analyzer.DefaultHistogramBounds())
+		props["histogramBounds"].Examples = []any{[]int{3, 6, 9, 12}}
+		setModeEnum(props)
+		setLineRangeBounds(props)
+		requireCodeOrFiles(schema)
+	})
+}
+
+func importFanoutInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.ImportFanoutInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["directory"].MinLength = jsonschema.Ptr(1)
+		props["threshold"].Minimum = jsonschema.Ptr(0.0)
+		props["threshold"].Default = defaultValue(analyzer.DefaultFanoutThreshold)
+		props["modulePath"].Examples = []any{"github.com/example/project"}
+		targetExamples(props)
+	})
+}
+
+func findTodosInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.FindTodosInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["markers"].Items.MinLength = jsonschema.Ptr(1)
+		props["markers"].Default = defaultValue(analyzer.DefaultTodoMarkers())
+	})
+}
+
+func findFatInterfacesInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.FindFatInterfacesInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["maxMethods"].Minimum = jsonschema.Ptr(0.0)
+		props["maxMethods"].Default = defaultValue(analyzer.DefaultMaxInterfaceMethods)
+	})
+}
+
+func riskScoreInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.RiskScoreInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		for _, weight := range props["weights"].Properties {
+			weight.Minimum = jsonschema.Ptr(0.0)
+		}
+		props["top"].Minimum = jsonschema.Ptr(0.0)
+		props["top"].Default = defaultValue(analyzer.DefaultRiskTop)
+	})
+}
+
+func dumpASTInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.DumpASTInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["maxDepth"].Minimum = jsonschema.Ptr(0.0)
+		props["maxDepth"].Default = defaultValue(0)
+	})
+}
+
+func findLongFunctionsInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.FindLongFunctionsInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["maxLines"].Minimum = jsonschema.Ptr(0.0)
+		props["maxLines"].Default = defaultValue(analyzer.DefaultMaxFunctionLines)
+	})
+}
+
+func checkImplementsInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.CheckImplementsInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["typeName"].MinLength = jsonschema.Ptr(1)
+		props["typeName"].Examples = []any{"FileStore", "*FileStore"}
+		props["interfaceName"].MinLength = jsonschema.Ptr(1)
+		props["interfaceName"].Examples = []any{"Store", "io.Reader", "fmt.Stringer", "error"}
+	})
+}
+
+func styleCheckInputSchema() *jsonschema.Schema {
+	return inputSchema[analyzer.StyleCheckInput](func(props map[string]*jsonschema.Schema, _ *jsonschema.Schema) {
+		props["nakedReturnLines"].Minimum = jsonschema.Ptr(0.0)
+		props["nakedReturnLines"].Default = defaultValue(analyzer.DefaultNakedReturnLines)
+		props["maxStatements"].Minimum = jsonschema.Ptr(0.0)
+		props["maxStatements"].Default = defaultValue(analyzer.DefaultMaxStatements)
+		props["maxParams"].Minimum = jsonschema.Ptr(0.0)
+		props["maxParams"].Default = defaultValue(analyzer.DefaultMaxParams)
+	})
+}
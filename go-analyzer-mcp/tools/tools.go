@@ -2,8 +2,13 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/jorda/go-analyzer-mcp/analyzer"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -11,40 +16,452 @@ import (
 // RegisterTools registers all Go analyzer tools with the MCP server
 func RegisterTools(server *mcp.Server) {
 	// Tool 1: Analyze Code (go vet)
-	mcp.AddTool(server,
+	addTool(server,
 		&mcp.Tool{
 			Name:        "analyze_code",
-			Description: "Analyze Go code for errors and warnings using go vet",
+			Description: "Analyze Go code, or a Go file or package directory on disk, for errors and warnings using go vet",
+			InputSchema: analyzeCodeInputSchema(),
 		},
 		handleAnalyzeCode,
 	)
 
 	// Tool 2: Format Code (gofmt)
-	mcp.AddTool(server,
+	addTool(server,
 		&mcp.Tool{
 			Name:        "format_code",
-			Description: "Format Go code using gofmt",
+			Description: "Format Go code using gofmt, or with goimports or gofumpt when selected and installed",
+			InputSchema: formatCodeInputSchema(),
 		},
 		handleFormatCode,
 	)
 
 	// Tool 3: Get Symbols
-	mcp.AddTool(server,
+	addTool(server,
 		&mcp.Tool{
 			Name:        "get_symbols",
 			Description: "Extract symbols (functions, types, variables) from Go code",
+			InputSchema: getSymbolsInputSchema(),
 		},
 		handleGetSymbols,
 	)
 
 	// Tool 4: Calculate Metrics
-	mcp.AddTool(server,
+	addTool(server,
 		&mcp.Tool{
 			Name:        "calculate_metrics",
 			Description: "Calculate code metrics including cyclomatic complexity and lines of code",
+			InputSchema: calculateMetricsInputSchema(),
 		},
 		handleCalculateMetrics,
 	)
+
+	// Tool 5: Find Detached Context
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_detached_context",
+			Description: "Find context.Background()/context.TODO() calls inside functions that already receive a context.Context",
+		},
+		handleFindDetachedContext,
+	)
+
+	// Tool 6: Check Format
+	addTool(server,
+		&mcp.Tool{
+			Name:        "check_format",
+			Description: "Check whether Go code is gofmt-clean, returning a unified diff instead of the formatted file",
+		},
+		handleCheckFormat,
+	)
+
+	// Tool 7: Doc Coverage
+	addTool(server,
+		&mcp.Tool{
+			Name:        "doc_coverage",
+			Description: "Report doc comment presence and length for each exported symbol, plus overall documentation coverage",
+		},
+		handleDocCoverage,
+	)
+
+	// Tool 8: Get Imports
+	addTool(server,
+		&mcp.Tool{
+			Name:        "get_imports",
+			Description: "Report the package name of Go code and list its imports grouped into standard library, third-party, and intra-module, with aliases and dot/blank markers",
+		},
+		handleGetImports,
+	)
+
+	// Tool 9: Find Trivial Wrappers
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_trivial_wrappers",
+			Description: "Find functions that only forward their arguments unchanged to another function, as inlining candidates",
+		},
+		handleFindTrivialWrappers,
+	)
+
+	// Tool 10: Format With Imports
+	addTool(server,
+		&mcp.Tool{
+			Name:        "format_with_imports",
+			Description: "Format Go code and organize imports using goimports, falling back to gofmt when goimports is unavailable",
+		},
+		handleFormatWithImports,
+	)
+
+	// Tool 11: Check Templates
+	addTool(server,
+		&mcp.Tool{
+			Name:        "check_templates",
+			Description: "Parse text/template and html/template literals passed to template.New(...).Parse and report template syntax errors with their source position",
+		},
+		handleCheckTemplates,
+	)
+
+	// Tool 12: Find WaitGroup Misuse
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_waitgroup_misuse",
+			Description: "Find sync.WaitGroup Add calls inside the launched goroutine and Done calls that are not deferred",
+		},
+		handleFindWaitGroupMisuse,
+	)
+
+	// Tool 13: Import Fan-out Report
+	addTool(server,
+		&mcp.Tool{
+			Name:        "import_fanout_report",
+			Description: "Report how many distinct packages each Go file in a directory imports, flagging files above a threshold",
+			InputSchema: importFanoutInputSchema(),
+		},
+		handleImportFanout,
+	)
+
+	// Tool 14: Find TODOs
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_todos",
+			Description: "Find TODO, FIXME, HACK and XXX markers (or custom markers) in line and block comments, with their text and position",
+			InputSchema: findTodosInputSchema(),
+		},
+		handleFindTodos,
+	)
+
+	// Tool 15: AST as JSON
+	addTool(server,
+		&mcp.Tool{
+			Name:         "ast_json",
+			Description:  "Parse Go code and return its syntax tree as JSON nodes with type, positions and children, for client-side tree views or queries",
+			OutputSchema: astJSONOutputSchema(),
+		},
+		handleASTToJSON,
+	)
+
+	// Tool 16: Check Nolint Justification
+	addTool(server,
+		&mcp.Tool{
+			Name:        "check_nolint_justification",
+			Description: "Flag //nolint directives that lack a justification comment after '//'",
+		},
+		handleCheckNolint,
+	)
+
+	// Tool 17: Check Function Verb Naming
+	addTool(server,
+		&mcp.Tool{
+			Name:        "check_function_verb_naming",
+			Description: "Opinionated style check: suggest action-oriented names for exported functions with side effects whose names do not start with a verb",
+		},
+		handleCheckFunctionVerbNaming,
+	)
+
+	// Tool 18: Diff Symbols
+	addTool(server,
+		&mcp.Tool{
+			Name:        "diff_symbols",
+			Description: "Compare the symbols of two versions of a Go file and report added, removed and changed functions, methods, types, constants and variables",
+		},
+		handleDiffSymbols,
+	)
+
+	// Tool 19: Find Deferred Return Mutation
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_deferred_return_mutation",
+			Description: "Find deferred closures that assign to the named results of their enclosing function, silently replacing the returned values",
+		},
+		handleFindDeferredReturnMutation,
+	)
+
+	// Tool 20: Call Graph
+	addTool(server,
+		&mcp.Tool{
+			Name:        "call_graph",
+			Description: "Build the intra-file call graph: which functions and methods call which, the imported functions each calls, and functions never called within the file",
+		},
+		handleCallGraph,
+	)
+
+	// Tool 21: Find Assertionless Tests
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_assertionless_tests",
+			Description: "Find test functions that never call t.Error, t.Fatal, t.Fail or an assertion library, and so pass vacuously",
+		},
+		handleFindAssertionlessTests,
+	)
+
+	// Tool 22: Lint Code (golangci-lint, falling back to go vet)
+	addTool(server,
+		&mcp.Tool{
+			Name:        "lint_code",
+			Description: "Lint Go code with golangci-lint when it is installed, falling back to go vet; the result names the backend that ran",
+		},
+		handleLintCode,
+	)
+
+	// Tool 23: Metrics For Hunks
+	addTool(server,
+		&mcp.Tool{
+			Name:        "metrics_for_hunks",
+			Description: "Report the functions each changed line range (diff hunk) touches, with their cyclomatic complexity and other metrics",
+		},
+		handleMetricsForHunks,
+	)
+
+	// Tool 24: Suggest Test Skeleton
+	addTool(server,
+		&mcp.Tool{
+			Name:        "suggest_test_skeleton",
+			Description: "Generate a table-driven _test.go skeleton with t.Run subtests for the exported functions and methods in the code",
+		},
+		handleSuggestTestSkeleton,
+	)
+
+	// Tool 25: Find Fat Interfaces
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_fat_interfaces",
+			Description: "Find interfaces declaring more methods than a limit (default 5), which are candidates for splitting into smaller interfaces",
+			InputSchema: findFatInterfacesInputSchema(),
+		},
+		handleFindFatInterfaces,
+	)
+
+	// Tool 26: Find Map Order Dependency
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_map_order_dependency",
+			Description: "Heuristically flag range loops over maps that append to a slice which is later returned or compared, where the random iteration order likely matters",
+		},
+		handleFindMapOrderDependency,
+	)
+
+	// Tool 27: Rename Symbol
+	addTool(server,
+		&mcp.Tool{
+			Name:        "rename_symbol",
+			Description: "Rename a symbol declared in a single Go file and every reference to it, resolved with go/types so shadowing locals are left alone, and return the rewritten source",
+		},
+		handleRenameSymbol,
+	)
+
+	// Tool 28: Risk Score
+	addTool(server,
+		&mcp.Tool{
+			Name:        "risk_score",
+			Description: "Rank functions by a 0-100 risk score combining cyclomatic and cognitive complexity, nesting depth, length and parameter count, with configurable weights",
+			InputSchema: riskScoreInputSchema(),
+		},
+		handleRiskScore,
+	)
+
+	// Tool 29: Find Shadowed Variables
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_shadowed_variables",
+			Description: "Find local variables, parameters and results that shadow an outer variable or a package-level or imported name, optionally ignoring err",
+		},
+		handleFindShadowedVariables,
+	)
+
+	// Tool 30: Find Unchecked Errors
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_unchecked_errors",
+			Description: "Find calls whose error result is ignored or assigned to _, resolving functions declared in the file and in the standard library",
+		},
+		handleFindUncheckedErrors,
+	)
+
+	// Tool 31: Dump AST
+	addTool(server,
+		&mcp.Tool{
+			Name:        "dump_ast",
+			Description: "Print the syntax tree of Go code as ast.Fprint does, optionally cut off below a nesting depth, for debugging tools built on the parser",
+			InputSchema: dumpASTInputSchema(),
+		},
+		handleDumpAST,
+	)
+
+	// Tool 32: Find Long Functions
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_long_functions",
+			Description: "List only the functions longer than a line limit, longest first, without computing the other metrics",
+			InputSchema: findLongFunctionsInputSchema(),
+		},
+		handleFindLongFunctions,
+	)
+
+	// Tool 33: Scan Security
+	addTool(server,
+		&mcp.Tool{
+			Name:        "scan_security",
+			Description: "Flag common insecure patterns with gosec rule IDs: hardcoded credentials, exec.Command with variable or concatenated arguments, math/rand for secrets, md5 and sha1 use, and http.ListenAndServe without timeouts",
+		},
+		handleScanSecurity,
+	)
+
+	// Tool 34: Check Implements
+	addTool(server,
+		&mcp.Tool{
+			Name:        "check_implements",
+			Description: "Check with go/types whether a type declared in the code implements an interface from the code or the standard library, listing missing methods, mismatched signatures and pointer receivers",
+			InputSchema: checkImplementsInputSchema(),
+		},
+		handleCheckImplements,
+	)
+
+	// Tool 35: Get Doc Comments
+	addTool(server,
+		&mcp.Tool{
+			Name:        "get_doc_comments",
+			Description: "Extract the doc comment of each exported function, method, type, constant and variable as plain text, with an empty doc for undocumented symbols",
+		},
+		handleGetDocComments,
+	)
+
+	// Tool 36: Apply Fixes
+	addTool(server,
+		&mcp.Tool{
+			Name:        "apply_fixes",
+			Description: "Apply the suggested fixes of go/analysis analyzers (modernize, printf, assign, unreachable and others) to Go code and return the fixed code with a diff. Fixes are applied in one pass; overlapping fixes are skipped.",
+			InputSchema: applyFixesInputSchema(),
+		},
+		handleApplyFixes,
+	)
+
+	// Tool 37: Import Graph
+	addTool(server,
+		&mcp.Tool{
+			Name:        "import_graph",
+			Description: "Build the import graph of several files of one package: the packages each file imports, and how many files import each package, flagging imports used by a single file versus pervasive ones. Useful for judging coupling before splitting a package.",
+		},
+		handleImportGraph,
+	)
+
+	// Tool 38: Compare Symbols
+	addTool(server,
+		&mcp.Tool{
+			Name:        "compare_symbols",
+			Description: "Compare the exported API of two versions of a Go file and flag breaking changes: removed exported symbols, changed function and method signatures, and removed or retyped struct fields. Changes are grouped by kind, for gating pull requests that break a library's public API.",
+		},
+		handleCompareSymbols,
+	)
+
+	// Tool 39: Style Check
+	addTool(server,
+		&mcp.Tool{
+			Name:        "style_check",
+			Description: "Report opinionated style findings that go vet ignores, each with a rule ID: naked returns in long functions, functions with too many statements or parameters, inconsistent receiver names, and exported symbols without doc comments",
+			InputSchema: styleCheckInputSchema(),
+		},
+		handleStyleCheck,
+	)
+
+	// Tool 40: Analyze All
+	addTool(server,
+		&mcp.Tool{
+			Name:        "analyze_all",
+			Description: "Run go vet, gofmt, symbol extraction and metrics on Go code in one call, parsing it once for all of them; the parts match what analyze_code, format_code, get_symbols and calculate_metrics return with their default options",
+		},
+		handleAnalyzeAll,
+	)
+
+	// Tool 41: Check Formatted
+	addTool(server,
+		&mcp.Tool{
+			Name:        "check_formatted",
+			Description: "Report only whether Go code is gofmt-clean and, when not, the lines where it first diverges",
+		},
+		handleCheckFormatted,
+	)
+
+	// Tool 42: Find Shadowed
+	addTool(server,
+		&mcp.Tool{
+			Name:        "find_shadowed",
+			Description: "Find variables declared with := or var that shadow a name of an enclosing scope, returning each name with its line and the line of the declaration it hides",
+		},
+		handleFindShadowed,
+	)
+
+	// Tool 43: Get Package Info
+	addTool(server,
+		&mcp.Tool{
+			Name:        "get_package_info",
+			Description: "Report the package name of Go code and its imports, each with its path, alias and line",
+		},
+		handleGetPackageInfo,
+	)
+}
+
+// Output formats selectable with the format argument every tool accepts
+const (
+	formatText = "text" // Only the human-readable summary
+	formatJSON = "json" // Only the JSON-encoded result
+)
+
+// addTool registers a tool like mcp.AddTool and adds the optional format
+// argument to its input schema. Inputs with a filePath field may give it
+// instead of code. By default a result carries both content
+// blocks built by newToolResult; format keeps just one of them. The
+// structured content is sent either way. Every call is logged.
+func addTool[In, Out any](server *mcp.Server, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) {
+	if tool.InputSchema == nil {
+		tool.InputSchema = inputSchema[In](func(map[string]*jsonschema.Schema, *jsonschema.Schema) {})
+	}
+	allowFilePath(tool.InputSchema.(*jsonschema.Schema))
+	recordSchemas[Out](tool)
+
+	tool.InputSchema.(*jsonschema.Schema).Properties["format"] = &jsonschema.Schema{
+		Type:        "string",
+		Enum:        []any{formatText, formatJSON},
+		Description: "Return only the human-readable text or only the JSON-encoded result (default: both)",
+	}
+
+	mcp.AddTool(server, tool, logToolCalls(tool.Name, func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		res, out, err := handler(ctx, req, input)
+		if err != nil || res == nil || len(res.Content) < 2 {
+			return res, out, err
+		}
+
+		var args struct {
+			Format string `json:"format"`
+		}
+		if len(req.Params.Arguments) > 0 {
+			// The arguments already passed schema validation
+			_ = json.Unmarshal(req.Params.Arguments, &args)
+		}
+		switch args.Format {
+		case formatText:
+			res.Content = res.Content[:len(res.Content)-1]
+		case formatJSON:
+			res.Content = res.Content[len(res.Content)-1:]
+		}
+		return res, out, err
+	}))
 }
 
 // Tool Handlers
@@ -53,138 +470,1960 @@ func handleAnalyzeCode(
 	ctx context.Context,
 	req *mcp.CallToolRequest,
 	input analyzer.AnalyzeCodeInput,
-) (*mcp.CallToolResult, any, error) {
-	result, err := analyzer.AnalyzeCode(input.Code, input.FileName)
+) (*mcp.CallToolResult, *analyzer.AnalyzeCodeOutput, error) {
+	result, err := analyzer.AnalyzeCode(input)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: formatAnalysisResult(result),
-			},
-		},
-	}, result, nil
+	res, err := newToolResult(formatAnalysisResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
 }
 
 func handleFormatCode(
 	ctx context.Context,
 	req *mcp.CallToolRequest,
 	input analyzer.FormatCodeInput,
-) (*mcp.CallToolResult, any, error) {
-	result, err := analyzer.FormatCode(input.Code)
+) (*mcp.CallToolResult, *analyzer.FormatCodeOutput, error) {
+	result, err := analyzer.FormatCode(input)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	if !result.Success {
-		return nil, nil, fmt.Errorf("%s", result.Error)
+		return nil, nil, toolError(result.Error, result.Diagnostics)
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: result.FormattedCode,
-			},
-		},
-	}, result, nil
+	// A requested diff replaces the full file as the readable summary
+	text := result.FormattedCode
+	if input.ReturnDiff {
+		text = result.Diff
+		if !result.Changed {
+			text = "✅ Code is already formatted; no changes"
+		}
+	}
+	if result.Partial {
+		text = "⚠️ Partially formatted: " + result.Note + "\n\n" + text
+	} else if result.Note != "" {
+		text = "⚠️ " + result.Note + "\n\n" + text
+	}
+
+	res, err := newToolResult(text, result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
 }
 
 func handleGetSymbols(
 	ctx context.Context,
 	req *mcp.CallToolRequest,
 	input analyzer.GetSymbolsInput,
-) (*mcp.CallToolResult, any, error) {
-	result, err := analyzer.GetSymbols(input.Code, input.Filter)
+) (*mcp.CallToolResult, *analyzer.GetSymbolsOutput, error) {
+	result, err := analyzer.GetSymbols(input)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	if !result.Success {
-		return nil, nil, fmt.Errorf("%s", result.Error)
+		return nil, nil, toolError(result.Error, result.Diagnostics)
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: formatSymbolsResult(result),
-			},
-		},
-	}, result, nil
+	res, err := newToolResult(formatSymbolsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
 }
 
 func handleCalculateMetrics(
 	ctx context.Context,
 	req *mcp.CallToolRequest,
 	input analyzer.CalculateMetricsInput,
-) (*mcp.CallToolResult, any, error) {
-	result, err := analyzer.CalculateMetrics(input.Code)
+) (*mcp.CallToolResult, *analyzer.CalculateMetricsOutput, error) {
+	result, err := analyzer.CalculateMetrics(input)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	if !result.Success {
-		return nil, nil, fmt.Errorf("%s", result.Error)
+		return nil, nil, toolError(result.Error, result.Diagnostics)
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: formatMetricsResult(result),
-			},
-		},
-	}, result, nil
+	res, err := newToolResult(formatMetricsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
 }
 
-// Helper functions for formatting results
+func handleFindDetachedContext(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindDetachedContextInput,
+) (*mcp.CallToolResult, *analyzer.DetachedCtxOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindDetachedContext(code)
+	if err != nil {
+		return nil, nil, err
+	}
 
-func formatAnalysisResult(result *analyzer.AnalyzeCodeOutput) string {
-	if result.Success {
-		return "✅ No issues found"
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
 	}
 
-	text := fmt.Sprintf("Found %d errors and %d warnings:\n\n", result.ErrorCount, result.WarningCount)
-	for _, diag := range result.Diagnostics {
-		text += fmt.Sprintf("[%s] %s\n", diag.Severity, diag.Message)
+	res, err := newToolResult(formatDetachedContextResult(result), result)
+	if err != nil {
+		return nil, nil, err
 	}
-	return text
+	return res, result, nil
 }
 
-func formatSymbolsResult(result *analyzer.GetSymbolsOutput) string {
-	text := fmt.Sprintf("Found %d symbols:\n\n", result.Count)
-	
-	for _, sym := range result.Symbols {
-		if sym.Signature != "" {
-			text += fmt.Sprintf("%s: %s (line %d)\n", sym.Kind, sym.Signature, sym.Line)
-		} else {
-			text += fmt.Sprintf("%s: %s (line %d)\n", sym.Kind, sym.Name, sym.Line)
-		}
+func handleCheckFormat(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CheckFormatInput,
+) (*mcp.CallToolResult, *analyzer.CheckFormatOutput, error) {
+	result, err := analyzer.CheckFormat(input)
+	if err != nil {
+		return nil, nil, err
 	}
-	
-	return text
-}
 
-func formatMetricsResult(result *analyzer.CalculateMetricsOutput) string {
-	m := result.Metrics
-	text := fmt.Sprintf(`Code Metrics:
-  Lines of Code: %d
-  Comment Lines: %d
-  Blank Lines: %d
-  Function Count: %d
-  Type Count: %d
-  Average Complexity: %.2f
-  Max Complexity: %d
+	if !result.Success {
+		return nil, nil, fmt.Errorf("%s", result.Error)
+	}
 
-`, m.LinesOfCode, m.CommentLines, m.BlankLines, m.FunctionCount, m.TypeCount, m.AverageComplexity, m.MaxComplexity)
+	res, err := newToolResult(formatCheckFormatResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
 
-	if len(result.FunctionMetrics) > 0 {
-		text += "Function Metrics:\n"
-		for _, fm := range result.FunctionMetrics {
-			text += fmt.Sprintf("  %s (line %d): complexity=%d, loc=%d\n",
-				fm.Name, fm.Line, fm.CyclomaticComplexity, fm.LinesOfCode)
-		}
+func handleDocCoverage(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.DocCoverageInput,
+) (*mcp.CallToolResult, *analyzer.DocCoverageOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.DocCoverage(code)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return text
-}
\ No newline at end of file
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatDocCoverageResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+// toolError builds the error returned for a failed analysis, listing each
+// positioned diagnostic on its own line when there are any
+func toolError(message string, diagnostics []analyzer.Diagnostic) error {
+	// Errors without a position, such as invalid input, are reported as-is
+	if len(diagnostics) == 0 || diagnostics[0].Line == 0 {
+		return fmt.Errorf("%s", message)
+	}
+
+	return fmt.Errorf("Found %d syntax errors:\n%s", len(diagnostics), syntaxErrorLines(diagnostics))
+}
+
+// partialResultNote introduces best-effort results computed despite the
+// syntax errors in diagnostics
+func partialResultNote(diagnostics []analyzer.Diagnostic) string {
+	return fmt.Sprintf("⚠️ Partial results: the code has %d syntax errors, and the parts they affect are missing:\n%s\n",
+		len(diagnostics), syntaxErrorLines(diagnostics))
+}
+
+// fragmentNote tells how code parsed as a fragment was wrapped, or returns
+// "" when it was not
+func fragmentNote(fragment string) string {
+	switch fragment {
+	case analyzer.FragmentDeclarations:
+		return "⚠️ Treated as declarations without a package clause\n\n"
+	case analyzer.FragmentStatements:
+		return "⚠️ Treated as statements, wrapped in a function named _\n\n"
+	case analyzer.FragmentExpression:
+		return "⚠️ Treated as an expression, wrapped in a function named _\n\n"
+	}
+	return ""
+}
+
+// syntaxErrorLines lists each diagnostic on its own line with its position
+func syntaxErrorLines(diagnostics []analyzer.Diagnostic) string {
+	text := ""
+	for _, diag := range diagnostics {
+		// Name the file only for multi-file requests
+		if diag.File != "" && diag.File != "temp.go" {
+			text += fmt.Sprintf("  %s line %d, column %d: %s\n", diag.File, diag.Line, diag.Column, diag.Message)
+		} else {
+			text += fmt.Sprintf("  line %d, column %d: %s\n", diag.Line, diag.Column, diag.Message)
+		}
+	}
+	return text
+}
+
+func handleGetImports(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.GetImportsInput,
+) (*mcp.CallToolResult, *analyzer.ImportsOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.GetImports(code, input.ModulePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatImportsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindTrivialWrappers(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindTrivialWrappersInput,
+) (*mcp.CallToolResult, *analyzer.WrapperOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindTrivialWrappers(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatWrappersResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFormatWithImports(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FormatWithImportsInput,
+) (*mcp.CallToolResult, *analyzer.FormatCodeOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FormatCodeWithImports(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatWithImportsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleCheckTemplates(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CheckTemplatesInput,
+) (*mcp.CallToolResult, *analyzer.TemplateOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.CheckTemplates(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatTemplatesResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindWaitGroupMisuse(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindWaitGroupMisuseInput,
+) (*mcp.CallToolResult, *analyzer.WaitGroupOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindWaitGroupMisuse(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatWaitGroupResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleImportFanout(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.ImportFanoutInput,
+) (*mcp.CallToolResult, *analyzer.ImportFanoutOutput, error) {
+	result, err := analyzer.ImportFanout(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, fmt.Errorf("%s", result.Error)
+	}
+
+	res, err := newToolResult(formatImportFanoutResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindTodos(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindTodosInput,
+) (*mcp.CallToolResult, *analyzer.TodosOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindTodos(code, input.Markers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatTodosResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleASTToJSON(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.ASTJSONInput,
+) (*mcp.CallToolResult, *analyzer.ASTJSONOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.ASTToJSON(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatASTJSONResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+// astJSONOutputSchema builds the output schema for ast_json by hand, since
+// schema inference rejects the recursive ASTNode type. Nodes are defined once
+// under $defs and children refer back to that definition.
+func astJSONOutputSchema() *jsonschema.Schema {
+	children := &jsonschema.Schema{
+		Type:  "array",
+		Items: &jsonschema.Schema{Ref: "#/$defs/node"},
+	}
+	node, err := jsonschema.For[analyzer.ASTNode](&jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[[]*analyzer.ASTNode](): children,
+		},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("ast_json node schema: %v", err))
+	}
+
+	schema, err := jsonschema.For[analyzer.ASTJSONOutput](&jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[analyzer.ASTNode](): node,
+		},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("ast_json output schema: %v", err))
+	}
+	schema.Defs = map[string]*jsonschema.Schema{"node": node}
+	return schema
+}
+
+func handleCheckNolint(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CheckNolintInput,
+) (*mcp.CallToolResult, *analyzer.NolintOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.CheckNolintJustification(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatNolintResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleCheckFunctionVerbNaming(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CheckVerbNamingInput,
+) (*mcp.CallToolResult, *analyzer.VerbNamingOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.CheckFunctionVerbNaming(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatVerbNamingResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleDiffSymbols(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.DiffSymbolsInput,
+) (*mcp.CallToolResult, *analyzer.DiffSymbolsOutput, error) {
+	result, err := analyzer.DiffSymbols(input.OldCode, input.NewCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatDiffSymbolsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindDeferredReturnMutation(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindDeferredReturnMutationInput,
+) (*mcp.CallToolResult, *analyzer.DeferReturnOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindDeferredReturnMutation(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatDeferReturnResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleCallGraph(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CallGraphInput,
+) (*mcp.CallToolResult, *analyzer.CallGraphOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.BuildCallGraph(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatCallGraphResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindAssertionlessTests(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindAssertionlessTestsInput,
+) (*mcp.CallToolResult, *analyzer.AssertionlessOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindAssertionlessTests(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatAssertionlessResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleLintCode(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.LintCodeInput,
+) (*mcp.CallToolResult, *analyzer.LintCodeOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.LintCode(code, input.Linters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := newToolResult(formatLintResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleMetricsForHunks(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.MetricsForHunksInput,
+) (*mcp.CallToolResult, *analyzer.HunkMetricsOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.MetricsForHunks(code, input.Hunks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatHunkMetricsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleSuggestTestSkeleton(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.GenerateTestSkeletonInput,
+) (*mcp.CallToolResult, *analyzer.TestSkeletonOutput, error) {
+	result, err := analyzer.GenerateTestSkeleton(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatTestSkeletonResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindFatInterfaces(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindFatInterfacesInput,
+) (*mcp.CallToolResult, *analyzer.FatInterfaceOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindFatInterfaces(code, input.MaxMethods)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatFatInterfaceResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindMapOrderDependency(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindMapOrderDependencyInput,
+) (*mcp.CallToolResult, *analyzer.MapOrderOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindMapOrderDependency(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatMapOrderResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleRenameSymbol(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.RenameSymbolInput,
+) (*mcp.CallToolResult, *analyzer.RenameSymbolOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.RenameSymbol(code, input.OldName, input.NewName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatRenameResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleRiskScore(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.RiskScoreInput,
+) (*mcp.CallToolResult, *analyzer.RiskScoreOutput, error) {
+	result, err := analyzer.RiskScore(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatRiskScoreResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindShadowedVariables(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindShadowedVariablesInput,
+) (*mcp.CallToolResult, *analyzer.ShadowOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindShadowedVariables(code, input.IgnoreErr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatShadowResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindUncheckedErrors(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindUncheckedErrorsInput,
+) (*mcp.CallToolResult, *analyzer.UncheckedErrorsOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindUncheckedErrors(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatUncheckedErrorsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleDumpAST(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.DumpASTInput,
+) (*mcp.CallToolResult, *analyzer.DumpASTOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.DumpAST(code, input.MaxDepth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatDumpASTResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleFindLongFunctions(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindLongFunctionsInput,
+) (*mcp.CallToolResult, *analyzer.LongFunctionsOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindLongFunctions(code, input.MaxLines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatLongFunctionsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleScanSecurity(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.ScanSecurityInput,
+) (*mcp.CallToolResult, *analyzer.ScanSecurityOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.ScanSecurity(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatScanSecurityResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleCheckImplements(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CheckImplementsInput,
+) (*mcp.CallToolResult, *analyzer.ImplementsOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.CheckImplements(code, input.TypeName, input.InterfaceName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatImplementsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleGetDocComments(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.GetDocCommentsInput,
+) (*mcp.CallToolResult, *analyzer.DocCommentsOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.GetDocComments(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatDocCommentsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleApplyFixes(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.ApplyFixesInput,
+) (*mcp.CallToolResult, *analyzer.ApplyFixesOutput, error) {
+	result, err := analyzer.ApplyFixes(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatApplyFixesResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleImportGraph(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.ImportGraphInput,
+) (*mcp.CallToolResult, *analyzer.ImportGraphOutput, error) {
+	result, err := analyzer.BuildImportGraph(input.Files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatImportGraphResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleCompareSymbols(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CompareSymbolsInput,
+) (*mcp.CallToolResult, *analyzer.CompareSymbolsOutput, error) {
+	result, err := analyzer.CompareSymbols(input.OldCode, input.NewCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatCompareSymbolsResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleStyleCheck(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.StyleCheckInput,
+) (*mcp.CallToolResult, *analyzer.StyleCheckOutput, error) {
+	result, err := analyzer.StyleCheck(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatStyleCheckResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleAnalyzeAll(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.AnalyzeAllInput,
+) (*mcp.CallToolResult, *analyzer.AnalyzeAllOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.AnalyzeAll(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatAnalyzeAllResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleCheckFormatted(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.CheckFormattedInput,
+) (*mcp.CallToolResult, *analyzer.CheckFormattedOutput, error) {
+	result, err := analyzer.CheckFormat(analyzer.CheckFormatInput{
+		Code:     input.Code,
+		FilePath: input.FilePath,
+		OmitDiff: true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, fmt.Errorf("%s", result.Error)
+	}
+
+	output := &analyzer.CheckFormattedOutput{
+		Formatted:      result.Formatted,
+		DivergentLines: result.DivergentLines,
+	}
+	res, err := newToolResult(formatCheckFormatResult(result), output)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, output, nil
+}
+
+func handleFindShadowed(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.FindShadowedInput,
+) (*mcp.CallToolResult, *analyzer.FindShadowedOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.FindShadowed(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatFindShadowedResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+func handleGetPackageInfo(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input analyzer.GetPackageInfoInput,
+) (*mcp.CallToolResult, *analyzer.PackageInfoOutput, error) {
+	code, err := analyzer.LoadSource(input.Code, input.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := analyzer.GetPackageInfo(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Success {
+		return nil, nil, toolError(result.Error, result.Diagnostics)
+	}
+
+	res, err := newToolResult(formatPackageInfoResult(result), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, result, nil
+}
+
+// newToolResult builds a tool result carrying the human-readable text followed
+// by the JSON-encoded result, so clients that only read content blocks can still
+// consume the structured output without re-parsing prose. The JSON block must
+// stay last, since addTool drops blocks by position.
+func newToolResult(text string, result any) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+			&mcp.TextContent{Text: string(data)},
+		},
+	}, nil
+}
+
+// Helper functions for formatting results
+
+func formatAnalysisResult(result *analyzer.AnalyzeCodeOutput) string {
+	if result.Success {
+		text := "✅ No issues found"
+		if raw := formatRawVetOutput(result); raw != "" {
+			text += "\n\n" + raw
+		}
+		return text
+	}
+
+	text := fmt.Sprintf("Found %d errors and %d warnings:\n\n", result.ErrorCount, result.WarningCount)
+	for _, diag := range result.Diagnostics {
+		message := diag.Message
+		if diag.Check != "" {
+			message += " (" + diag.Check + ")"
+		}
+		switch {
+		case diag.Column > 0:
+			text += fmt.Sprintf("[%s] %s:%d:%d: %s\n", diag.Severity, diag.File, diag.Line, diag.Column, message)
+		case diag.Line > 0:
+			text += fmt.Sprintf("[%s] %s:%d: %s\n", diag.Severity, diag.File, diag.Line, message)
+		default:
+			text += fmt.Sprintf("[%s] %s\n", diag.Severity, message)
+		}
+	}
+	if raw := formatRawVetOutput(result); raw != "" {
+		text += "\n" + raw
+	}
+	return text
+}
+
+// formatRawVetOutput renders the exit code and output of go vet, when the
+// analysis was verbose
+func formatRawVetOutput(result *analyzer.AnalyzeCodeOutput) string {
+	if result.ExitCode == nil || result.RawOutput == nil {
+		return ""
+	}
+	text := fmt.Sprintf("go vet exit code: %d\n", *result.ExitCode)
+	for _, stream := range []struct{ name, output string }{
+		{"stdout", result.RawOutput.Stdout},
+		{"stderr", result.RawOutput.Stderr},
+	} {
+		if stream.output == "" {
+			text += fmt.Sprintf("\ngo vet %s: (empty)\n", stream.name)
+			continue
+		}
+		text += fmt.Sprintf("\ngo vet %s:\n%s", stream.name, strings.TrimRight(stream.output, "\n")+"\n")
+	}
+	return text
+}
+
+func formatSymbolsResult(result *analyzer.GetSymbolsOutput) string {
+	text := fmt.Sprintf("Found %d symbols (%d exported, %d unexported):\n\n",
+		result.Count, result.ExportedCount, result.UnexportedCount)
+
+	for _, sym := range result.Symbols {
+		location := fmt.Sprintf("line %d", sym.Line)
+		if sym.File != "" {
+			location = fmt.Sprintf("%s:%d", sym.File, sym.Line)
+		}
+		if sym.Signature != "" {
+			text += fmt.Sprintf("%s: %s (%s)\n", sym.Kind, sym.Signature, location)
+		} else {
+			text += fmt.Sprintf("%s: %s%s (%s)\n", sym.Kind, sym.Name, sym.TypeParams, location)
+		}
+		if sym.Doc != "" {
+			summary, _, _ := strings.Cut(sym.Doc, "\n")
+			text += fmt.Sprintf("  // %s\n", summary)
+		}
+		if len(sym.Methods) > 0 {
+			text += fmt.Sprintf("  methods: %s\n", strings.Join(sym.Methods, ", "))
+		}
+	}
+
+	if result.Partial {
+		text = partialResultNote(result.Diagnostics) + text
+	}
+	return fragmentNote(result.Fragment) + text
+}
+
+func formatMetricsResult(result *analyzer.CalculateMetricsOutput) string {
+	m := result.Metrics
+	text := fmt.Sprintf(`Code Metrics:
+  Lines of Code: %d
+  Source Lines of Code: %d
+  Comment Lines: %d
+  Blank Lines: %d
+  TODO Comments: %d
+  Function Count: %d
+  Type Count: %d
+  Average Complexity: %.2f
+  Max Complexity: %d
+  Longest Function: %s (%d lines)
+  Halstead Volume: %.1f
+  Maintainability Index: %.1f
+
+`, m.LinesOfCode, m.SourceLinesOfCode, m.CommentLines, m.BlankLines, m.TodoCount, m.FunctionCount, m.TypeCount, m.AverageComplexity, m.MaxComplexity,
+		m.LongestFunctionName, m.LongestFunctionLines, m.Halstead.Volume, m.MaintainabilityIndex)
+	if result.Lines != nil {
+		text = fmt.Sprintf("Lines %d-%d only\n\n", result.Lines.Start, result.Lines.End) + text
+	}
+
+	if result.Test != nil {
+		text += "Production vs Test:\n"
+		for _, bucket := range []struct {
+			name string
+			m    *analyzer.CodeMetrics
+		}{{"Production", result.Production}, {"Test", result.Test}} {
+			if bucket.m != nil {
+				text += fmt.Sprintf("  %s: sloc=%d, functions=%d, avg complexity=%.2f, max complexity=%d, mi=%.1f\n",
+					bucket.name, bucket.m.SourceLinesOfCode, bucket.m.FunctionCount, bucket.m.AverageComplexity,
+					bucket.m.MaxComplexity, bucket.m.MaintainabilityIndex)
+			}
+		}
+		text += fmt.Sprintf("  Tests: %d, Benchmarks: %d, Fuzz Targets: %d\n\n",
+			result.Test.TestFunctionCount, result.Test.BenchmarkCount, result.Test.FuzzTargetCount)
+	}
+
+	if result.MinComplexity > 0 {
+		text += fmt.Sprintf("Function Metrics (complexity %d and above, most complex first):\n", result.MinComplexity)
+		if len(result.FunctionMetrics) == 0 {
+			text += "  ✅ none\n"
+		}
+	} else if len(result.FunctionMetrics) > 0 {
+		text += "Function Metrics:\n"
+	}
+	for _, fm := range result.FunctionMetrics {
+		name := fm.Name
+		if fm.Receiver != "" {
+			name = fm.Receiver + "." + fm.Name
+		}
+		location := fmt.Sprintf("line %d", fm.Line)
+		if fm.File != "" {
+			location = fmt.Sprintf("%s:%d", fm.File, fm.Line)
+		}
+		marker := ""
+		if result.ComplexityThreshold > 0 && fm.CyclomaticComplexity >= result.ComplexityThreshold {
+			marker = " ⚠️"
+		}
+		text += fmt.Sprintf("  %s (%s): complexity=%d, cognitive=%d, loc=%d, nesting=%d, params=%d, returns=%d, volume=%.1f, mi=%.1f%s\n",
+			name, location, fm.CyclomaticComplexity, fm.CognitiveComplexity, fm.LinesOfCode, fm.MaxNestingDepth,
+			fm.ParameterCount, fm.ReturnCount, fm.Halstead.Volume, fm.MaintainabilityIndex, marker)
+	}
+
+	text += "\nComplexity Distribution:\n"
+	for _, label := range analyzer.HistogramLabels(result.HistogramBounds) {
+		text += fmt.Sprintf("  %s: %d\n", label, result.ComplexityHistogram[label])
+	}
+
+	if len(result.HighComplexityFunctions) > 0 {
+		text += fmt.Sprintf("\n⚠️ %d functions at or above complexity %d:\n",
+			len(result.HighComplexityFunctions), result.ComplexityThreshold)
+		for _, fm := range result.HighComplexityFunctions {
+			text += fmt.Sprintf("  ⚠️ %s (line %d): complexity=%d\n", fm.Name, fm.Line, fm.CyclomaticComplexity)
+		}
+	}
+
+	if len(result.Todos) > 0 {
+		text += fmt.Sprintf("\n%d TODO comments:\n", len(result.Todos))
+		for _, todo := range result.Todos {
+			text += "  " + formatTodo(todo) + "\n"
+		}
+	}
+
+	if result.Partial {
+		text = partialResultNote(result.Diagnostics) + text
+	}
+	return fragmentNote(result.Fragment) + text
+}
+
+func formatDetachedContextResult(result *analyzer.DetachedCtxOutput) string {
+	if result.Count == 0 {
+		return "✅ No detached contexts found"
+	}
+
+	text := fmt.Sprintf("Found %d detached context calls:\n\n", result.Count)
+	for _, f := range result.Findings {
+		text += fmt.Sprintf("  %s (line %d): %s used while %q is available\n",
+			f.Function, f.Line, f.Call, f.ContextParam)
+	}
+	return text
+}
+
+func formatCheckFormatResult(result *analyzer.CheckFormatOutput) string {
+	if result.Formatted {
+		return "✅ Code is gofmt-clean"
+	}
+	lines := make([]string, len(result.DivergentLines))
+	for i, line := range result.DivergentLines {
+		lines[i] = strconv.Itoa(line)
+	}
+	label := "lines"
+	if len(lines) == 1 {
+		label = "line"
+	}
+	text := fmt.Sprintf("❌ Code is not gofmt-clean (differs from gofmt at %s %s)", label, strings.Join(lines, ", "))
+	if result.Diff != "" {
+		text += ":\n\n" + result.Diff
+	}
+	return text
+}
+
+func formatDocCoverageResult(result *analyzer.DocCoverageOutput) string {
+	text := fmt.Sprintf("Documentation coverage: %.1f%% (%d of %d exported symbols documented)\n\n",
+		result.CoveragePercent, result.DocumentedCount, result.ExportedCount)
+	for _, sym := range result.Symbols {
+		if sym.Documented {
+			text += fmt.Sprintf("  ✅ %s %s (line %d): %d words\n", sym.Kind, sym.Name, sym.Line, sym.WordCount)
+		} else {
+			text += fmt.Sprintf("  ❌ %s %s (line %d): undocumented\n", sym.Kind, sym.Name, sym.Line)
+		}
+	}
+	return text
+}
+
+func formatImportsResult(result *analyzer.ImportsOutput) string {
+	text := fmt.Sprintf("Package %s\n\nFound %d imports (%d standard library, %d third-party, %d intra-module):\n\n",
+		result.PackageName, len(result.Imports), result.StdlibCount, result.ThirdPartyCount, result.ModuleCount)
+	for _, imp := range result.Imports {
+		line := fmt.Sprintf("  [%s] %s", imp.Kind, imp.Path)
+		switch {
+		case imp.Dot:
+			line += " (dot import)"
+		case imp.Blank:
+			line += " (blank import)"
+		case imp.Alias != "":
+			line += fmt.Sprintf(" as %s", imp.Alias)
+		}
+		text += line + "\n"
+	}
+	return text
+}
+
+func formatWrappersResult(result *analyzer.WrapperOutput) string {
+	if result.Count == 0 {
+		return "✅ No trivial wrappers found"
+	}
+
+	text := fmt.Sprintf("Found %d trivial wrappers (inlining candidates):\n\n", result.Count)
+	for _, w := range result.Wrappers {
+		name := w.Name
+		if w.Receiver != "" {
+			name = fmt.Sprintf("(%s) %s", w.Receiver, w.Name)
+		}
+		text += fmt.Sprintf("  %s (line %d) forwards to %s\n", name, w.Line, w.Callee)
+	}
+	return text
+}
+
+func formatWithImportsResult(result *analyzer.FormatCodeOutput) string {
+	if !result.UsedGoimports {
+		return "⚠️ goimports is not available; formatted with gofmt without organizing imports\n\n" + result.FormattedCode
+	}
+	return result.FormattedCode
+}
+
+func formatTemplatesResult(result *analyzer.TemplateOutput) string {
+	if result.Count == 0 {
+		return "No template literals found"
+	}
+	if result.ErrorCount == 0 {
+		return fmt.Sprintf("✅ All %d templates parsed successfully", result.Count)
+	}
+
+	text := fmt.Sprintf("Found %d template errors in %d templates:\n\n", result.ErrorCount, result.Count)
+	for _, t := range result.Templates {
+		if t.Valid {
+			continue
+		}
+		name := t.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		text += fmt.Sprintf("  %s (line %d, column %d): %s\n", name, t.Line, t.Column, t.Message)
+	}
+	return text
+}
+
+func formatWaitGroupResult(result *analyzer.WaitGroupOutput) string {
+	if result.Count == 0 {
+		return "✅ No sync.WaitGroup misuse found"
+	}
+
+	text := fmt.Sprintf("Found %d sync.WaitGroup issues:\n\n", result.Count)
+	for _, f := range result.Findings {
+		text += fmt.Sprintf("  [%s] %s (line %d): %s\n", f.Kind, f.Variable, f.Line, f.Message)
+	}
+	return text
+}
+
+func formatImportFanoutResult(result *analyzer.ImportFanoutOutput) string {
+	text := fmt.Sprintf("Import fan-out for %d files in %s (threshold %d, %d flagged):\n\n",
+		result.FileCount, result.Directory, result.Threshold, result.FlaggedCount)
+	for _, f := range result.Files {
+		switch {
+		case f.Error != "":
+			text += fmt.Sprintf("  ❌ %s: %s\n", f.File, f.Error)
+		case f.Flagged:
+			text += fmt.Sprintf("  ⚠️ %s: %d imports (%d stdlib, %d third-party, %d module)\n",
+				f.File, f.ImportCount, f.StdlibCount, f.ThirdPartyCount, f.ModuleCount)
+		default:
+			text += fmt.Sprintf("  %s: %d imports (%d stdlib, %d third-party, %d module)\n",
+				f.File, f.ImportCount, f.StdlibCount, f.ThirdPartyCount, f.ModuleCount)
+		}
+	}
+	return text
+}
+
+func formatTodosResult(result *analyzer.TodosOutput) string {
+	if result.Count == 0 {
+		return "✅ No TODO comments found"
+	}
+
+	text := fmt.Sprintf("Found %d TODO comments:\n\n", result.Count)
+	for _, todo := range result.Todos {
+		text += "  " + formatTodo(todo) + "\n"
+	}
+	return text
+}
+
+// formatTodo formats a TODO comment with its marker, owner and position
+func formatTodo(todo analyzer.TodoComment) string {
+	marker := todo.Marker
+	if todo.Owner != "" {
+		marker += "(" + todo.Owner + ")"
+	}
+	location := fmt.Sprintf("line %d", todo.Line)
+	if todo.File != "" {
+		location = fmt.Sprintf("%s:%d", todo.File, todo.Line)
+	}
+	return fmt.Sprintf("[%s] %s: %s", marker, location, todo.Text)
+}
+
+func formatASTJSONResult(result *analyzer.ASTJSONOutput) string {
+	text := fmt.Sprintf("Syntax tree with %d nodes", result.NodeCount)
+	if result.Truncated {
+		text += fmt.Sprintf(" (⚠️ truncated at %d nodes)", result.MaxNodes)
+	}
+	text += "; the tree itself is in the JSON result.\n\nTop-level nodes:\n"
+	for _, child := range result.Root.Children {
+		label := child.Type
+		if child.Token != "" {
+			label += " " + child.Token
+		}
+		if child.Name != "" {
+			label += " " + child.Name
+		}
+		for _, grandchild := range child.Children {
+			if grandchild.Field == "Name" {
+				label += " " + grandchild.Name
+			}
+		}
+		text += fmt.Sprintf("  %s %s (line %d)\n", child.Field, label, child.Start.Line)
+	}
+	return text
+}
+
+func formatNolintResult(result *analyzer.NolintOutput) string {
+	if result.Count == 0 {
+		return fmt.Sprintf("✅ All %d nolint directives are justified", result.DirectiveCount)
+	}
+
+	text := fmt.Sprintf("Found %d of %d nolint directives without a justification:\n\n", result.Count, result.DirectiveCount)
+	for _, d := range result.Unjustified {
+		text += fmt.Sprintf("  line %d: %s\n    %s\n", d.Line, d.Comment, d.Message)
+	}
+	return text
+}
+
+func formatVerbNamingResult(result *analyzer.VerbNamingOutput) string {
+	if result.Count == 0 {
+		return "✅ No naming suggestions"
+	}
+
+	text := fmt.Sprintf("%d naming suggestions:\n\n", result.Count)
+	for _, f := range result.Findings {
+		name := f.Function
+		if f.Receiver != "" {
+			name = f.Receiver + "." + f.Function
+		}
+		text += fmt.Sprintf("  💡 %s (line %d): %s\n", name, f.Line, f.Message)
+	}
+	return text
+}
+
+func formatDiffSymbolsResult(result *analyzer.DiffSymbolsOutput) string {
+	if len(result.Added)+len(result.Removed)+len(result.Changed) == 0 {
+		return "✅ No symbol changes"
+	}
+
+	text := fmt.Sprintf("Symbol changes: %d added, %d removed, %d changed\n",
+		len(result.Added), len(result.Removed), len(result.Changed))
+
+	if len(result.Added) > 0 {
+		text += "\nAdded:\n"
+		for _, sym := range result.Added {
+			text += fmt.Sprintf("  + %s: %s (line %d)\n", sym.Kind, symbolLabel(sym), sym.Line)
+		}
+	}
+	if len(result.Removed) > 0 {
+		text += "\nRemoved:\n"
+		for _, sym := range result.Removed {
+			text += fmt.Sprintf("  - %s: %s (line %d)\n", sym.Kind, symbolLabel(sym), sym.Line)
+		}
+	}
+	if len(result.Changed) > 0 {
+		text += "\nChanged:\n"
+		for _, change := range result.Changed {
+			text += fmt.Sprintf("  ~ %s: %s (line %d)\n", change.New.Kind, symbolLabel(change.New), change.New.Line)
+			text += fmt.Sprintf("      was %s: %s (line %d)\n", change.Old.Kind, symbolLabel(change.Old), change.Old.Line)
+		}
+	}
+
+	return text
+}
+
+// symbolLabel renders a symbol as its signature, or its name, type
+// parameters and declared type when it has none
+func symbolLabel(sym analyzer.Symbol) string {
+	if sym.Signature != "" {
+		if sym.Receiver != "" {
+			return "(" + sym.Receiver + ") " + sym.Signature
+		}
+		return sym.Signature
+	}
+	label := sym.Name + sym.TypeParams
+	if sym.TypeName != "" {
+		label += " " + sym.TypeName
+	}
+	return label
+}
+
+func formatDeferReturnResult(result *analyzer.DeferReturnOutput) string {
+	if result.Count == 0 {
+		return "✅ No deferred closures assign to named results"
+	}
+
+	text := fmt.Sprintf("Found %d assignments to named results in deferred closures:\n\n", result.Count)
+	for _, m := range result.Mutations {
+		text += fmt.Sprintf("  %s (line %d, deferred at line %d): %s\n", m.Function, m.Line, m.DeferLine, m.Message)
+	}
+
+	return text
+}
+
+func formatCallGraphResult(result *analyzer.CallGraphOutput) string {
+	text := fmt.Sprintf("Call graph for %d functions:\n\n", len(result.Functions))
+	for _, name := range result.Functions {
+		callees := result.Graph[name]
+		external := result.External[name]
+		if len(callees) == 0 && len(external) == 0 {
+			continue
+		}
+		text += fmt.Sprintf("  %s\n", name)
+		for _, callee := range callees {
+			text += fmt.Sprintf("    → %s\n", callee)
+		}
+		for _, callee := range external {
+			text += fmt.Sprintf("    → %s (external)\n", callee)
+		}
+	}
+
+	if len(result.Uncalled) > 0 {
+		text += fmt.Sprintf("\nNever called within the file: %s\n", strings.Join(result.Uncalled, ", "))
+	}
+	return text
+}
+
+func formatAssertionlessResult(result *analyzer.AssertionlessOutput) string {
+	if result.Count == 0 {
+		return fmt.Sprintf("✅ All %d test functions report failures", result.TestCount)
+	}
+
+	text := fmt.Sprintf("Found %d of %d test functions without assertions:\n\n", result.Count, result.TestCount)
+	for _, t := range result.Tests {
+		text += fmt.Sprintf("  %s (line %d): %s\n", t.Name, t.Line, t.Message)
+	}
+
+	return text
+}
+
+func formatLintResult(result *analyzer.LintCodeOutput) string {
+	return fmt.Sprintf("Backend: %s\n\n", result.Backend) + formatAnalysisResult(&analyzer.AnalyzeCodeOutput{
+		Success:      result.Success,
+		Diagnostics:  result.Diagnostics,
+		ErrorCount:   result.ErrorCount,
+		WarningCount: result.WarningCount,
+	})
+}
+
+func formatHunkMetricsResult(result *analyzer.HunkMetricsOutput) string {
+	text := fmt.Sprintf("Functions touched by %d hunks:\n\n", len(result.Hunks))
+	for _, hunk := range result.Hunks {
+		text += fmt.Sprintf("  lines %d-%d:", hunk.Range.Start, hunk.Range.End)
+		if len(hunk.Functions) == 0 {
+			text += " no functions\n"
+			continue
+		}
+		text += fmt.Sprintf(" max complexity %d\n", hunk.MaxComplexity)
+		for _, fm := range hunk.Functions {
+			name := fm.Name
+			if fm.Receiver != "" {
+				name = fm.Receiver + "." + fm.Name
+			}
+			text += fmt.Sprintf("    %s (lines %d-%d): complexity %d, cognitive %d, nesting %d\n",
+				name, fm.Line, fm.EndLine, fm.CyclomaticComplexity, fm.CognitiveComplexity, fm.MaxNestingDepth)
+		}
+	}
+
+	return text
+}
+
+func formatTestSkeletonResult(result *analyzer.TestSkeletonOutput) string {
+	if len(result.Tests) == 0 {
+		return "No exported functions or methods to generate tests for"
+	}
+
+	text := fmt.Sprintf("Generated %d tests in %s:\n\n%s", len(result.Tests), result.FileName, result.TestCode)
+	if len(result.Skipped) > 0 {
+		text += fmt.Sprintf("\nSkipped generic functions: %s\n", strings.Join(result.Skipped, ", "))
+	}
+	return text
+}
+
+func formatFatInterfaceResult(result *analyzer.FatInterfaceOutput) string {
+	if result.Count == 0 {
+		return fmt.Sprintf("✅ No interfaces with more than %d methods", result.MaxMethods)
+	}
+
+	text := fmt.Sprintf("Found %d interfaces with more than %d methods:\n\n", result.Count, result.MaxMethods)
+	for _, iface := range result.Interfaces {
+		text += fmt.Sprintf("  %s (line %d): %d methods: %s\n", iface.Name, iface.Line, iface.MethodCount, strings.Join(iface.Methods, ", "))
+		if len(iface.Embedded) > 0 {
+			text += fmt.Sprintf("    also embeds %s\n", strings.Join(iface.Embedded, ", "))
+		}
+	}
+
+	return text
+}
+
+func formatMapOrderResult(result *analyzer.MapOrderOutput) string {
+	if result.Count == 0 {
+		return "✅ No slices built from map iteration are used in an order-dependent way"
+	}
+
+	text := fmt.Sprintf("Found %d slices whose order depends on map iteration:\n\n", result.Count)
+	for _, d := range result.Dependencies {
+		text += fmt.Sprintf("  %s (line %d, %s at line %d): %s\n", d.Function, d.Line, d.Use, d.UseLine, d.Message)
+	}
+	text += "\n" + result.Note + "\n"
+
+	return text
+}
+
+func formatRenameResult(result *analyzer.RenameSymbolOutput) string {
+	return fmt.Sprintf("✅ Renamed %s declared at line %d (%d occurrences)\n\n%s",
+		result.Kind, result.Line, result.Occurrences, result.Code)
+}
+
+func formatRiskScoreResult(result *analyzer.RiskScoreOutput) string {
+	if result.FunctionCount == 0 {
+		return "No functions to score"
+	}
+
+	text := fmt.Sprintf("Top %d of %d functions by risk:\n\n", len(result.Functions), result.FunctionCount)
+	for _, fr := range result.Functions {
+		name := fr.Name
+		if fr.Receiver != "" {
+			name = fr.Receiver + "." + fr.Name
+		}
+		text += fmt.Sprintf("  %5.1f  %s (line %d): mostly %s; complexity=%d, cognitive=%d, nesting=%d, sloc=%d, params=%d\n",
+			fr.Score, name, fr.Line, fr.MainFactor, fr.CyclomaticComplexity, fr.CognitiveComplexity,
+			fr.MaxNestingDepth, fr.SourceLinesOfCode, fr.ParameterCount)
+	}
+
+	return text
+}
+
+func formatShadowResult(result *analyzer.ShadowOutput) string {
+	if result.Count == 0 {
+		return "✅ No shadowed variables found"
+	}
+
+	text := fmt.Sprintf("Found %d shadowed variables:\n\n", result.Count)
+	for _, s := range result.Shadows {
+		location := fmt.Sprintf("line %d", s.Line)
+		if s.Function != "" {
+			location = fmt.Sprintf("%s, line %d", s.Function, s.Line)
+		}
+		text += fmt.Sprintf("  %s (%s): %s\n", s.Name, location, s.Message)
+	}
+
+	return text
+}
+
+func formatUncheckedErrorsResult(result *analyzer.UncheckedErrorsOutput) string {
+	text := "✅ No unchecked errors found\n"
+	if result.Count > 0 {
+		text = fmt.Sprintf("Found %d unchecked errors:\n\n", result.Count)
+		for _, u := range result.Unchecked {
+			text += fmt.Sprintf("  line %d, column %d: %s\n", u.Line, u.Column, u.Message)
+		}
+	}
+	if len(result.UnresolvedImports) > 0 {
+		text += fmt.Sprintf("\nCalls into %s were not checked\n", strings.Join(result.UnresolvedImports, ", "))
+	}
+
+	return text
+}
+
+func formatDumpASTResult(result *analyzer.DumpASTOutput) string {
+	if result.OmittedLines > 0 {
+		return fmt.Sprintf("%s\n%d of %d lines nested deeper than %d levels omitted\n",
+			result.Dump, result.OmittedLines, result.Lines, result.MaxDepth)
+	}
+	return result.Dump
+}
+
+func formatLongFunctionsResult(result *analyzer.LongFunctionsOutput) string {
+	if result.Count == 0 {
+		return fmt.Sprintf("✅ No functions longer than %d lines", result.MaxLines)
+	}
+
+	text := fmt.Sprintf("Found %d functions longer than %d lines:\n\n", result.Count, result.MaxLines)
+	for _, fn := range result.Functions {
+		name := fn.Name
+		if fn.Receiver != "" {
+			name = fn.Receiver + "." + fn.Name
+		}
+		text += fmt.Sprintf("  %s (lines %d-%d): %d lines\n", name, fn.Line, fn.EndLine, fn.Lines)
+	}
+
+	return text
+}
+
+func formatScanSecurityResult(result *analyzer.ScanSecurityOutput) string {
+	if result.Count == 0 {
+		return "✅ No insecure patterns found\n"
+	}
+
+	text := fmt.Sprintf("Found %d potential security issues:\n\n", result.Count)
+	for _, f := range result.Findings {
+		text += fmt.Sprintf("  [%s %s] line %d, column %d: %s\n", f.RuleID, f.Severity, f.Line, f.Column, f.Message)
+	}
+
+	return text
+}
+
+func formatImplementsResult(result *analyzer.ImplementsOutput) string {
+	if result.Implements {
+		return fmt.Sprintf("✅ %s implements %s\n", result.TypeName, result.InterfaceName)
+	}
+
+	text := fmt.Sprintf("❌ %s does not implement %s:\n\n", result.TypeName, result.InterfaceName)
+	for _, m := range result.Missing {
+		text += fmt.Sprintf("  %s\n", m.Message)
+	}
+	if result.PointerImplements {
+		text += fmt.Sprintf("\n*%s implements %s\n", strings.TrimPrefix(result.TypeName, "*"), result.InterfaceName)
+	}
+
+	return text
+}
+
+func formatDocCommentsResult(result *analyzer.DocCommentsOutput) string {
+	if result.Count == 0 {
+		return "No exported symbols found\n"
+	}
+
+	text := fmt.Sprintf("Found %d exported symbols (%d undocumented):\n\n", result.Count, result.UndocumentedCount)
+	for _, sym := range result.Symbols {
+		name := sym.Name
+		if sym.Receiver != "" {
+			name = sym.Receiver + "." + sym.Name
+		}
+		if sym.Doc == "" {
+			text += fmt.Sprintf("%s %s (line %d): undocumented\n\n", sym.Kind, name, sym.Line)
+			continue
+		}
+		text += fmt.Sprintf("%s %s (line %d):\n", sym.Kind, name, sym.Line)
+		for _, line := range strings.Split(sym.Doc, "\n") {
+			if line != "" {
+				line = "    " + line
+			}
+			text += line + "\n"
+		}
+		text += "\n"
+	}
+	return text
+}
+
+func formatApplyFixesResult(result *analyzer.ApplyFixesOutput) string {
+	text := ""
+	if !result.Changed {
+		text = "✅ No suggested fixes to apply\n"
+	} else {
+		text = fmt.Sprintf("Applied %d fixes:\n\n", result.Count)
+		for _, fix := range result.Fixes {
+			text += fmt.Sprintf("  line %d [%s]: %s\n", fix.Line, fix.Analyzer, fix.Message)
+			if fix.Fix != "" {
+				text += fmt.Sprintf("    fix: %s\n", fix.Fix)
+			}
+		}
+	}
+	if len(result.Skipped) > 0 {
+		text += fmt.Sprintf("\nSkipped %d overlapping fixes; run again to apply them:\n", len(result.Skipped))
+		for _, fix := range result.Skipped {
+			text += fmt.Sprintf("  line %d [%s]: %s\n", fix.Line, fix.Analyzer, fix.Message)
+		}
+	}
+	if len(result.AnalyzerErrors) > 0 {
+		text += "\nAnalyzers that failed:\n"
+		for _, msg := range result.AnalyzerErrors {
+			text += "  " + msg + "\n"
+		}
+	}
+	if len(result.UnresolvedImports) > 0 {
+		text += fmt.Sprintf("\nUnresolved imports: %s\n", strings.Join(result.UnresolvedImports, ", "))
+	}
+	if result.Diff != "" {
+		text += "\n" + result.Diff
+	}
+	return text
+}
+
+func formatImportGraphResult(result *analyzer.ImportGraphOutput) string {
+	text := fmt.Sprintf("Package %s: %d files import %d packages (%d from a single file, %d pervasive)\n\n",
+		result.PackageName, result.FileCount, result.PackageCount, result.SingleFileCount, result.PervasiveCount)
+	text += "Packages:\n"
+	for _, pkg := range result.Packages {
+		if pkg.Usage == "single" {
+			text += fmt.Sprintf("  %s (%s): single, only in %s\n", pkg.Path, pkg.Kind, pkg.Files[0])
+			continue
+		}
+		text += fmt.Sprintf("  %s (%s): %s, in %d files\n", pkg.Path, pkg.Kind, pkg.Usage, pkg.Count)
+	}
+	text += "\nFiles:\n"
+	for _, file := range result.Files {
+		if len(file.Imports) == 0 {
+			text += fmt.Sprintf("  %s: no imports\n", file.File)
+			continue
+		}
+		text += fmt.Sprintf("  %s: %s\n", file.File, strings.Join(file.Imports, ", "))
+	}
+	return text
+}
+
+func formatCompareSymbolsResult(result *analyzer.CompareSymbolsOutput) string {
+	if len(result.Groups) == 0 {
+		return "✅ The exported API is unchanged"
+	}
+
+	text := fmt.Sprintf("Exported API: %d added, %d removed, %d changed\n", result.AddedCount, result.RemovedCount, result.ChangedCount)
+	if result.Breaking {
+		text += fmt.Sprintf("❌ %d breaking changes\n", result.BreakingCount)
+	} else {
+		text += "✅ No breaking changes\n"
+	}
+	marker := func(change analyzer.APIChange) string {
+		if change.Breaking {
+			return fmt.Sprintf(" ❌ %s", change.Reason)
+		}
+		return ""
+	}
+	for _, group := range result.Groups {
+		text += fmt.Sprintf("\n%s:\n", group.Kind)
+		for _, change := range group.Removed {
+			text += fmt.Sprintf("  - %s (old line %d)%s\n", change.Old, change.Line, marker(change))
+		}
+		for _, change := range group.Changed {
+			text += fmt.Sprintf("  ~ %s (line %d)%s\n      was %s\n", change.New, change.Line, marker(change), change.Old)
+		}
+		for _, change := range group.Added {
+			text += fmt.Sprintf("  + %s (line %d)\n", change.New, change.Line)
+		}
+	}
+	return text
+}
+
+func formatStyleCheckResult(result *analyzer.StyleCheckOutput) string {
+	if result.Count == 0 {
+		return fmt.Sprintf("✅ No style findings (rules: %s)\n", strings.Join(result.Rules, ", "))
+	}
+
+	text := fmt.Sprintf("Found %d style findings (rules: %s):\n\n", result.Count, strings.Join(result.Rules, ", "))
+	for _, f := range result.Findings {
+		text += fmt.Sprintf("  [%s] line %d, column %d: %s\n", f.RuleID, f.Line, f.Column, f.Message)
+	}
+
+	return text
+}
+
+func formatAnalyzeAllResult(result *analyzer.AnalyzeAllOutput) string {
+	text := "## go vet\n\n" + formatAnalysisResult(result.Vet) + "\n\n## Formatting\n\n"
+	if result.Format.Changed {
+		text += "⚠️ gofmt would change the code; run format_code to get the formatted file\n"
+	} else {
+		text += "✅ Code is already formatted\n"
+	}
+	text += "\n## Symbols\n\n" + formatSymbolsResult(result.Symbols)
+	text += "\n## Metrics\n\n" + formatMetricsResult(result.Metrics)
+	return text
+}
+
+func formatFindShadowedResult(result *analyzer.FindShadowedOutput) string {
+	if result.Count == 0 {
+		return "✅ No shadowed variables found"
+	}
+
+	text := fmt.Sprintf("Found %d shadowed variables:\n\n", result.Count)
+	for _, s := range result.Shadows {
+		text += fmt.Sprintf("  %s at line %d shadows line %d\n", s.Name, s.Line, s.ShadowedAtLine)
+	}
+
+	return text
+}
+
+func formatPackageInfoResult(result *analyzer.PackageInfoOutput) string {
+	text := fmt.Sprintf("Package %s\n\nFound %d imports:\n\n", result.PackageName, len(result.Imports))
+	for _, imp := range result.Imports {
+		line := fmt.Sprintf("  %s", imp.Path)
+		switch imp.Alias {
+		case "":
+		case ".":
+			line += " (dot import)"
+		case "_":
+			line += " (blank import)"
+		default:
+			line += fmt.Sprintf(" as %s", imp.Alias)
+		}
+		text += fmt.Sprintf("%s (line %d)\n", line, imp.Line)
+	}
+	return text
+}
@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jorda/go-analyzer-mcp/analyzer"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connect registers the tools on a server and returns a client session
+// connected to it in memory
+func connect(t *testing.T) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1"}, nil)
+	RegisterTools(server)
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "1"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func TestToolResultsCarryJSON(t *testing.T) {
+	session := connect(t)
+	const code = "package p\n\n// F does nothing\nfunc F() {}\n"
+
+	tests := []struct {
+		tool   string
+		format string
+		text   string // Expected in the text block
+		blocks int
+	}{
+		{tool: "get_symbols", text: "F", blocks: 2},
+		{tool: "calculate_metrics", text: "Function", blocks: 2},
+		{tool: "check_format", text: "gofmt-clean", blocks: 2},
+		{tool: "check_formatted", text: "gofmt-clean", blocks: 2},
+		{tool: "find_shadowed", text: "No shadowed variables", blocks: 2},
+		{tool: "get_package_info", text: "Package p", blocks: 2},
+		{tool: "get_symbols", format: "text", text: "F", blocks: 1},
+		{tool: "get_symbols", format: "json", blocks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+tt.format, func(t *testing.T) {
+			args := map[string]any{"code": code}
+			if tt.format != "" {
+				args["format"] = tt.format
+			}
+			res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: tt.tool, Arguments: args})
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if res.IsError {
+				t.Fatalf("tool error: %v", res.Content)
+			}
+			if len(res.Content) != tt.blocks {
+				t.Fatalf("got %d content blocks, want %d", len(res.Content), tt.blocks)
+			}
+			if tt.text != "" && !strings.Contains(res.Content[0].(*mcp.TextContent).Text, tt.text) {
+				t.Errorf("text %q does not mention %q", res.Content[0].(*mcp.TextContent).Text, tt.text)
+			}
+			if tt.format == "text" {
+				return
+			}
+
+			// The last block is the structured result, encoded as JSON
+			var fromText any
+			last := res.Content[len(res.Content)-1].(*mcp.TextContent).Text
+			if err := json.Unmarshal([]byte(last), &fromText); err != nil {
+				t.Fatalf("last block is not JSON: %v", err)
+			}
+			if !reflect.DeepEqual(fromText, res.StructuredContent) {
+				t.Errorf("JSON block %v differs from the structured content %v", fromText, res.StructuredContent)
+			}
+		})
+	}
+}
+
+// decodeResult decodes the JSON block of a tool result into out, failing on
+// fields that out does not have
+func decodeResult(t *testing.T, res *mcp.CallToolResult, out any) {
+	t.Helper()
+	last := res.Content[len(res.Content)-1].(*mcp.TextContent).Text
+	decoder := json.NewDecoder(strings.NewReader(last))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		t.Fatalf("JSON block does not decode into %T: %v", out, err)
+	}
+}
+
+func TestToolResultsRoundTrip(t *testing.T) {
+	session := connect(t)
+	const code = "package p\n\n// F does nothing\nfunc F() {}\n"
+	call := func(tool string) *mcp.CallToolResult {
+		t.Helper()
+		res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: tool, Arguments: map[string]any{"code": code}})
+		if err != nil {
+			t.Fatalf("CallTool(%s): %v", tool, err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: tool error: %v", tool, res.Content)
+		}
+		return res
+	}
+
+	t.Run("get_symbols", func(t *testing.T) {
+		var got analyzer.GetSymbolsOutput
+		decodeResult(t, call("get_symbols"), &got)
+		want, err := analyzer.GetSymbols(analyzer.GetSymbolsInput{Code: code})
+		if err != nil {
+			t.Fatalf("GetSymbols: %v", err)
+		}
+		if len(got.Symbols) != 1 || got.Symbols[0].Name != "F" || got.Symbols[0].Kind != "function" {
+			t.Errorf("Symbols = %+v, want function F", got.Symbols)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("decoded result %+v differs from GetSymbols %+v", got, *want)
+		}
+	})
+
+	t.Run("calculate_metrics", func(t *testing.T) {
+		var got analyzer.CalculateMetricsOutput
+		decodeResult(t, call("calculate_metrics"), &got)
+		want, err := analyzer.CalculateMetrics(analyzer.CalculateMetricsInput{Code: code})
+		if err != nil {
+			t.Fatalf("CalculateMetrics: %v", err)
+		}
+		if got.Metrics == nil || got.Metrics.FunctionCount != 1 || len(got.FunctionMetrics) != 1 || got.FunctionMetrics[0].Name != "F" {
+			t.Errorf("decoded result = %+v, want the metrics of F", got)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("decoded result %+v differs from CalculateMetrics %+v", got, *want)
+		}
+	})
+}
+
+func TestToolErrors(t *testing.T) {
+	session := connect(t)
+	tests := []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{tool: "get_symbols", args: map[string]any{"code": "package p\nfunc {"}, want: "syntax error"},
+		{tool: "check_formatted", args: map[string]any{"code": "package p\nfunc {"}, want: "expected"},
+		{tool: "get_symbols", args: map[string]any{"filePath": "../outside.go"}, want: "outside the source root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: tt.tool, Arguments: tt.args})
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if !res.IsError {
+				t.Fatal("tool succeeded, want an error")
+			}
+			if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, tt.want) {
+				t.Errorf("error %q does not mention %q", text, tt.want)
+			}
+		})
+	}
+}